@@ -0,0 +1,253 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+const (
+	// MaxConcurrentTranscoders is the driver config of the maximum number of devices streaming at the same time,
+	// 0 means no limit
+	MaxConcurrentTranscoders = "MaxConcurrentTranscoders"
+	// UsbBandwidthBudgetPercent is the driver config of the share of the speed of a USB bus the streams of the
+	// cameras on the bus may use, 0 disables the check
+	UsbBandwidthBudgetPercent        = "UsbBandwidthBudgetPercent"
+	DefaultUsbBandwidthBudgetPercent = 80
+)
+
+// bytesPerPixel are the average sizes of a pixel of the v4l2 pixel formats, the sizes of the compressed formats
+// are estimates of a typical frame
+var bytesPerPixel = map[uint32]float64{
+	v4l2.PixelFmtYUYV:  2,
+	v4l2.PixelFmtYYUV:  2,
+	v4l2.PixelFmtYVYU:  2,
+	v4l2.PixelFmtUYVY:  2,
+	v4l2.PixelFmtVYUY:  2,
+	v4l2.PixelFmtRGB24: 3,
+	v4l2.PixelFmtGrey:  1,
+	v4l2.PixelFmtMJPEG: 0.25,
+	v4l2.PixelFmtJPEG:  0.25,
+	v4l2.PixelFmtH264:  0.1,
+}
+
+// defaultBytesPerPixel is the size of a pixel of the pixel formats missing from bytesPerPixel
+const defaultBytesPerPixel = 2
+
+// admissionConfig limits the transcoders started by the driver
+type admissionConfig struct {
+	MaxTranscoders         int
+	BandwidthBudgetPercent int
+}
+
+// parseAdmissionConfig parses the admission control of the driver configs
+func parseAdmissionConfig(configs map[string]string) (admissionConfig, error) {
+	config := admissionConfig{BandwidthBudgetPercent: DefaultUsbBandwidthBudgetPercent}
+	if value := strings.TrimSpace(configs[MaxConcurrentTranscoders]); value != "" {
+		maxTranscoders, err := strconv.Atoi(value)
+		if err != nil || maxTranscoders < 0 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be a non-negative integer", MaxConcurrentTranscoders, value)
+		}
+		config.MaxTranscoders = maxTranscoders
+	}
+	if value := strings.TrimSpace(configs[UsbBandwidthBudgetPercent]); value != "" {
+		percent, err := strconv.Atoi(value)
+		if err != nil || percent < 0 || percent > 100 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be an integer between 0 and 100", UsbBandwidthBudgetPercent, value)
+		}
+		config.BandwidthBudgetPercent = percent
+	}
+	return config, nil
+}
+
+// StreamBandwidth is the estimated USB bandwidth of the stream of a device
+type StreamBandwidth struct {
+	// Bus is the number of the USB bus of the camera
+	Bus string `json:"bus"`
+	// BytesPerSecond is the estimated size of the frames captured per second
+	BytesPerSecond float64 `json:"bytesPerSecond"`
+	// Format is the capture format the estimate is computed from, e.g. "YUYV 1920x1080 at 30 fps"
+	Format string `json:"format"`
+}
+
+// usbBusOf returns the USB bus of the bus path of a device, e.g. 1 for 1-1.2
+func usbBusOf(busPath string) string {
+	bus, _, _ := strings.Cut(busPath, "-")
+	return bus
+}
+
+// usbBusSpeed returns the speed of the USB bus in Mbit/s, e.g. 480 for USB 2.0
+func (d *Driver) usbBusSpeed(bus string) (float64, error) {
+	sysfsRoot := d.sysfsRoot
+	if sysfsRoot == "" {
+		sysfsRoot = DefaultSysfsRoot
+	}
+	dir := filepath.Join(sysfsRoot, "bus", "usb", "devices", "usb"+bus)
+	value := readSysfsAttribute(dir, "speed")
+	speed, err := strconv.ParseFloat(value, 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("unknown speed of USB bus %s in %s", bus, dir)
+	}
+	return speed, nil
+}
+
+// currentCaptureFormat returns the current pixel format and frame rate of the camera, it is a variable so that the
+// tests do not need a camera
+var currentCaptureFormat = getCurrentCaptureFormat
+
+// estimateStreamBandwidth estimates the USB bandwidth of the stream of the device from the input options of the
+// streaming, the current capture format of the camera is used for the options which are not set
+func (d *Driver) estimateStreamBandwidth(device *Device) (*StreamBandwidth, error) {
+	if device.busPath == "" {
+		return nil, fmt.Errorf("the USB bus of device %s is unknown", device.name)
+	}
+	status := device.status()
+
+	var pixelFormat uint32
+	var width, height uint32
+	var fps float64
+	var err error
+	if status.InputPixelFormat != "" {
+		if formats := FFmpegPixelFormatV4l2Mappings[status.InputPixelFormat]; len(formats) > 0 {
+			pixelFormat = formats[0]
+		}
+	}
+	if status.InputImageSize != "" {
+		if width, height, err = parseFrameSize(status.InputImageSize); err != nil {
+			return nil, err
+		}
+	}
+	if status.InputFps != "" {
+		if fps, err = parseFrameRate(status.InputFps); err != nil {
+			return nil, err
+		}
+	}
+	if pixelFormat == 0 || width == 0 || fps == 0 {
+		pixFmt, frameRate, err := currentCaptureFormat(status.TranscoderInputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the capture format of device %s: %w", device.name, err)
+		}
+		if pixelFormat == 0 {
+			pixelFormat = pixFmt.PixelFormat
+		}
+		if width == 0 {
+			width, height = pixFmt.Width, pixFmt.Height
+		}
+		if fps == 0 && frameRate.Denominator != 0 {
+			fps = float64(frameRate.Numerator) / float64(frameRate.Denominator)
+		}
+	}
+	if width == 0 || height == 0 || fps <= 0 {
+		return nil, fmt.Errorf("the capture format of device %s is unknown", device.name)
+	}
+
+	size, ok := bytesPerPixel[pixelFormat]
+	if !ok {
+		size = defaultBytesPerPixel
+	}
+	return &StreamBandwidth{
+		Bus:            usbBusOf(device.busPath),
+		BytesPerSecond: float64(width) * float64(height) * size * fps,
+		Format:         fmt.Sprintf("%s %dx%d at %s fps", fourCCString(pixelFormat), width, height, strconv.FormatFloat(fps, 'f', -1, 64)),
+	}, nil
+}
+
+// admitStreaming checks that starting the streaming of the device keeps the transcoders within the limit and the
+// streams of its USB bus within the bandwidth budget. On success the caller must call the returned release
+// function once the transcoder is started, so that the concurrent starts are checked one after the other.
+func (d *Driver) admitStreaming(device *Device) (func(), errors.EdgeX) {
+	d.admissionMutex.Lock()
+
+	var streaming []*Device
+	for _, other := range d.devices.list() {
+		if other != device && other.isStreaming() {
+			streaming = append(streaming, other)
+		}
+	}
+	if maxTranscoders := d.admissionConfig.MaxTranscoders; maxTranscoders > 0 && len(streaming) >= maxTranscoders {
+		d.admissionMutex.Unlock()
+		return nil, errors.NewCommonEdgeX(errors.KindLimitExceeded, fmt.Sprintf(
+			"cannot start streaming for device %s, the limit of %d concurrent transcoders set by %s is reached by devices %s",
+			device.name, maxTranscoders, MaxConcurrentTranscoders, deviceNames(streaming)), nil)
+	}
+
+	if d.admissionConfig.BandwidthBudgetPercent == 0 {
+		return d.admissionMutex.Unlock, nil
+	}
+	bandwidth, err := d.estimateStreamBandwidth(device)
+	if err != nil {
+		d.lc.Warnf("Skipping the USB bandwidth check of device %s: %s", device.name, err.Error())
+		device.setStreamBandwidth(nil)
+		return d.admissionMutex.Unlock, nil
+	}
+	speed, err := d.usbBusSpeed(bandwidth.Bus)
+	if err != nil {
+		d.lc.Warnf("Skipping the USB bandwidth check of device %s: %s", device.name, err.Error())
+		device.setStreamBandwidth(bandwidth)
+		return d.admissionMutex.Unlock, nil
+	}
+
+	var used float64
+	var sharing []*Device
+	for _, other := range streaming {
+		if otherBandwidth := other.streamBandwidth(); otherBandwidth != nil && otherBandwidth.Bus == bandwidth.Bus {
+			used += otherBandwidth.BytesPerSecond
+			sharing = append(sharing, other)
+		}
+	}
+	budget := speed * 1e6 / 8 * float64(d.admissionConfig.BandwidthBudgetPercent) / 100
+	if used+bandwidth.BytesPerSecond > budget {
+		d.admissionMutex.Unlock()
+		message := fmt.Sprintf("cannot start streaming for device %s, its estimated bandwidth of %.1f MB/s (%s)",
+			device.name, bandwidth.BytesPerSecond/1e6, bandwidth.Format)
+		if len(sharing) > 0 {
+			message += fmt.Sprintf(" added to the %.1f MB/s of devices %s", used/1e6, deviceNames(sharing))
+		}
+		message += fmt.Sprintf(" exceeds the budget of %.1f MB/s of USB bus %s (%d%% of %s Mbit/s set by %s)",
+			budget/1e6, bandwidth.Bus, d.admissionConfig.BandwidthBudgetPercent,
+			strconv.FormatFloat(speed, 'f', -1, 64), UsbBandwidthBudgetPercent)
+		return nil, errors.NewCommonEdgeX(errors.KindLimitExceeded, message, nil)
+	}
+	device.setStreamBandwidth(bandwidth)
+	return d.admissionMutex.Unlock, nil
+}
+
+// rejectStreaming reports the admission error in the StreamingStatus of the device
+func (d *Driver) rejectStreaming(device *Device, edgexErr errors.EdgeX) {
+	device.mutex.Lock()
+	device.streamingStatus.Error = edgexErr.Message()
+	device.mutex.Unlock()
+	go d.publishStreamingStatus(device)
+}
+
+// streamBandwidth returns the estimated bandwidth of the stream of the device when it was admitted
+func (dev *Device) streamBandwidth() *StreamBandwidth {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	return dev.bandwidth
+}
+
+func (dev *Device) setStreamBandwidth(bandwidth *StreamBandwidth) {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	dev.bandwidth = bandwidth
+}
+
+// deviceNames returns the names of the devices for the error messages
+func deviceNames(devices []*Device) string {
+	names := make([]string, len(devices))
+	for i, device := range devices {
+		names[i] = device.name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,176 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// fakeUsbBuses creates a sysfs tree with the USB buses of the speeds in Mbit/s
+func fakeUsbBuses(t *testing.T, speeds map[string]string) string {
+	sysfsRoot := t.TempDir()
+	for bus, speed := range speeds {
+		dir := filepath.Join(sysfsRoot, "bus", "usb", "devices", "usb"+bus)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "speed"), []byte(speed+"\n"), 0644))
+	}
+	return sysfsRoot
+}
+
+// fakeCaptureFormat replaces the current capture format of the cameras for the test
+func fakeCaptureFormat(t *testing.T, pixFmt v4l2.PixFormat, fps uint32, err error) {
+	original := currentCaptureFormat
+	currentCaptureFormat = func(string) (v4l2.PixFormat, v4l2.Fract, error) {
+		return pixFmt, v4l2.Fract{Numerator: fps, Denominator: 1}, err
+	}
+	t.Cleanup(func() { currentCaptureFormat = original })
+}
+
+// setInputOptions sets the input options of the streaming of the device
+func setInputOptions(dev *Device, pixelFormat, imageSize, fps string) {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	dev.streamingStatus.InputPixelFormat = pixelFormat
+	dev.streamingStatus.InputImageSize = imageSize
+	dev.streamingStatus.InputFps = fps
+}
+
+func TestParseAdmissionConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		expected  admissionConfig
+		expectErr bool
+	}{
+		{"defaults", map[string]string{}, admissionConfig{BandwidthBudgetPercent: DefaultUsbBandwidthBudgetPercent}, false},
+		{"limits", map[string]string{MaxConcurrentTranscoders: "4", UsbBandwidthBudgetPercent: "60"}, admissionConfig{4, 60}, false},
+		{"disabled", map[string]string{MaxConcurrentTranscoders: "0", UsbBandwidthBudgetPercent: "0"}, admissionConfig{0, 0}, false},
+		{"negative transcoders", map[string]string{MaxConcurrentTranscoders: "-1"}, admissionConfig{}, true},
+		{"invalid transcoders", map[string]string{MaxConcurrentTranscoders: "many"}, admissionConfig{}, true},
+		{"percent over 100", map[string]string{UsbBandwidthBudgetPercent: "120"}, admissionConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseAdmissionConfig(tt.configs)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
+
+func TestEstimateStreamBandwidth(t *testing.T) {
+	installFakeFFmpeg(t)
+	fakeCaptureFormat(t, v4l2.PixFormat{PixelFormat: v4l2.PixelFmtMJPEG, Width: 1280, Height: 720}, 30, nil)
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.busPath = "3-1.4"
+
+	bandwidth, err := d.estimateStreamBandwidth(dev)
+	require.NoError(t, err)
+	assert.Equal(t, "3", bandwidth.Bus)
+	assert.Equal(t, "MJPG 1280x720 at 30 fps", bandwidth.Format, "the current capture format of the camera is used")
+	assert.InDelta(t, 1280*720*0.25*30, bandwidth.BytesPerSecond, 1)
+
+	setInputOptions(dev, FFmpegPixelFmtYUYV, "1920x1080", "15")
+	bandwidth, err = d.estimateStreamBandwidth(dev)
+	require.NoError(t, err)
+	assert.Equal(t, "YUYV 1920x1080 at 15 fps", bandwidth.Format)
+	assert.InDelta(t, 1920*1080*2*15, bandwidth.BytesPerSecond, 1)
+
+	setInputOptions(dev, "", "hd720", "")
+	bandwidth, err = d.estimateStreamBandwidth(dev)
+	require.NoError(t, err)
+	assert.Equal(t, "MJPG 1280x720 at 30 fps", bandwidth.Format, "the options which are not set are taken from the camera")
+
+	dev.busPath = ""
+	_, err = d.estimateStreamBandwidth(dev)
+	assert.Error(t, err)
+}
+
+func TestAdmitStreamingUsbBandwidth(t *testing.T) {
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	fakeCaptureFormat(t, v4l2.PixFormat{}, 0, fmt.Errorf("no camera"))
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	startFakeRTSPAuthServer(t, d)
+	d.sysfsRoot = fakeUsbBuses(t, map[string]string{"1": "480", "2": "5000"})
+	d.admissionConfig = admissionConfig{BandwidthBudgetPercent: DefaultUsbBandwidthBudgetPercent}
+
+	// two YUYV 1080p cameras at 10 fps need 83 MB/s, more than the 48 MB/s budget of a USB 2.0 bus
+	first := addFakeStreamingDevice(t, d, "first")
+	first.busPath = "1-1.1"
+	setInputOptions(first, FFmpegPixelFmtYUYV, "1920x1080", "10")
+	second := addFakeStreamingDevice(t, d, "second")
+	second.busPath = "1-1.2"
+	setInputOptions(second, FFmpegPixelFmtYUYV, "1920x1080", "10")
+	small := addFakeStreamingDevice(t, d, "small")
+	small.busPath = "1-1.3"
+	setInputOptions(small, FFmpegPixelFmtMJPEG, "640x480", "30")
+	other := addFakeStreamingDevice(t, d, "other")
+	other.busPath = "2-1"
+	setInputOptions(other, FFmpegPixelFmtYUYV, "1920x1080", "10")
+	unknown := addFakeStreamingDevice(t, d, "unknown")
+	unknown.busPath = "1-2"
+
+	require.NoError(t, d.startStreaming(first))
+	edgexErr := d.startStreaming(second)
+	require.Error(t, edgexErr)
+	assert.Equal(t, errors.KindLimitExceeded, errors.Kind(edgexErr))
+	assert.Contains(t, edgexErr.Error(), "USB bus 1")
+	assert.Contains(t, edgexErr.Error(), "devices first")
+	assert.False(t, second.isStreaming())
+	assert.Contains(t, second.status().Error, "USB bus 1", "the rejection is reported in the streaming status")
+
+	require.NoError(t, d.startStreaming(small), "the compressed stream fits in the rest of the budget")
+	require.NoError(t, d.startStreaming(other), "the budgets of the buses are separate")
+	require.NoError(t, d.startStreaming(unknown), "the devices of unknown bandwidth are not rejected")
+	assert.Nil(t, unknown.streamBandwidth())
+	state := first.streamingState(d.rtspServerMode, first.streamStartedAt)
+	require.NotNil(t, state.Bandwidth)
+	assert.Equal(t, "1", state.Bandwidth.Bus)
+
+	// the bandwidth of the stopped streams is released
+	first.StopStreaming()
+	require.NoError(t, d.startStreaming(second))
+
+	for _, dev := range []*Device{second, small, other, unknown} {
+		dev.StopStreaming()
+	}
+	require.NoError(t, d.Stop(false))
+}
+
+func TestAdmitStreamingMaxTranscoders(t *testing.T) {
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	startFakeRTSPAuthServer(t, d)
+	d.admissionConfig = admissionConfig{MaxTranscoders: 1}
+	first := addFakeStreamingDevice(t, d, "first")
+	second := addFakeStreamingDevice(t, d, "second")
+
+	require.NoError(t, d.startStreaming(first))
+	edgexErr := d.startStreaming(second)
+	require.Error(t, edgexErr)
+	assert.Equal(t, errors.KindLimitExceeded, errors.Kind(edgexErr))
+	assert.Contains(t, edgexErr.Error(), MaxConcurrentTranscoders)
+	assert.False(t, second.isStreaming())
+
+	first.StopStreaming()
+	require.NoError(t, d.startStreaming(second))
+	second.StopStreaming()
+	require.NoError(t, d.Stop(false))
+}
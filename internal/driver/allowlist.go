@@ -0,0 +1,119 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+)
+
+// parseViewerNetworks parses a list of CIDR ranges or IP addresses, given as a comma separated string or as a
+// list of strings. An empty list allows every viewer.
+func parseViewerNetworks(value any) ([]*net.IPNet, error) {
+	var entries []string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		entries = strings.Split(v, ",")
+	case []string:
+		entries = v
+	case []any:
+		for _, entry := range v {
+			entries = append(entries, fmt.Sprint(entry))
+		}
+	default:
+		return nil, fmt.Errorf("%v is not a list of CIDR ranges", value)
+	}
+
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%s is neither a CIDR range nor an IP address", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s is neither a CIDR range nor an IP address", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// viewerAllowed returns whether the IP is in one of the networks, every IP is allowed if there is no network
+func viewerAllowed(networks []*net.IPNet, ip string) bool {
+	if len(networks) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceViewerNetworks returns the networks allowed to read the stream of a device, which are set by its
+// ViewerAllowedCidrs protocol property, or by the driver config otherwise
+func (d *Driver) deviceViewerNetworks(name string, protocols map[string]models.ProtocolProperties) ([]*net.IPNet, errors.EdgeX) {
+	value, ok := protocols[UsbProtocol][ViewerAllowedCidrs]
+	if !ok || fmt.Sprint(value) == "" {
+		return d.defaultViewerNetworks, nil
+	}
+	networks, err := parseViewerNetworks(value)
+	if err != nil {
+		return nil, errors.NewCommonEdgeX(errors.KindContractInvalid,
+			fmt.Sprintf("invalid %s protocol property of device %s", ViewerAllowedCidrs, name), err)
+	}
+	return networks, nil
+}
+
+// viewerNetworks returns the networks allowed to read the stream path, the paths of unknown devices are
+// restricted by the driver config
+func (d *Driver) viewerNetworks(streamPath string) []*net.IPNet {
+	name, ok := streamDeviceName(streamPath)
+	if !ok {
+		return d.defaultViewerNetworks
+	}
+	device, ok := d.devices.get(name)
+	if !ok {
+		return d.defaultViewerNetworks
+	}
+	return device.viewerNetworks
+}
+
+// localServiceRead returns whether the request reads a stream from the local host with the credentials of the RTSP
+// service user, as the driver does to take the snapshots of the streams. These reads are allowed whatever the viewer
+// networks are.
+func (d *Driver) localServiceRead(authRequest RTSPAuthRequest) bool {
+	ip := net.ParseIP(authRequest.IP)
+	if ip == nil || !ip.IsLoopback() || authRequest.User == "" || authRequest.Password == "" {
+		return false
+	}
+	credential, edgexErr := d.tryGetCredentials(RtspAuthSecretName)
+	return edgexErr == nil && credentialsMatch(credential, authRequest.User, authRequest.Password)
+}
@@ -0,0 +1,104 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseViewerNetworks(t *testing.T, value any) []*net.IPNet {
+	networks, err := parseViewerNetworks(value)
+	require.NoError(t, err)
+	return networks
+}
+
+func TestParseViewerNetworks(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     any
+		expected  []string
+		expectErr bool
+	}{
+		{"empty", "", nil, false},
+		{"none", nil, nil, false},
+		{"string", " 10.10.0.0/24, 127.0.0.1 ,fd00::/8", []string{"10.10.0.0/24", "127.0.0.1/32", "fd00::/8"}, false},
+		{"list", []any{"10.10.0.0/24", "::1"}, []string{"10.10.0.0/24", "::1/128"}, false},
+		{"strings", []string{"192.168.1.7/16"}, []string{"192.168.0.0/16"}, false},
+		{"invalid range", "10.10.0.0/33", nil, true},
+		{"invalid ip", "10.10.0", nil, true},
+		{"invalid type", 10, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networks, err := parseViewerNetworks(tt.value)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var actual []string
+			for _, network := range networks {
+				actual = append(actual, network.String())
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestViewerAllowed(t *testing.T) {
+	networks := mustParseViewerNetworks(t, "10.10.0.0/24,::1")
+	assert.True(t, viewerAllowed(networks, "10.10.0.42"))
+	assert.True(t, viewerAllowed(networks, "::1"))
+	assert.False(t, viewerAllowed(networks, "10.10.1.42"))
+	assert.False(t, viewerAllowed(networks, "127.0.0.1"))
+	assert.False(t, viewerAllowed(networks, "unknown"))
+	assert.True(t, viewerAllowed(nil, "10.10.1.42"), "every IP is allowed without networks")
+}
+
+func TestDeviceViewerNetworks(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.defaultViewerNetworks = mustParseViewerNetworks(t, "10.0.0.0/8")
+
+	networks, err := d.deviceViewerNetworks("camera", map[string]models.ProtocolProperties{UsbProtocol: {}})
+	require.NoError(t, err)
+	assert.Equal(t, d.defaultViewerNetworks, networks, "the devices without the property use the driver config")
+
+	networks, err = d.deviceViewerNetworks("camera", map[string]models.ProtocolProperties{UsbProtocol: {ViewerAllowedCidrs: "192.168.1.0/24"}})
+	require.NoError(t, err)
+	assert.Equal(t, mustParseViewerNetworks(t, "192.168.1.0/24"), networks)
+
+	_, err = d.deviceViewerNetworks("camera", map[string]models.ProtocolProperties{UsbProtocol: {ViewerAllowedCidrs: "office"}})
+	assert.Error(t, err)
+}
+
+func TestAuthenticateViewerNetworks(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.defaultViewerNetworks = mustParseViewerNetworks(t, "10.0.0.0/8")
+	require.NoError(t, d.devices.add(&Device{name: "lobby", viewerNetworks: mustParseViewerNetworks(t, "0.0.0.0/0,::/0")}, nil))
+	require.NoError(t, d.devices.add(&Device{name: "vault", viewerNetworks: mustParseViewerNetworks(t, "10.20.0.0/24")}, nil))
+
+	request := func(ip, path, action string) int {
+		status, _ := d.authenticate(RTSPAuthRequest{IP: ip, User: testRtspUser, Password: testRtspPassword, Path: path, Protocol: "rtsp", Action: action})
+		return status
+	}
+	assert.Equal(t, http.StatusOK, request("10.20.0.5", "stream/vault", "read"))
+	assert.Equal(t, http.StatusForbidden, request("10.30.0.5", "stream/vault", "read"), "valid credentials do not bypass the allowlist")
+	assert.Equal(t, http.StatusForbidden, request("10.30.0.5", "stream/vault", "playback"))
+	assert.Equal(t, http.StatusOK, request("127.0.0.1", "stream/vault", "publish"), "the transcoders can still publish")
+	assert.Equal(t, http.StatusOK, request("192.168.1.5", "stream/lobby", "read"), "the device property replaces the driver config")
+	assert.Equal(t, http.StatusForbidden, request("192.168.1.5", "stream/unknown", "read"), "the unknown paths use the driver config")
+	assert.Equal(t, http.StatusOK, request("10.1.1.1", "stream/unknown", "read"))
+	assert.Equal(t, http.StatusOK, request("127.0.0.1", "stream/vault", "read"), "the driver reads the snapshots of the streams")
+	status, _ := d.authenticate(RTSPAuthRequest{IP: "127.0.0.1", User: testRtspUser, Password: "wrong", Path: "stream/vault", Protocol: "rtsp", Action: "read"})
+	assert.Equal(t, http.StatusForbidden, status, "only the service user is allowed from the local host")
+}
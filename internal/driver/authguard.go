@@ -0,0 +1,233 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTrackedFailureKeys is the number of IPs and users whose failures are tracked before the expired
+	// counters are pruned
+	maxTrackedFailureKeys = 1024
+
+	// SystemEventTypeRtspAuth and SystemEventActionLockout identify the system events published when an IP
+	// or a user is locked out after repeated authentication failures
+	SystemEventTypeRtspAuth  = "rtspauth"
+	SystemEventActionLockout = "lockout"
+)
+
+// authGuardConfig configures the lockout of the IPs and users after repeated authentication failures
+type authGuardConfig struct {
+	// MaxFailures is the number of failures within FailureWindow which locks out an IP or a user,
+	// the lockout is disabled if it is zero
+	MaxFailures     int
+	FailureWindow   time.Duration
+	LockoutDuration time.Duration
+	// PublishEvents publishes a system event whenever an IP or a user is locked out
+	PublishEvents bool
+}
+
+func parseAuthGuardConfig(configs map[string]string) (authGuardConfig, error) {
+	config := authGuardConfig{
+		MaxFailures:     DefaultRtspAuthMaxFailures,
+		FailureWindow:   DefaultRtspAuthFailureWindow,
+		LockoutDuration: DefaultRtspAuthLockoutDuration,
+	}
+	if value := configs[RtspAuthMaxFailures]; value != "" {
+		maxFailures, err := strconv.Atoi(value)
+		if err != nil || maxFailures < 0 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be a positive integer, or 0 to disable the lockout",
+				RtspAuthMaxFailures, value)
+		}
+		config.MaxFailures = maxFailures
+	}
+	for key, duration := range map[string]*time.Duration{
+		RtspAuthFailureWindow:   &config.FailureWindow,
+		RtspAuthLockoutDuration: &config.LockoutDuration,
+	} {
+		if configs[key] == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(configs[key])
+		if err != nil || parsed <= 0 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be a positive duration such as 5m", key, configs[key])
+		}
+		*duration = parsed
+	}
+	var err error
+	if config.PublishEvents, err = parseBoolConfig(configs, RtspAuthFailureEvents, false); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// failureCounter counts the authentication failures of an IP or a user
+type failureCounter struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// lockout is an IP or a user locked out after repeated authentication failures
+type lockout struct {
+	// Kind is "ip" or "user"
+	Kind        string    `json:"kind"`
+	Key         string    `json:"key"`
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// authGuard tracks the authentication failures per IP and per user, and locks them out temporarily after
+// repeated failures. The requests from the loopback addresses are never locked out, since the transcoders
+// publish the streams from the local host with the same user as the readers. Note that this also exempts every
+// client of a reverse proxy running on the local host, since the RTSP server only sees the address of the proxy.
+type authGuard struct {
+	config authGuardConfig
+	mutex  sync.Mutex
+	ips    map[string]*failureCounter
+	users  map[string]*failureCounter
+}
+
+func newAuthGuard(config authGuardConfig) *authGuard {
+	return &authGuard{
+		config: config,
+		ips:    make(map[string]*failureCounter),
+		users:  make(map[string]*failureCounter),
+	}
+}
+
+func (g *authGuard) exempt(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return g.config.MaxFailures == 0 || (parsed != nil && parsed.IsLoopback())
+}
+
+// locked returns the lockout of the IP or the user if any, the user is not checked if it is empty
+func (g *authGuard) locked(ip, user string, now time.Time) (lockout, bool) {
+	if g.exempt(ip) {
+		return lockout{}, false
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if c, ok := g.ips[ip]; ok && now.Before(c.lockedUntil) {
+		return lockout{Kind: "ip", Key: ip, LockedUntil: c.lockedUntil}, true
+	}
+	if c, ok := g.users[user]; ok && user != "" && now.Before(c.lockedUntil) {
+		return lockout{Kind: "user", Key: user, LockedUntil: c.lockedUntil}, true
+	}
+	return lockout{}, false
+}
+
+// recordFailure counts a failure of the IP and the user if any, and returns the lockouts it has started
+func (g *authGuard) recordFailure(ip, user string, now time.Time) []lockout {
+	if g.exempt(ip) {
+		return nil
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	var lockouts []lockout
+	if l, ok := g.count(g.ips, ip, now); ok {
+		l.Kind = "ip"
+		lockouts = append(lockouts, l)
+	}
+	if user == "" {
+		return lockouts
+	}
+	if l, ok := g.count(g.users, user, now); ok {
+		l.Kind = "user"
+		lockouts = append(lockouts, l)
+	}
+	return lockouts
+}
+
+// recordSuccess resets the failures of the IP and the user
+func (g *authGuard) recordSuccess(ip, user string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.ips, ip)
+	delete(g.users, user)
+}
+
+// count counts a failure of the key, and returns the lockout if the failure has started one
+func (g *authGuard) count(counters map[string]*failureCounter, key string, now time.Time) (lockout, bool) {
+	c, ok := counters[key]
+	if !ok {
+		if len(counters) >= maxTrackedFailureKeys {
+			g.prune(counters, now)
+		}
+		c = &failureCounter{windowStart: now}
+		counters[key] = c
+	} else if now.Sub(c.windowStart) > g.config.FailureWindow {
+		c.failures = 0
+		c.windowStart = now
+	}
+	c.failures++
+	if c.failures < g.config.MaxFailures {
+		return lockout{}, false
+	}
+	l := lockout{Key: key, Failures: c.failures, LockedUntil: now.Add(g.config.LockoutDuration)}
+	c.lockedUntil = l.LockedUntil
+	c.failures = 0
+	c.windowStart = l.LockedUntil
+	return l, true
+}
+
+// prune removes the counters which neither lock out nor count failures within the window anymore
+func (g *authGuard) prune(counters map[string]*failureCounter, now time.Time) {
+	for key, c := range counters {
+		if !now.Before(c.lockedUntil) && now.Sub(c.windowStart) > g.config.FailureWindow {
+			delete(counters, key)
+		}
+	}
+}
+
+// credentialsMatch compares the credentials in constant time, so that the comparison does not reveal how much
+// of the user or password is right
+func credentialsMatch(expected Credentials, user, password string) bool {
+	userMatch := subtle.ConstantTimeCompare([]byte(expected.Username), []byte(user))
+	passwordMatch := subtle.ConstantTimeCompare([]byte(expected.Password), []byte(password))
+	return userMatch&passwordMatch == 1
+}
+
+// auditAuthentication writes the result of an authentication request to the audit log. The successes are logged at
+// the debug level, since the requests of the service itself such as the polls of the API of the internal RTSP server
+// by the on demand idle checks are authenticated every few seconds.
+func (d *Driver) auditAuthentication(authRequest RTSPAuthRequest, result, reason string) {
+	args := []any{
+		"audit", "rtspauth",
+		"result", result,
+		"ip", authRequest.IP,
+		"user", authRequest.User,
+		"path", authRequest.Path,
+		"action", authRequest.Action,
+		"protocol", authRequest.Protocol,
+	}
+	if reason != "" {
+		args = append(args, "reason", reason)
+	}
+	if result == "success" {
+		d.lc.Debug("rtsp authentication succeeded", args...)
+	} else {
+		d.lc.Warn("rtsp authentication failed", args...)
+	}
+}
+
+// reportLockouts logs the lockouts, and publishes them as system events if enabled
+func (d *Driver) reportLockouts(authRequest RTSPAuthRequest, lockouts []lockout) {
+	for _, l := range lockouts {
+		d.lc.Warnf("rtsp authentication: %s %s is locked out until %s after %d failures, the last one from %s for %s",
+			l.Kind, l.Key, l.LockedUntil.Format(time.RFC3339), l.Failures, authRequest.IP, authRequest.Path)
+		if d.authGuard.config.PublishEvents {
+			d.ds.PublishGenericSystemEvent(SystemEventTypeRtspAuth, SystemEventActionLockout, l)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	sdkMocks "github.com/edgexfoundry/device-sdk-go/v4/pkg/interfaces/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAuthGuardConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		expected  authGuardConfig
+		expectErr bool
+	}{
+		{"defaults", map[string]string{},
+			authGuardConfig{DefaultRtspAuthMaxFailures, DefaultRtspAuthFailureWindow, DefaultRtspAuthLockoutDuration, false}, false},
+		{"configured", map[string]string{RtspAuthMaxFailures: "0", RtspAuthFailureWindow: "1m", RtspAuthLockoutDuration: "1h", RtspAuthFailureEvents: "true"},
+			authGuardConfig{0, time.Minute, time.Hour, true}, false},
+		{"negative failures", map[string]string{RtspAuthMaxFailures: "-1"}, authGuardConfig{}, true},
+		{"invalid window", map[string]string{RtspAuthFailureWindow: "forever"}, authGuardConfig{}, true},
+		{"zero lockout", map[string]string{RtspAuthLockoutDuration: "0s"}, authGuardConfig{}, true},
+		{"invalid events", map[string]string{RtspAuthFailureEvents: "loud"}, authGuardConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseAuthGuardConfig(tt.configs)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
+
+func TestAuthGuard(t *testing.T) {
+	start := time.Now()
+	config := authGuardConfig{MaxFailures: 3, FailureWindow: time.Minute, LockoutDuration: 10 * time.Minute}
+
+	t.Run("ip lockout", func(t *testing.T) {
+		g := newAuthGuard(config)
+		for i, user := range []string{"admin", "root"} {
+			assert.Empty(t, g.recordFailure("10.0.0.1", user, start.Add(time.Duration(i)*time.Second)))
+		}
+		lockouts := g.recordFailure("10.0.0.1", "guest", start.Add(2*time.Second))
+		require.Len(t, lockouts, 1)
+		assert.Equal(t, lockout{Kind: "ip", Key: "10.0.0.1", Failures: 3, LockedUntil: start.Add(2*time.Second + 10*time.Minute)}, lockouts[0])
+
+		l, locked := g.locked("10.0.0.1", "camera", start.Add(5*time.Minute))
+		assert.True(t, locked)
+		assert.Equal(t, "ip", l.Kind)
+		_, locked = g.locked("10.0.0.2", "camera", start.Add(5*time.Minute))
+		assert.False(t, locked, "the other IPs are not locked out")
+		_, locked = g.locked("10.0.0.1", "camera", start.Add(11*time.Minute))
+		assert.False(t, locked, "the lockout expires")
+	})
+
+	t.Run("user lockout", func(t *testing.T) {
+		g := newAuthGuard(config)
+		g.recordFailure("10.0.0.1", "admin", start)
+		g.recordFailure("10.0.0.2", "admin", start)
+		lockouts := g.recordFailure("10.0.0.3", "admin", start)
+		require.Len(t, lockouts, 1)
+		assert.Equal(t, "user", lockouts[0].Kind)
+		l, locked := g.locked("10.0.0.4", "admin", start)
+		assert.True(t, locked)
+		assert.Equal(t, "admin", l.Key)
+		_, locked = g.locked("127.0.0.1", "admin", start)
+		assert.False(t, locked, "the loopback addresses are never locked out")
+	})
+
+	t.Run("window", func(t *testing.T) {
+		g := newAuthGuard(config)
+		g.recordFailure("10.0.0.1", "admin", start)
+		g.recordFailure("10.0.0.1", "admin", start.Add(time.Second))
+		assert.Empty(t, g.recordFailure("10.0.0.1", "admin", start.Add(2*time.Minute)), "the failures out of the window are forgotten")
+	})
+
+	t.Run("success", func(t *testing.T) {
+		g := newAuthGuard(config)
+		g.recordFailure("10.0.0.1", "admin", start)
+		g.recordFailure("10.0.0.1", "admin", start)
+		g.recordSuccess("10.0.0.1", "admin")
+		assert.Empty(t, g.recordFailure("10.0.0.1", "admin", start), "a success resets the failures")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		g := newAuthGuard(authGuardConfig{})
+		for range 10 {
+			assert.Empty(t, g.recordFailure("10.0.0.1", "admin", start))
+		}
+		_, locked := g.locked("10.0.0.1", "admin", start)
+		assert.False(t, locked)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		g := newAuthGuard(config)
+		for i := range maxTrackedFailureKeys {
+			g.recordFailure(fmt.Sprintf("10.0.%d.%d", i/256, i%256), "admin", start)
+		}
+		g.recordFailure("10.1.0.1", "admin", start.Add(time.Hour))
+		assert.Len(t, g.ips, 1, "the expired counters are pruned")
+		_, locked := g.locked("10.1.0.1", "admin", start.Add(time.Hour))
+		assert.False(t, locked)
+	})
+}
+
+func TestCredentialsMatch(t *testing.T) {
+	expected := Credentials{Username: testRtspUser, Password: testRtspPassword}
+	assert.True(t, credentialsMatch(expected, testRtspUser, testRtspPassword))
+	assert.False(t, credentialsMatch(expected, testRtspUser, testRtspPassword+"x"))
+	assert.False(t, credentialsMatch(expected, "other", testRtspPassword))
+	assert.False(t, credentialsMatch(expected, "", ""))
+}
+
+func TestAuthenticateLockout(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.authGuard = newAuthGuard(authGuardConfig{MaxFailures: 2, FailureWindow: time.Minute, LockoutDuration: time.Minute, PublishEvents: true})
+	mockService, ok := d.ds.(*sdkMocks.DeviceServiceSDK)
+	require.True(t, ok)
+	mockService.On("PublishGenericSystemEvent", SystemEventTypeRtspAuth, SystemEventActionLockout, mock.AnythingOfType("lockout")).Return()
+
+	request := func(ip, password string) int {
+		status, _ := d.authenticate(RTSPAuthRequest{IP: ip, User: testRtspUser, Password: password, Path: "stream/camera", Protocol: "rtsp", Action: "read"})
+		return status
+	}
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.1", "wrong"))
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.1", "wrong"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1", testRtspPassword), "the IP is locked out")
+	assert.Equal(t, http.StatusOK, request("10.0.0.2", testRtspPassword), "the user of the service is shared, it is not locked out")
+	assert.Equal(t, http.StatusOK, request("127.0.0.1", testRtspPassword), "the local transcoders can still publish")
+	mockService.AssertNumberOfCalls(t, "PublishGenericSystemEvent", 1)
+
+	guess := func(ip, user, password string) int {
+		status, _ := d.authenticate(RTSPAuthRequest{IP: ip, User: user, Password: password, Path: "stream/camera", Protocol: "rtsp", Action: "read"})
+		return status
+	}
+	assert.Equal(t, http.StatusUnauthorized, guess("10.0.1.1", "admin", "admin"))
+	assert.Equal(t, http.StatusUnauthorized, guess("10.0.1.2", "admin", "password"))
+	assert.Equal(t, http.StatusTooManyRequests, guess("10.0.1.3", "admin", "secret"), "the other users are locked out")
+	assert.Equal(t, http.StatusOK, guess("10.0.1.3", testRtspUser, testRtspPassword), "the IP is not locked out by the user lockout")
+	mockService.AssertNumberOfCalls(t, "PublishGenericSystemEvent", 2)
+}
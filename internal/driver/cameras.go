@@ -0,0 +1,206 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+
+	"github.com/labstack/echo/v4"
+	usbDevice "github.com/vladimirvivien/go4vl/device"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// CameraInfo is the state of an active camera
+type CameraInfo struct {
+	Name         string   `json:"name"`
+	Paths        []string `json:"paths"`
+	SerialNumber string   `json:"serialNumber"`
+	BusPath      string   `json:"busPath,omitempty"`
+	// PixelFormat, Width, Height and Fps are the current capture settings of the streaming path, or the first path
+	PixelFormat string `json:"pixelFormat,omitempty"`
+	Width       uint32 `json:"width,omitempty"`
+	Height      uint32 `json:"height,omitempty"`
+	Fps         string `json:"fps,omitempty"`
+	// FormatError is set when the current capture settings cannot be read from the camera
+	FormatError string               `json:"formatError,omitempty"`
+	Streaming   CameraStreamingState `json:"streaming"`
+}
+
+// CameraStreamingState is the state of the video streaming of a camera
+type CameraStreamingState struct {
+	IsStreaming bool   `json:"isStreaming"`
+	InputPath   string `json:"inputPath,omitempty"`
+	LastError   string `json:"lastError,omitempty"`
+	RtspUri     string `json:"rtspUri,omitempty"`
+	// Pid, StartedAt, Uptime, Connected and LastProgressAt are only set while streaming
+	Pid       int        `json:"pid,omitempty"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	Uptime    string     `json:"uptime,omitempty"`
+	// Connected means that the transcoder has published frames to the RTSP server
+	Connected      bool       `json:"connected"`
+	LastProgressAt *time.Time `json:"lastProgressAt,omitempty"`
+	// Readers are the addresses of the clients reading the stream, they are only known to the embedded RTSP server
+	Readers []string `json:"readers,omitempty"`
+	// Schedule is the state of the streaming schedule, it is only set if the camera has one
+	Schedule *StreamingScheduleStatus `json:"schedule,omitempty"`
+	// Bandwidth is the estimated USB bandwidth of the stream, it is only set while streaming
+	Bandwidth *StreamBandwidth `json:"bandwidth,omitempty"`
+}
+
+// CamerasResponse is the response of the cameras API
+type CamerasResponse struct {
+	commonDTO.BaseResponse `json:",inline"`
+	Cameras                []CameraInfo `json:"cameras"`
+	// RtspServer is the health of the external RTSP server, it is only set in the external mode
+	RtspServer *RTSPServerHealth `json:"rtspServer,omitempty"`
+}
+
+// CameraResponse is the response of the camera API
+type CameraResponse struct {
+	commonDTO.BaseResponse `json:",inline"`
+	Camera                 CameraInfo `json:"camera"`
+	// RtspServer is the health of the external RTSP server, it is only set in the external mode
+	RtspServer *RTSPServerHealth `json:"rtspServer,omitempty"`
+}
+
+// streamingState returns the state of the video streaming of the device
+func (dev *Device) streamingState(rtspServerMode RTSPServerMode, now time.Time) CameraStreamingState {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+
+	state := CameraStreamingState{
+		IsStreaming: dev.streamingStatus.IsStreaming,
+		InputPath:   dev.streamingStatus.TranscoderInputPath,
+		LastError:   dev.streamingStatus.Error,
+		Schedule:    scheduleStatus(dev.schedule, now),
+	}
+	if rtspServerMode != RTSPServerModeNone {
+		state.RtspUri = dev.rtspUri
+	}
+	if !state.IsStreaming {
+		return state
+	}
+	if proc := dev.transcoder.Process(); proc != nil && proc.Process != nil {
+		state.Pid = proc.Process.Pid
+	}
+	if !dev.streamStartedAt.IsZero() {
+		startedAt := dev.streamStartedAt
+		state.StartedAt = &startedAt
+		state.Uptime = now.Sub(startedAt).Round(time.Second).String()
+	}
+	if !dev.lastProgressAt.IsZero() {
+		lastProgressAt := dev.lastProgressAt
+		state.LastProgressAt = &lastProgressAt
+		state.Connected = true
+	}
+	state.Bandwidth = dev.bandwidth
+	return state
+}
+
+// cameraInfo returns the state of the device, including the current capture settings read from the camera
+func (d *Driver) cameraInfo(dev *Device) CameraInfo {
+	info := CameraInfo{
+		Name:         dev.name,
+		Paths:        slices.Clone(dev.paths),
+		SerialNumber: dev.serialNumber,
+		BusPath:      dev.busPath,
+		Streaming:    dev.streamingState(d.rtspServerMode, time.Now()),
+	}
+	if info.Streaming.IsStreaming {
+		info.Streaming.Readers = d.rtspReaders(dev.name)
+	}
+	path := info.Streaming.InputPath
+	if path == "" && len(info.Paths) > 0 {
+		path = info.Paths[0]
+	}
+	if path == "" {
+		return info
+	}
+	pixFmt, fps, err := getCurrentCaptureFormat(path)
+	if err != nil {
+		info.FormatError = fmt.Sprintf("failed to read the current format of the device at path %s: %s", path, err.Error())
+		return info
+	}
+	info.PixelFormat = v4l2.PixelFormats[pixFmt.PixelFormat]
+	if info.PixelFormat == "" {
+		info.PixelFormat = fourCCString(pixFmt.PixelFormat)
+	}
+	info.Width = pixFmt.Width
+	info.Height = pixFmt.Height
+	if fps.Numerator != 0 && fps.Denominator != 0 {
+		info.Fps = formatFrameRate(fps)
+	}
+	return info
+}
+
+// getCurrentCaptureFormat returns the current pixel format and frame rate of the camera on the specified path
+func getCurrentCaptureFormat(path string) (v4l2.PixFormat, v4l2.Fract, error) {
+	cameraDevice, err := usbDevice.Open(path)
+	if err != nil {
+		return v4l2.PixFormat{}, v4l2.Fract{}, err
+	}
+	defer cameraDevice.Close()
+	pixFmt, err := cameraDevice.GetPixFormat()
+	if err != nil {
+		return v4l2.PixFormat{}, v4l2.Fract{}, err
+	}
+	streamParam, err := cameraDevice.GetStreamParam()
+	if err != nil {
+		return pixFmt, v4l2.Fract{}, nil
+	}
+	timePerFrame := streamParam.Capture.TimePerFrame
+	return pixFmt, v4l2.Fract{Numerator: timePerFrame.Denominator, Denominator: timePerFrame.Numerator}, nil
+}
+
+// fourCCString returns the four character code of a pixel format unknown to go4vl, e.g. Z16
+func fourCCString(pixelFormat uint32) string {
+	for name, value := range PixelFormatV4l2Mappings {
+		if value == pixelFormat {
+			return name
+		}
+	}
+	return fmt.Sprintf("%c%c%c%c", byte(pixelFormat), byte(pixelFormat>>8), byte(pixelFormat>>16), byte(pixelFormat>>24))
+}
+
+// CamerasRoute returns the state of all the active cameras
+func (d *Driver) CamerasRoute(writer http.ResponseWriter, request *http.Request) {
+	devices := d.devices.list()
+
+	response := CamerasResponse{
+		BaseResponse: commonDTO.NewBaseResponse("", "", http.StatusOK),
+		Cameras:      make([]CameraInfo, 0, len(devices)),
+		RtspServer:   d.rtspServerHealth(),
+	}
+	for _, dev := range devices {
+		response.Cameras = append(response.Cameras, d.cameraInfo(dev))
+	}
+	d.writeJSONResponse(writer, request, http.StatusOK, response)
+}
+
+// CameraRoute returns the state of the active camera specified by the name path parameter
+func (d *Driver) CameraRoute(c echo.Context) error {
+	name := c.Param(common.Name)
+	dev, ok := d.devices.get(name)
+	if !ok {
+		response := commonDTO.NewBaseResponse("", fmt.Sprintf("camera %s is not active", name), http.StatusNotFound)
+		d.writeJSONResponse(c.Response(), c.Request(), http.StatusNotFound, response)
+		return nil
+	}
+	response := CameraResponse{
+		BaseResponse: commonDTO.NewBaseResponse("", "", http.StatusOK),
+		Camera:       d.cameraInfo(dev),
+		RtspServer:   d.rtspServerHealth(),
+	}
+	d.writeJSONResponse(c.Response(), c.Request(), http.StatusOK, response)
+	return nil
+}
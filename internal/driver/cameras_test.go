@@ -0,0 +1,104 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCamerasRoute(t *testing.T) {
+	installFakeFFmpeg(t, "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	streaming := addFakeStreamingDevice(t, d, "camera1")
+	streaming.serialNumber = "SN0001"
+	streaming.rtspUri = "rtsp://localhost:8554/stream/camera1"
+	idle := addFakeStreamingDevice(t, d, "camera2")
+	idle.streamingStatus.Error = "previous failure"
+	require.NoError(t, d.startStreaming(streaming))
+	require.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	defer func() {
+		streaming.StopStreaming()
+		nextStreamingStatus(t, asyncCh)
+	}()
+
+	recorder := httptest.NewRecorder()
+	d.CamerasRoute(recorder, httptest.NewRequest(http.MethodGet, common.ApiBase+ApiCameras, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var response CamerasResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Len(t, response.Cameras, 2)
+
+	camera := response.Cameras[0]
+	assert.Equal(t, "camera1", camera.Name)
+	assert.Equal(t, []string{"/dev/video0"}, camera.Paths)
+	assert.Equal(t, "SN0001", camera.SerialNumber)
+	assert.True(t, camera.Streaming.IsStreaming)
+	assert.Equal(t, "/dev/video0", camera.Streaming.InputPath)
+	assert.Equal(t, "rtsp://localhost:8554/stream/camera1", camera.Streaming.RtspUri)
+	assert.Positive(t, camera.Streaming.Pid)
+	require.NotNil(t, camera.Streaming.StartedAt)
+	assert.WithinDuration(t, time.Now(), *camera.Streaming.StartedAt, time.Minute)
+	assert.NotEmpty(t, camera.Streaming.Uptime)
+	assert.True(t, camera.Streaming.Connected)
+	assert.NotNil(t, camera.Streaming.LastProgressAt)
+
+	camera = response.Cameras[1]
+	assert.Equal(t, "camera2", camera.Name)
+	assert.False(t, camera.Streaming.IsStreaming)
+	assert.Equal(t, "previous failure", camera.Streaming.LastError)
+	assert.Zero(t, camera.Streaming.Pid)
+	assert.Nil(t, camera.Streaming.StartedAt)
+	assert.False(t, camera.Streaming.Connected)
+}
+
+func TestCameraRoute(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspServerMode = RTSPServerModeNone
+	installFakeFFmpeg(t)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.rtspUri = "rtsp://localhost:8554/stream/camera"
+
+	tests := []struct {
+		name           string
+		deviceName     string
+		expectedStatus int
+	}{
+		{"active camera", "camera", http.StatusOK},
+		{"unknown camera", "unknown", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, common.ApiBase+ApiCameras+"/name/"+tt.deviceName, nil), recorder)
+			c.SetParamNames(common.Name)
+			c.SetParamValues(tt.deviceName)
+			require.NoError(t, d.CameraRoute(c))
+			require.Equal(t, tt.expectedStatus, recorder.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var response CameraResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, "camera", response.Camera.Name)
+			assert.Empty(t, response.Camera.Streaming.RtspUri, "there is no RTSP URI without RTSP server")
+		})
+	}
+}
+
+func TestFourCCString(t *testing.T) {
+	assert.Equal(t, "Z16", fourCCString(PixFmtDepthZ16))
+	assert.Equal(t, "ABCD", fourCCString(uint32('A')|uint32('B')<<8|uint32('C')<<16|uint32('D')<<24))
+}
@@ -0,0 +1,164 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+
+	sdkModels "github.com/edgexfoundry/device-sdk-go/v4/pkg/models"
+
+	usbDevice "github.com/vladimirvivien/go4vl/device"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// CameraCapabilities summarizes what a camera is able to capture, so that the provision watchers can
+// route the discovered devices to the right device profiles
+type CameraCapabilities struct {
+	Depth     bool
+	IR        bool
+	H264      bool
+	MJPEG     bool
+	MaxWidth  uint32
+	MaxHeight uint32
+}
+
+// irPixelFormats are the pixel formats of infrared sensors, such as the ones of depth cameras and face recognition cameras
+var irPixelFormats = map[uint32]bool{
+	v4l2.PixelFmtGrey: true,
+	PixFmtY8I:         true,
+	PixFmtY12I:        true,
+}
+
+// newCameraCapabilities builds the capabilities of a camera from the formats of one of its paths
+func newCameraCapabilities(formats []InputFormat) CameraCapabilities {
+	var caps CameraCapabilities
+	for _, format := range formats {
+		switch {
+		case format.PixelFormat == PixFmtDepthZ16:
+			caps.Depth = true
+		case irPixelFormats[format.PixelFormat]:
+			caps.IR = true
+		case format.PixelFormat == v4l2.PixelFmtH264:
+			caps.H264 = true
+		case format.PixelFormat == v4l2.PixelFmtMJPEG || format.PixelFormat == v4l2.PixelFmtJPEG:
+			caps.MJPEG = true
+		}
+		for _, frameSize := range format.FrameSizes {
+			caps.updateMaxResolution(frameSize.Size.MaxWidth, frameSize.Size.MaxHeight)
+		}
+	}
+	return caps
+}
+
+// getCameraCapabilities returns the capabilities of the camera on the specified path
+func getCameraCapabilities(path string) (CameraCapabilities, error) {
+	cameraDevice, err := usbDevice.Open(path)
+	if err != nil {
+		return CameraCapabilities{}, err
+	}
+	defer cameraDevice.Close()
+	formats, err := getInputFormats(cameraDevice)
+	if err != nil {
+		return CameraCapabilities{}, err
+	}
+	return newCameraCapabilities(formats), nil
+}
+
+// merge combines the capabilities of another path of the same camera
+func (caps *CameraCapabilities) merge(other CameraCapabilities) {
+	caps.Depth = caps.Depth || other.Depth
+	caps.IR = caps.IR || other.IR
+	caps.H264 = caps.H264 || other.H264
+	caps.MJPEG = caps.MJPEG || other.MJPEG
+	caps.updateMaxResolution(other.MaxWidth, other.MaxHeight)
+}
+
+func (caps *CameraCapabilities) updateMaxResolution(width, height uint32) {
+	if uint64(width)*uint64(height) > uint64(caps.MaxWidth)*uint64(caps.MaxHeight) {
+		caps.MaxWidth, caps.MaxHeight = width, height
+	}
+}
+
+// maxResolution returns the largest resolution as <width>x<height>, or an empty string if it is unknown
+func (caps CameraCapabilities) maxResolution() string {
+	if caps.MaxWidth == 0 || caps.MaxHeight == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", caps.MaxWidth, caps.MaxHeight)
+}
+
+// setProtocolProperties sets the capability flags and the max resolution in the protocol properties
+func (caps CameraCapabilities) setProtocolProperties(properties models.ProtocolProperties) {
+	properties[HasDepth] = strconv.FormatBool(caps.Depth)
+	properties[HasIR] = strconv.FormatBool(caps.IR)
+	properties[HasH264] = strconv.FormatBool(caps.H264)
+	properties[HasMJPEG] = strconv.FormatBool(caps.MJPEG)
+	properties[MaxResolution] = caps.maxResolution()
+}
+
+// labels returns the labels of the capabilities of the camera
+func (caps CameraCapabilities) labels() []string {
+	var labels []string
+	if caps.Depth {
+		labels = append(labels, LabelDepth)
+	}
+	if caps.IR {
+		labels = append(labels, LabelIR)
+	}
+	if caps.H264 {
+		labels = append(labels, LabelH264)
+	}
+	if caps.MJPEG {
+		labels = append(labels, LabelMJPEG)
+	}
+	if resolution := caps.maxResolution(); resolution != "" {
+		labels = append(labels, LabelPrefixMaxResolution+resolution)
+	}
+	return labels
+}
+
+// newDiscoveredDevice builds the discovered device of the camera on the specified path. Apart from the properties
+// identifying the camera, the protocol properties and labels contain the USB ids, the kernel driver, the USB port
+// and the capabilities of the camera, which the provision watchers can use as identifiers.
+func newDiscoveredDevice(name, path string, identity USBDeviceIdentity, caps CameraCapabilities) sdkModels.DiscoveredDevice {
+	properties := models.ProtocolProperties{
+		Paths:        []string{path},
+		SerialNumber: identity.SerialNumber,
+		CardName:     identity.CardName,
+		VendorID:     identity.VendorID,
+		ProductID:    identity.ProductID,
+		Manufacturer: identity.Manufacturer,
+		KernelDriver: identity.Driver,
+		BusPath:      identity.BusPath,
+	}
+	caps.setProtocolProperties(properties)
+	return sdkModels.DiscoveredDevice{
+		Name:        name,
+		Protocols:   map[string]models.ProtocolProperties{UsbProtocol: properties},
+		Description: fmt.Sprintf("USB camera %s", identity.CardName),
+		Labels:      discoveredDeviceLabels(identity, caps),
+	}
+}
+
+// discoveredDeviceLabels returns the labels of a discovered device
+func discoveredDeviceLabels(identity USBDeviceIdentity, caps CameraCapabilities) []string {
+	labels := []string{"auto-discovery", identity.CardName}
+	if identity.VendorID != "" {
+		labels = append(labels, LabelPrefixVendor+identity.VendorID,
+			LabelPrefixProduct+identity.VendorID+":"+identity.ProductID)
+	}
+	if identity.Driver != "" {
+		labels = append(labels, LabelPrefixDriver+identity.Driver)
+	}
+	if identity.BusPath != "" {
+		labels = append(labels, LabelPrefixBusPath+identity.BusPath)
+	}
+	return append(labels, caps.labels()...)
+}
@@ -0,0 +1,119 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+func discreteInputFormat(pixelFormat uint32, sizes ...[2]uint32) InputFormat {
+	format := InputFormat{PixelFormat: pixelFormat}
+	for _, size := range sizes {
+		format.FrameSizes = append(format.FrameSizes, InputFrameSize{
+			Type: v4l2.FrameSizeTypeDiscrete,
+			Size: v4l2.FrameSize{MinWidth: size[0], MaxWidth: size[0], MinHeight: size[1], MaxHeight: size[1]},
+		})
+	}
+	return format
+}
+
+func TestNewCameraCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		formats  []InputFormat
+		expected CameraCapabilities
+	}{
+		{
+			name:     "no formats",
+			expected: CameraCapabilities{},
+		},
+		{
+			name: "webcam",
+			formats: []InputFormat{
+				discreteInputFormat(v4l2.PixelFmtYUYV, [2]uint32{640, 480}, [2]uint32{1280, 720}),
+				discreteInputFormat(v4l2.PixelFmtMJPEG, [2]uint32{1920, 1080}, [2]uint32{640, 480}),
+			},
+			expected: CameraCapabilities{MJPEG: true, MaxWidth: 1920, MaxHeight: 1080},
+		},
+		{
+			name: "h264 camera",
+			formats: []InputFormat{
+				discreteInputFormat(v4l2.PixelFmtH264, [2]uint32{3840, 2160}),
+			},
+			expected: CameraCapabilities{H264: true, MaxWidth: 3840, MaxHeight: 2160},
+		},
+		{
+			name: "depth sensor",
+			formats: []InputFormat{
+				discreteInputFormat(PixFmtDepthZ16, [2]uint32{1280, 720}),
+				discreteInputFormat(PixFmtY8I, [2]uint32{1280, 800}),
+			},
+			expected: CameraCapabilities{Depth: true, IR: true, MaxWidth: 1280, MaxHeight: 800},
+		},
+		{
+			name: "stepwise frame sizes",
+			formats: []InputFormat{{
+				PixelFormat: v4l2.PixelFmtGrey,
+				FrameSizes: []InputFrameSize{{
+					Type: v4l2.FrameSizeTypeStepwise,
+					Size: v4l2.FrameSize{MinWidth: 16, MaxWidth: 1600, StepWidth: 16, MinHeight: 16, MaxHeight: 1200, StepHeight: 16},
+				}},
+			}},
+			expected: CameraCapabilities{IR: true, MaxWidth: 1600, MaxHeight: 1200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newCameraCapabilities(tt.formats))
+		})
+	}
+}
+
+func TestCameraCapabilitiesMerge(t *testing.T) {
+	caps := CameraCapabilities{Depth: true, MaxWidth: 1280, MaxHeight: 720}
+	caps.merge(CameraCapabilities{IR: true, MaxWidth: 1280, MaxHeight: 800})
+	caps.merge(CameraCapabilities{MJPEG: true, MaxWidth: 640, MaxHeight: 480})
+	assert.Equal(t, CameraCapabilities{Depth: true, IR: true, MJPEG: true, MaxWidth: 1280, MaxHeight: 800}, caps)
+}
+
+func TestNewDiscoveredDevice(t *testing.T) {
+	identity := USBDeviceIdentity{
+		CardName:     "Intel(R) RealSense(TM) Depth Ca",
+		SerialNumber: "123456789",
+		VendorID:     "8086",
+		ProductID:    "0b07",
+		Manufacturer: "Intel(R) RealSense(TM) Depth Camera 435",
+		BusPath:      "2-1",
+		Driver:       "uvcvideo",
+	}
+	caps := CameraCapabilities{Depth: true, IR: true, MaxWidth: 1280, MaxHeight: 800}
+
+	discovered := newDiscoveredDevice("camera", "/dev/video0", identity, caps)
+	assert.Equal(t, "camera", discovered.Name)
+	properties := discovered.Protocols[UsbProtocol]
+	assert.Equal(t, []string{"/dev/video0"}, properties[Paths])
+	assert.Equal(t, "123456789", properties[SerialNumber])
+	assert.Equal(t, "Intel(R) RealSense(TM) Depth Ca", properties[CardName])
+	assert.Equal(t, "8086", properties[VendorID])
+	assert.Equal(t, "0b07", properties[ProductID])
+	assert.Equal(t, "Intel(R) RealSense(TM) Depth Camera 435", properties[Manufacturer])
+	assert.Equal(t, "uvcvideo", properties[KernelDriver])
+	assert.Equal(t, "2-1", properties[BusPath])
+	assert.Equal(t, "true", properties[HasDepth])
+	assert.Equal(t, "true", properties[HasIR])
+	assert.Equal(t, "false", properties[HasH264])
+	assert.Equal(t, "false", properties[HasMJPEG])
+	assert.Equal(t, "1280x800", properties[MaxResolution])
+	assert.Equal(t, []string{
+		"auto-discovery", "Intel(R) RealSense(TM) Depth Ca",
+		"vendor:8086", "product:8086:0b07", "driver:uvcvideo", "bus:2-1",
+		"depth", "ir", "max-resolution:1280x800",
+	}, discovered.Labels)
+}
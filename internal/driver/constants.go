@@ -6,6 +6,12 @@
 
 package driver
 
+import (
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+)
+
 const (
 	GetFunction                     = "getFunction"
 	SetFunction                     = "setFunction"
@@ -14,19 +20,88 @@ const (
 	Path                            = "Path"
 	SerialNumber                    = "SerialNumber"
 	CardName                        = "CardName"
+	BusPath                         = "BusPath"
+	IdentityMode                    = "IdentityMode"
+	VendorID                        = "VendorID"
+	ProductID                       = "ProductID"
+	Manufacturer                    = "Manufacturer"
+	KernelDriver                    = "Driver"
+	HasDepth                        = "HasDepth"
+	HasIR                           = "HasIR"
+	HasH264                         = "HasH264"
+	HasMJPEG                        = "HasMJPEG"
+	MaxResolution                   = "MaxResolution"
 	AutoStreaming                   = "AutoStreaming"
 	InputIndex                      = "InputIndex"
 	UrlRawQuery                     = "urlRawQuery"
 	RtspServerMode                  = "RtspServerMode"
 	RtspServerExe                   = "RtspServerExecutable"
 	RtspServerExeDefault            = "./mediamtx"
+	RtspServerConfigFile            = "RtspServerConfigFile"
+	RtspServerTransports            = "RtspServerTransports"
+	DefaultRtspServerTransports     = "tcp"
+	RtspServerEnableRtmp            = "RtspServerEnableRtmp"
+	RtspServerEnableHls             = "RtspServerEnableHls"
+	RtspServerHlsPort               = "RtspServerHlsPort"
+	DefaultRtspServerHlsPort        = "8888"
+	RtspServerEnableWebRtc          = "RtspServerEnableWebRtc"
+	RtspServerWebRtcPort            = "RtspServerWebRtcPort"
+	DefaultRtspServerWebRtcPort     = "8889"
+	RtspMaxReaders                  = "RtspMaxReaders"
+	ViewerAllowedCidrs              = "ViewerAllowedCidrs"
 	RtspServerHostName              = "RtspServerHostName"
 	DefaultRtspServerHostName       = "localhost"
 	RtspTcpPort                     = "RtspTcpPort"
 	DefaultRtspTcpPort              = "8554"
 	RtspAuthenticationServer        = "RtspAuthenticationServer"
 	DefaultRtspAuthenticationServer = "localhost:8000"
+	SysfsRoot                       = "SysfsRoot"
+	DefaultSysfsRoot                = "/sys"
+	DiscoveryAllowCardName          = "DiscoveryAllowCardName"
+	DiscoveryDenyCardName           = "DiscoveryDenyCardName"
+	DiscoveryAllowUSBID             = "DiscoveryAllowUSBID"
+	DiscoveryDenyUSBID              = "DiscoveryDenyUSBID"
+	DiscoveryAllowDriver            = "DiscoveryAllowDriver"
+	DiscoveryDenyDriver             = "DiscoveryDenyDriver"
+	DiscoveryAllowPath              = "DiscoveryAllowPath"
+	DiscoveryDenyPath               = "DiscoveryDenyPath"
+	DiscoverySkipIntegratedCameras  = "DiscoverySkipIntegratedCameras"
+	PreviewMaxFps                   = "PreviewMaxFps"
+	DefaultPreviewMaxFps            = 10
+	PreviewMaxWidth                 = "PreviewMaxWidth"
+	DefaultPreviewMaxWidth          = 1280
+	PreviewMaxHeight                = "PreviewMaxHeight"
+	DefaultPreviewMaxHeight         = 720
+	PreviewJpegQuality              = "PreviewJpegQuality"
+	DefaultPreviewJpegQuality       = 80
+	StreamSnapshots                 = "StreamSnapshots"
+	RtspHealthCheckInterval         = "RtspHealthCheckInterval"
+	DefaultRtspHealthCheckInterval  = 10 * time.Second
+	RtspHealthCheckTimeout          = "RtspHealthCheckTimeout"
+	DefaultRtspHealthCheckTimeout   = 2 * time.Second
+	RtspHealthCheckMethod           = "RtspHealthCheckMethod"
+	RtspHealthCheckApiUrl           = "RtspHealthCheckApiUrl"
+	RtspAuthMaxFailures             = "RtspAuthMaxFailures"
+	DefaultRtspAuthMaxFailures      = 5
+	RtspAuthFailureWindow           = "RtspAuthFailureWindow"
+	DefaultRtspAuthFailureWindow    = 5 * time.Minute
+	RtspAuthLockoutDuration         = "RtspAuthLockoutDuration"
+	DefaultRtspAuthLockoutDuration  = 5 * time.Minute
+	RtspAuthFailureEvents           = "RtspAuthFailureEvents"
+	StreamTokenTtl                  = "StreamTokenTtl"
+	DefaultStreamTokenTtl           = 5 * time.Minute
+	StreamTokenMaxTtl               = "StreamTokenMaxTtl"
+	DefaultStreamTokenMaxTtl        = 24 * time.Hour
+	RtspAuthServerTls               = "RtspAuthenticationServerTls"
+	RtspAuthServerTlsSecretName     = "RtspAuthenticationServerTlsSecretName"
+	RtspAuthServerTlsCertFile       = "RtspAuthenticationServerTlsCertFile"
+	RtspAuthServerTlsKeyFile        = "RtspAuthenticationServerTlsKeyFile"
+	RtspTls                         = "RtspTls"
+	RtspTlsSecretName               = "RtspTlsSecretName"
+	RtspTlsCertFile                 = "RtspTlsCertFile"
+	RtspTlsKeyFile                  = "RtspTlsKeyFile"
 	RtspUriScheme                   = "rtsp"
+	RtspsUriScheme                  = "rtsps"
 	Stream                          = "stream"
 	PrefixInput                     = "Input"
 	PrefixOutput                    = "Output"
@@ -41,8 +116,24 @@ const (
 	Greyscale                       = "Greyscale"
 	Depth                           = "Depth"
 
+	// Labels of discovered devices
+	LabelDepth               = "depth"
+	LabelIR                  = "ir"
+	LabelH264                = "h264"
+	LabelMJPEG               = "mjpeg"
+	LabelPrefixVendor        = "vendor:"
+	LabelPrefixProduct       = "product:"
+	LabelPrefixDriver        = "driver:"
+	LabelPrefixBusPath       = "bus:"
+	LabelPrefixMaxResolution = "max-resolution:"
+
 	// API route specific to Device Service
 	ApiRefreshDevicePaths = "/refreshdevicepaths"
+	ApiDiscoveryReport    = "/discovery/report"
+	ApiCameras            = "/cameras"
+	ApiCameraByName       = ApiCameras + "/" + common.Name + "/:" + common.Name
+	ApiPreview            = "/preview/:" + common.Name
+	ApiSnapshot           = "/snapshot/:" + common.Name
 
 	// Metadata descriptions
 	DescNotSpecified = "not specified"
@@ -61,6 +152,7 @@ const (
 	VideoStartStreaming         = "VIDEO_START_STREAMING"
 	VideoStopStreaming          = "VIDEO_STOP_STREAMING"
 	VideoStreamUri              = "VIDEO_STREAM_URI"
+	VideoStreamToken            = "VIDEO_STREAM_TOKEN"
 	VideoStreamingStatus        = "VIDEO_STREAMING_STATUS"
 	VideoGetFrameRate           = "VIDEO_GET_FRAMERATE"
 	VideoSetFrameRate           = "VIDEO_SET_FRAMERATE"
@@ -81,14 +173,40 @@ const (
 	FFmpegPixelFmtGray  = "gray"
 	FFmpegPixelFmtYUYV  = "yuyv422"
 	FFmpegPixelFmtMJPEG = "mjpeg"
+	FFmpegPixelFmtH264  = "h264"
+	// FFmpegCodecCopy is the OutputVideoCodec publishing the compressed stream of the camera without re-encoding it
+	FFmpegCodecCopy = "copy"
 
 	// Input option names
 	InputPixelFormat = "InputPixelFormat"
+	InputImageSize   = "InputImageSize"
+	InputFps         = "InputFps"
+
+	// Output option names
+	OutputVideoCodec   = "OutputVideoCodec"
+	OutputFps          = "OutputFps"
+	OutputImageSize    = "OutputImageSize"
+	OutputVideoQuality = "OutputVideoQuality"
+
+	// Overlay option names, the overlays are drawn on the video by the drawtext filter of FFmpeg. The time zone of
+	// the timestamp is the time zone of the whole ffmpeg process, so it applies to all the local times it outputs.
+	OutputOverlayPrefix     = "OutputOverlay"
+	OutputOverlayTimestamp  = "OutputOverlayTimestamp"
+	OutputOverlayTimezone   = "OutputOverlayTimezone"
+	OutputOverlayDeviceName = "OutputOverlayDeviceName"
+	OutputOverlayText       = "OutputOverlayText"
+	OutputOverlayPosition   = "OutputOverlayPosition"
+	OutputOverlayFontSize   = "OutputOverlayFontSize"
+	OutputOverlayFontColor  = "OutputOverlayFontColor"
 
-	// udev device properties
-	UdevSerialShort = "ID_SERIAL_SHORT"
-	UdevSerial      = "ID_SERIAL"
-	UdevV4lProduct  = "ID_V4L_PRODUCT"
+	// Overlay option values
+	OverlayPositionTopLeft     = "top-left"
+	OverlayPositionTopRight    = "top-right"
+	OverlayPositionBottomLeft  = "bottom-left"
+	OverlayPositionBottomRight = "bottom-right"
+	DefaultOverlayTimestamp    = "%Y-%m-%d %H:%M:%S"
+	DefaultOverlayFontSize     = "24"
+	DefaultOverlayFontColor    = "white"
 
 	// Pixel Formats not supported by go4vl pre-defined pixel format definitions
 	PixFmtBYR2     = 844257602
@@ -98,4 +216,8 @@ const (
 
 	// RtspAuthSecretName defines the secretName used for storing RTSP credentials in the secret store.
 	RtspAuthSecretName string = "rtspauth"
+	// TlsCertSecretKey and TlsKeySecretKey are the keys of the PEM encoded certificate and private key
+	// in the secrets storing TLS certificates.
+	TlsCertSecretKey = "cert"
+	TlsKeySecretKey  = "key"
 )
@@ -11,6 +11,12 @@ import (
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
 )
 
+const (
+	// SystemEventActionRotation identifies the system event reporting the streams restarted after the
+	// rotation of the RTSP credentials
+	SystemEventActionRotation = "rotation"
+)
+
 // Credentials encapsulates Username and Password pair.
 type Credentials struct {
 	Username string
@@ -34,16 +40,77 @@ func (d *Driver) tryGetCredentials(secretPath string) (Credentials, errors.EdgeX
 	return credentials, nil
 }
 
+// credentialRotation reports the streams restarted with the new credentials after the rotation of the RTSP
+// credentials, along with the ones which failed to restart
+type credentialRotation struct {
+	Restarted []string          `json:"restarted"`
+	Failed    map[string]string `json:"failed,omitempty"`
+}
+
 func (d *Driver) secretUpdated(secretName string) {
 	d.lc.Infof("Secret updated callback called for secretName '%s'", secretName)
 
-	for _, device := range d.activeDevices {
+	// the whole rotation holds the lock of the driver, so that the driver is not stopped nor initialized while the
+	// streams are restarted, and each device is updated under its own lifecycle lock
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var streaming []*Device
+	for _, device := range d.devices.list() {
 		d.lc.Debugf("Updating usb camera device %s with new stream uri", device.name)
 		uri := d.getAuthenticatedRTSPUri(device.name)
-		if err := device.transcoder.SetOutputPath(uri); err != nil {
+		unlock := d.devices.lock(device.name)
+		device.mutex.Lock()
+		err := device.transcoder.SetOutputPath(uri)
+		isStreaming := device.streamingStatus.IsStreaming
+		device.mutex.Unlock()
+		unlock()
+		if err != nil {
 			d.lc.Errorf("Failed to update output path for device %s: %v", device.name, err)
+		} else if isStreaming {
+			streaming = append(streaming, device)
 		}
 	}
-
 	d.lc.Debug("Done updating usb device stream uris")
+
+	// the running transcoders keep publishing with the old credentials, and would be rejected when they reconnect,
+	// so they are restarted one at a time to limit the number of streams interrupted at once
+	rotation := credentialRotation{Restarted: []string{}}
+	for _, device := range streaming {
+		restarted, err := d.restartStreaming(device)
+		if err != nil {
+			d.lc.Errorf("Failed to restart the video streaming of device %s with the new credentials: %v", device.name, err)
+			if rotation.Failed == nil {
+				rotation.Failed = make(map[string]string)
+			}
+			rotation.Failed[device.name] = err.Error()
+			continue
+		}
+		if restarted {
+			rotation.Restarted = append(rotation.Restarted, device.name)
+		}
+	}
+	if len(streaming) == 0 {
+		return
+	}
+	d.lc.Infof("Restarted the video streaming of devices %v with the new credentials of secretName '%s', %d failed",
+		rotation.Restarted, secretName, len(rotation.Failed))
+	d.ds.PublishGenericSystemEvent(SystemEventTypeRtspAuth, SystemEventActionRotation, rotation)
+}
+
+// restartStreaming stops the transcoder of the device, and starts it again with its current options. It returns
+// false if the streaming of the device has been stopped in the meantime.
+func (d *Driver) restartStreaming(device *Device) (bool, error) {
+	unlock := d.devices.lock(device.name)
+	defer unlock()
+	if !device.isStreaming() {
+		return false, nil
+	}
+	if err := device.stopTranscoder(); err != nil {
+		return false, err
+	}
+	if edgexErr := d.startStreaming(device); edgexErr != nil {
+		return false, edgexErr
+	}
+	return true, nil
 }
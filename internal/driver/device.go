@@ -8,11 +8,13 @@ package driver
 
 import (
 	"fmt"
+	"net"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
@@ -37,23 +39,82 @@ var (
 )
 
 type Device struct {
-	lc                          logger.LoggingClient
-	name                        string
-	paths                       []string
-	serialNumber                string
-	rtspUri                     string
-	transcoder                  *transcoder.Transcoder
-	autoStreaming               bool
+	lc            logger.LoggingClient
+	name          string
+	paths         []string
+	serialNumber  string
+	busPath       string
+	rtspUri       string
+	transcoder    *transcoder.Transcoder
+	autoStreaming bool
+	// viewerNetworks are the networks allowed to read the stream, every network is allowed if it is empty
+	viewerNetworks              []*net.IPNet
 	mutex                       sync.Mutex
 	streamingStatus             StreamingStatus
 	streamingStatusResourceName string
+	// streamStartedAt is when the current transcoder process has started
+	streamStartedAt time.Time
+	// lastProgressAt is when the current transcoder process has last reported progress
+	lastProgressAt time.Time
+	// stopped is closed once the current transcoder process has exited
+	stopped chan struct{}
+	// stopPolicy is the stop sequence of the transcoder, and stopStage the step of it in progress
+	stopPolicy ffmpegStopPolicy
+	stopStage  string
+	// preview is the capture shared by the MJPEG preview clients, it is guarded by previewMutex
+	preview      *previewSource
+	previewMutex sync.Mutex
+	// snapshot is the latest frame of the stream, or the latest snapshot captured from the idle camera
+	snapshot snapshotFrame
+	// streamSnapshots makes the transcoder write the frames of the snapshots, snapshotOutput is whether the current
+	// transcoder process does. Otherwise, the snapshots of the stream are read from the RTSP server on request,
+	// one at a time under snapshotMutex.
+	streamSnapshots bool
+	snapshotOutput  bool
+	snapshotMutex   sync.Mutex
+	// schedule starts and stops the streaming automatically, scheduleInWindow is the state of the schedule
+	// last applied to the device
+	schedule         streamingSchedule
+	scheduleApplied  bool
+	scheduleInWindow bool
+	// streamingDefaults are the attributes of the StartStreaming resource of the profile, which hold the default
+	// ffmpeg options, and optionsConfigured is whether the ffmpeg options have been set since the device was added
+	streamingDefaults map[string]interface{}
+	optionsConfigured bool
+	// onDemand starts the streaming when the stream is read, and stops it once lastViewedAt is older than
+	// onDemandIdleTimeout
+	onDemand            bool
+	onDemandIdleTimeout time.Duration
+	lastViewedAt        time.Time
+	// bandwidth is the estimated USB bandwidth of the stream when the streaming was last admitted
+	bandwidth *StreamBandwidth
+	// overlayTimezone is the time zone of the timestamp overlay, the local time zone of the service is used if empty
+	overlayTimezone string
 }
 
-func (dev *Device) StartStreaming() (<-chan string, <-chan error, error) {
+// status returns a snapshot of the StreamingStatus of the device
+func (dev *Device) status() StreamingStatus {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	return dev.streamingStatus
+}
+
+// isStreaming returns whether the transcoder of the device is running
+func (dev *Device) isStreaming() bool {
 	dev.mutex.Lock()
-	isStreaming := dev.streamingStatus.IsStreaming
-	dev.mutex.Unlock()
-	if isStreaming {
+	defer dev.mutex.Unlock()
+	return dev.streamingStatus.IsStreaming
+}
+
+// setTranscoderInputPath sets the input path reported in the StreamingStatus of the device
+func (dev *Device) setTranscoderInputPath(fdPath string) {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	dev.streamingStatus.TranscoderInputPath = fdPath
+}
+
+func (dev *Device) StartStreaming() (<-chan string, <-chan error, error) {
+	if dev.isStreaming() {
 		return nil, nil, fmt.Errorf("video streaming is already in progress")
 	}
 
@@ -66,21 +127,21 @@ func (dev *Device) StartStreaming() (<-chan string, <-chan error, error) {
 	return progressChan, errChan, nil
 }
 
+// StopStreaming stops the transcoder with its stop sequence, and returns once its process has exited
 func (dev *Device) StopStreaming() {
-	dev.mutex.Lock()
-	defer dev.mutex.Unlock()
-	if !dev.streamingStatus.IsStreaming {
+	if !dev.isStreaming() {
 		return
 	}
 
 	dev.lc.Debugf("Stopping transcoder for device %s", dev.name)
-	if err := dev.transcoder.Stop(); err != nil {
+	if err := dev.stopTranscoder(); err != nil {
 		dev.lc.Errorf("Failed to stop video streaming transcoder for device %s, error: %s", dev.name, err)
-		return
 	}
 }
 
 func (dev *Device) updateTranscoderInputPath(fdPath string) error {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
 	trans := dev.transcoder
 	err := trans.SetInputPath(fdPath)
 	if err != nil {
@@ -230,6 +291,7 @@ func (dev *Device) GetPixelFormat(usbDevice *usbdevice.Device) (interface{}, err
 	return result, nil
 }
 
+// updateFFmpegOptions sets the option in the StreamingStatus of the device, the caller must hold dev.mutex
 func (dev *Device) updateFFmpegOptions(optName, optVal string) {
 	ps := reflect.ValueOf(&dev.streamingStatus)
 	s := ps.Elem()
@@ -0,0 +1,183 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+
+	sdkModels "github.com/edgexfoundry/device-sdk-go/v4/pkg/models"
+
+	usbDevice "github.com/vladimirvivien/go4vl/device"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// The results of the discovery of a video device node
+const (
+	DiscoveryResultAccepted = "accepted"
+	DiscoveryResultMerged   = "merged"
+	DiscoveryResultRejected = "rejected"
+
+	// filteredReasonPrefix prefixes the reasons of the devices rejected by the discovery filter
+	filteredReasonPrefix = "filtered: "
+)
+
+var (
+	// videoDevicePaths lists the video device nodes of the host
+	videoDevicePaths = usbDevice.GetAllDevicePaths
+	// videoDeviceCapability queries the capability of the video device node on the specified path
+	videoDeviceCapability = func(path string) (v4l2.Capability, error) {
+		cameraDevice, err := usbDevice.Open(path)
+		if err != nil {
+			return v4l2.Capability{}, err
+		}
+		defer cameraDevice.Close()
+		return cameraDevice.Capability(), nil
+	}
+)
+
+// DiscoveryReportEntry describes what the discovery does with a video device node
+type DiscoveryReportEntry struct {
+	Path             string             `json:"path"`
+	CaptureDevice    bool               `json:"captureDevice"`
+	StreamingCapable bool               `json:"streamingCapable"`
+	Identity         *USBDeviceIdentity `json:"identity,omitempty"`
+	// ExistingDevice is the name of the existing device the camera belongs to
+	ExistingDevice string `json:"existingDevice,omitempty"`
+	// DiscoveredDevice is the name of the device the discovery reports for the camera
+	DiscoveredDevice string `json:"discoveredDevice,omitempty"`
+	Result           string `json:"result"`
+	Reason           string `json:"reason"`
+}
+
+// discoveryScan is the result of scanning the video device nodes of the host
+type discoveryScan struct {
+	// discovered are the new devices keyed by their identity key
+	discovered   map[string]sdkModels.DiscoveredDevice
+	capabilities map[string]CameraCapabilities
+	// existingDevices are the existing devices whose paths should be refreshed keyed by their name
+	existingDevices map[string]models.Device
+	report          []DiscoveryReportEntry
+}
+
+// scanVideoDevices scans the video device nodes of the host and finds out which of them belong to existing devices
+// and which of them are new devices, without changing anything.
+func (d *Driver) scanVideoDevices() *discoveryScan {
+	scan := &discoveryScan{
+		discovered:      make(map[string]sdkModels.DiscoveredDevice),
+		capabilities:    make(map[string]CameraCapabilities),
+		existingDevices: make(map[string]models.Device),
+	}
+
+	// Convert the slice of cached devices to map in order to improve the performance in the subsequent for loop.
+	currentDevices := d.cachedDeviceMap()
+
+	allDevices, err := videoDevicePaths()
+	if err != nil {
+		d.lc.Errorf("failed to list the video devices, error: %s", err.Error())
+	}
+	for _, fdPath := range allDevices {
+		entry := DiscoveryReportEntry{Path: fdPath, Result: DiscoveryResultRejected}
+		d.scanVideoDevice(scan, currentDevices, &entry)
+		scan.report = append(scan.report, entry)
+	}
+	return scan
+}
+
+func (d *Driver) scanVideoDevice(scan *discoveryScan, currentDevices map[string]models.Device, entry *DiscoveryReportEntry) {
+	fdPath := entry.Path
+	c, err := videoDeviceCapability(fdPath)
+	if err != nil {
+		entry.Reason = fmt.Sprintf("cannot be opened: %s", err.Error())
+		return
+	}
+	entry.CaptureDevice = isVideoCaptureSupported(c)
+	entry.StreamingCapable = isStreamingSupported(c)
+	if !entry.CaptureDevice {
+		entry.Reason = "not a video capture device"
+		return
+	}
+	if !entry.StreamingCapable {
+		entry.Reason = "not streaming-capable"
+		return
+	}
+
+	identity, edgexErr := d.getUSBDeviceIdentity(fdPath)
+	if edgexErr != nil {
+		entry.Reason = fmt.Sprintf("missing serial number: %s", edgexErr.Error())
+		return
+	}
+	entry.Identity = &identity
+	cn, sn, bp := identity.CardName, identity.SerialNumber, identity.BusPath
+
+	// Update existing device if it's path has changed, the devices bound to the port take precedence
+	cd, ok := currentDevices[identityKey(IdentityModePort, cn, sn, bp)]
+	if !ok {
+		cd, ok = currentDevices[identityKey(IdentityModeSerial, cn, sn, bp)]
+	}
+	if ok {
+		scan.existingDevices[cd.Name] = cd
+		entry.ExistingDevice = cd.Name
+		entry.Result = DiscoveryResultMerged
+		entry.Reason = fmt.Sprintf("belongs to the existing device %s", cd.Name)
+		return
+	}
+
+	if reason := d.discoveryFilter.skipReason(fdPath, identity); reason != "" {
+		entry.Reason = filteredReasonPrefix + reason
+		return
+	}
+
+	key := identityKey(d.identityMode, cn, sn, bp)
+	caps, err := getCameraCapabilities(fdPath)
+	if err != nil {
+		d.lc.Warnf("failed to get the capabilities of the device on path %s, error: %s", fdPath, err.Error())
+	}
+	discovered, found := scan.discovered[key]
+	if !found {
+		discovered = newDiscoveredDevice(buildDiscoveredDeviceName(d.identityMode, identity), fdPath, identity, caps)
+		if d.identityMode == IdentityModePort {
+			discovered.Protocols[UsbProtocol][IdentityMode] = string(IdentityModePort)
+		}
+		scan.discovered[key] = discovered
+		scan.capabilities[key] = caps
+		entry.DiscoveredDevice = discovered.Name
+		entry.Result = DiscoveryResultAccepted
+		entry.Reason = "new device"
+		return
+	}
+
+	discovered.Protocols[UsbProtocol][Paths] = append(discovered.Protocols[UsbProtocol][Paths].([]string), fdPath)
+	// the capabilities of a camera are the ones of all its paths
+	merged := scan.capabilities[key]
+	merged.merge(caps)
+	merged.setProtocolProperties(discovered.Protocols[UsbProtocol])
+	discovered.Labels = discoveredDeviceLabels(identity, merged)
+	scan.discovered[key] = discovered
+	scan.capabilities[key] = merged
+	entry.DiscoveredDevice = discovered.Name
+	entry.Result = DiscoveryResultMerged
+	entry.Reason = fmt.Sprintf("another path of the discovered device %s", discovered.Name)
+}
+
+// logDiscoveryReport logs what the discovery did with each video device node
+func (d *Driver) logDiscoveryReport(report []DiscoveryReportEntry) {
+	for _, entry := range report {
+		switch {
+		case entry.Result == DiscoveryResultAccepted:
+			d.lc.Infof("discovered device: %s", entry.DiscoveredDevice)
+		case entry.Result == DiscoveryResultRejected && strings.HasPrefix(entry.Reason, filteredReasonPrefix):
+			d.lc.Infof("skipping the device on path %s, %s", entry.Path, entry.Reason)
+		case entry.Result == DiscoveryResultRejected && entry.CaptureDevice && entry.StreamingCapable:
+			d.lc.Errorf("skipping the device on path %s, %s", entry.Path, entry.Reason)
+		default:
+			d.lc.Debugf("device on path %s %s: %s", entry.Path, entry.Result, entry.Reason)
+		}
+	}
+}
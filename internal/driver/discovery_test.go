@@ -0,0 +1,146 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// setFakeVideoDevices replaces the video device nodes of the host with the specified ones for the duration of the test
+func setFakeVideoDevices(t *testing.T, capabilities map[string]uint32) {
+	originalPaths, originalCapability := videoDevicePaths, videoDeviceCapability
+	t.Cleanup(func() {
+		videoDevicePaths, videoDeviceCapability = originalPaths, originalCapability
+	})
+	var paths []string
+	for i := 0; i < len(capabilities); i++ {
+		paths = append(paths, fmt.Sprintf("/dev/video%d", i))
+	}
+	videoDevicePaths = func() ([]string, error) {
+		return paths, nil
+	}
+	videoDeviceCapability = func(path string) (v4l2.Capability, error) {
+		caps, ok := capabilities[path]
+		if !ok {
+			return v4l2.Capability{}, fmt.Errorf("no such device")
+		}
+		return v4l2.Capability{DeviceCapabilities: caps}, nil
+	}
+}
+
+func newFakeDiscoveryDriver(t *testing.T, configs map[string]string) *Driver {
+	root := t.TempDir()
+	camera := func(node, cardName, busPath, interfaceNumber, serial string) sysfsFixtureDevice {
+		return sysfsFixtureDevice{
+			node:            node,
+			cardName:        cardName,
+			busPath:         busPath,
+			interfaceNumber: interfaceNumber,
+			driver:          "uvcvideo",
+			usbAttributes:   map[string]string{"idVendor": "1bcf", "idProduct": "2c99", "serial": serial},
+		}
+	}
+	createSysfsFixture(t, root,
+		camera("video0", "Existing Camera", "1-1", "00", "SN0001"),
+		camera("video1", "Existing Camera", "1-1", "00", "SN0001"),
+		camera("video2", "New Camera", "1-2", "00", "SN0002"),
+		camera("video3", "New Camera", "1-2", "02", "SN0002"),
+		camera("video4", "USB3.0 HDMI Capture", "1-3", "00", "SN0003"),
+		camera("video6", "Output Device", "1-4", "00", "SN0004"),
+	)
+	capture := v4l2.CapVideoCapture | v4l2.CapStreaming
+	setFakeVideoDevices(t, map[string]uint32{
+		"/dev/video0": capture,
+		// the metadata node of the camera
+		"/dev/video1": v4l2.CapStreaming,
+		"/dev/video2": capture,
+		"/dev/video3": capture,
+		"/dev/video4": capture,
+		// not in the sysfs tree
+		"/dev/video5": capture,
+		"/dev/video6": v4l2.CapVideoCapture,
+	})
+
+	d, mockService := createDriverWithMockService()
+	d.sysfsRoot = root
+	filter, err := newDiscoveryFilter(configs)
+	require.NoError(t, err)
+	d.discoveryFilter = filter
+	mockService.On("Devices").Return([]models.Device{{
+		Name: "existing-camera",
+		Protocols: map[string]models.ProtocolProperties{
+			UsbProtocol: {CardName: "Existing Camera", SerialNumber: "SN0001", Paths: []any{"/dev/video0"}},
+		},
+	}})
+	return d
+}
+
+func TestScanVideoDevices(t *testing.T) {
+	d := newFakeDiscoveryDriver(t, map[string]string{DiscoveryDenyCardName: "(?i)hdmi"})
+
+	scan := d.scanVideoDevices()
+	require.Len(t, scan.report, 7)
+	expected := []struct {
+		result           string
+		reason           string
+		existingDevice   string
+		discoveredDevice string
+	}{
+		{DiscoveryResultMerged, "belongs to the existing device existing-camera", "existing-camera", ""},
+		{DiscoveryResultRejected, "not a video capture device", "", ""},
+		{DiscoveryResultAccepted, "new device", "", "New_Camera-SN0002"},
+		{DiscoveryResultMerged, "another path of the discovered device New_Camera-SN0002", "", "New_Camera-SN0002"},
+		{DiscoveryResultRejected, `filtered: CardName "USB3.0 HDMI Capture" matches the deny list "(?i)hdmi"`, "", ""},
+		{DiscoveryResultRejected, "missing serial number", "", ""},
+		{DiscoveryResultRejected, "not streaming-capable", "", ""},
+	}
+	for i, entry := range scan.report {
+		assert.Equal(t, fmt.Sprintf("/dev/video%d", i), entry.Path)
+		assert.Equal(t, expected[i].result, entry.Result, entry.Path)
+		assert.Contains(t, entry.Reason, expected[i].reason, entry.Path)
+		assert.Equal(t, expected[i].existingDevice, entry.ExistingDevice, entry.Path)
+		assert.Equal(t, expected[i].discoveredDevice, entry.DiscoveredDevice, entry.Path)
+	}
+	assert.Equal(t, "SN0001", scan.report[0].Identity.SerialNumber)
+	assert.Nil(t, scan.report[5].Identity)
+
+	require.Contains(t, scan.existingDevices, "existing-camera")
+	require.Len(t, scan.discovered, 1)
+	for _, discovered := range scan.discovered {
+		assert.Equal(t, []string{"/dev/video2", "/dev/video3"}, discovered.Protocols[UsbProtocol][Paths])
+	}
+}
+
+func TestDiscoveryReportRoute(t *testing.T) {
+	// the mock service fails the test if the report updates any device
+	d := newFakeDiscoveryDriver(t, nil)
+
+	request := httptest.NewRequest(http.MethodGet, common.ApiBase+ApiDiscoveryReport, nil)
+	request.Header.Set(common.CorrelationHeader, "correlation-id")
+	recorder := httptest.NewRecorder()
+	d.DiscoveryReportRoute(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "correlation-id", recorder.Header().Get(common.CorrelationHeader))
+	assert.Equal(t, common.ContentTypeJSON, recorder.Header().Get(common.ContentType))
+	var response DiscoveryReportResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	require.Len(t, response.Entries, 7)
+	assert.Equal(t, DiscoveryResultAccepted, response.Entries[4].Result, "the HDMI dongle is not filtered")
+	assert.Equal(t, "1-3", response.Entries[4].Identity.BusPath)
+}
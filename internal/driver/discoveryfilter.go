@@ -0,0 +1,122 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+const (
+	// sysfs values of the USB devices which are built into the host
+	sysfsRemovableFixed       = "fixed"
+	sysfsConnectTypeHardwired = "hardwired"
+)
+
+// integratedCameraNameRegex matches the card names used by the integrated cameras of laptops
+var integratedCameraNameRegex = regexp.MustCompile(`(?i)\b(integrated|built-?in)\b`)
+
+// discoveryFilterRule allows and denies the devices by matching one attribute of the devices
+type discoveryFilterRule struct {
+	attribute string
+	value     func(path string, identity USBDeviceIdentity) string
+	allow     *regexp.Regexp
+	deny      *regexp.Regexp
+}
+
+// DiscoveryFilter decides which of the devices found by Discover are reported to the device service
+type DiscoveryFilter struct {
+	rules                 []discoveryFilterRule
+	skipIntegratedCameras bool
+}
+
+// newDiscoveryFilter creates the discovery filter from the driver configs. Each allow and deny config is a regular
+// expression, use alternation such as "^uvcvideo$|^usb_video$" to list several patterns.
+func newDiscoveryFilter(configs map[string]string) (*DiscoveryFilter, error) {
+	filter := &DiscoveryFilter{}
+	attributes := []struct {
+		name     string
+		allowKey string
+		denyKey  string
+		value    func(path string, identity USBDeviceIdentity) string
+	}{
+		{CardName, DiscoveryAllowCardName, DiscoveryDenyCardName,
+			func(_ string, identity USBDeviceIdentity) string { return identity.CardName }},
+		{"USB ID", DiscoveryAllowUSBID, DiscoveryDenyUSBID,
+			func(_ string, identity USBDeviceIdentity) string { return identity.VendorID + ":" + identity.ProductID }},
+		{KernelDriver, DiscoveryAllowDriver, DiscoveryDenyDriver,
+			func(_ string, identity USBDeviceIdentity) string { return identity.Driver }},
+		{Path, DiscoveryAllowPath, DiscoveryDenyPath,
+			func(path string, _ USBDeviceIdentity) string { return path }},
+	}
+	for _, attribute := range attributes {
+		rule := discoveryFilterRule{attribute: attribute.name, value: attribute.value}
+		var err error
+		if rule.allow, err = compileDiscoveryFilterRegex(configs, attribute.allowKey); err != nil {
+			return nil, err
+		}
+		if rule.deny, err = compileDiscoveryFilterRegex(configs, attribute.denyKey); err != nil {
+			return nil, err
+		}
+		if rule.allow != nil || rule.deny != nil {
+			filter.rules = append(filter.rules, rule)
+		}
+	}
+
+	if value := strings.TrimSpace(configs[DiscoverySkipIntegratedCameras]); value != "" {
+		skip, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s value of \"%s\" is invalid, it must be a boolean", DiscoverySkipIntegratedCameras, value)
+		}
+		filter.skipIntegratedCameras = skip
+	}
+	return filter, nil
+}
+
+func compileDiscoveryFilterRegex(configs map[string]string, key string) (*regexp.Regexp, error) {
+	expr := strings.TrimSpace(configs[key])
+	if expr == "" {
+		return nil, nil
+	}
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s value of \"%s\" is not a valid regular expression: %w", key, expr, err)
+	}
+	return regex, nil
+}
+
+// skipReason returns why the device on the specified path should be skipped, or an empty string if it is accepted
+func (f *DiscoveryFilter) skipReason(path string, identity USBDeviceIdentity) string {
+	if f == nil {
+		return ""
+	}
+	for _, rule := range f.rules {
+		value := rule.value(path, identity)
+		if rule.allow != nil && !rule.allow.MatchString(value) {
+			return fmt.Sprintf("%s \"%s\" does not match the allow list \"%s\"", rule.attribute, value, rule.allow.String())
+		}
+		if rule.deny != nil && rule.deny.MatchString(value) {
+			return fmt.Sprintf("%s \"%s\" matches the deny list \"%s\"", rule.attribute, value, rule.deny.String())
+		}
+	}
+	if f.skipIntegratedCameras && isIntegratedCamera(identity) {
+		return "the camera is built into the host"
+	}
+	return ""
+}
+
+// isIntegratedCamera checks whether the camera is built into the host, which is reported by the platform firmware
+// through the removable attribute of the USB device or the connect type of its port. The card name is used as
+// a fallback as many laptops do not report it.
+func isIntegratedCamera(identity USBDeviceIdentity) bool {
+	return identity.Removable == sysfsRemovableFixed ||
+		identity.ConnectType == sysfsConnectTypeHardwired ||
+		integratedCameraNameRegex.MatchString(identity.CardName)
+}
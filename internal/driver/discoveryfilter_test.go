@@ -0,0 +1,86 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiscoveryFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		expectErr bool
+	}{
+		{"no filters", map[string]string{}, false},
+		{"valid filters", map[string]string{DiscoveryDenyCardName: "(?i)hdmi|capture", DiscoverySkipIntegratedCameras: "true"}, false},
+		{"invalid regex", map[string]string{DiscoveryAllowUSBID: "046d:(0825"}, true},
+		{"invalid boolean", map[string]string{DiscoverySkipIntegratedCameras: "sometimes"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newDiscoveryFilter(tt.configs)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDiscoveryFilterSkipReason(t *testing.T) {
+	webcam := USBDeviceIdentity{CardName: "HD Pro Webcam C920", VendorID: "046d", ProductID: "082d", Driver: "uvcvideo", Removable: "removable"}
+	hdmiDongle := USBDeviceIdentity{CardName: "USB3.0 HDMI Capture", VendorID: "534d", ProductID: "2109", Driver: "uvcvideo"}
+	laptopCamera := USBDeviceIdentity{CardName: "HP HD Camera", VendorID: "04f2", ProductID: "b6ab", Driver: "uvcvideo", Removable: "fixed"}
+	hardwiredCamera := USBDeviceIdentity{CardName: "USB Camera", VendorID: "0c45", ProductID: "6366", Driver: "uvcvideo", ConnectType: "hardwired"}
+	namedCamera := USBDeviceIdentity{CardName: "Integrated Camera: Integrated C", VendorID: "5986", ProductID: "2113", Driver: "uvcvideo"}
+
+	tests := []struct {
+		name           string
+		configs        map[string]string
+		path           string
+		identity       USBDeviceIdentity
+		expectedReason string
+	}{
+		{"no filters", map[string]string{}, "/dev/video0", hdmiDongle, ""},
+		{"card name denied", map[string]string{DiscoveryDenyCardName: "(?i)hdmi"}, "/dev/video0", hdmiDongle,
+			`CardName "USB3.0 HDMI Capture" matches the deny list "(?i)hdmi"`},
+		{"card name not denied", map[string]string{DiscoveryDenyCardName: "(?i)hdmi"}, "/dev/video0", webcam, ""},
+		{"usb id allowed", map[string]string{DiscoveryAllowUSBID: "^046d:"}, "/dev/video0", webcam, ""},
+		{"usb id not allowed", map[string]string{DiscoveryAllowUSBID: "^046d:"}, "/dev/video0", hdmiDongle,
+			`USB ID "534d:2109" does not match the allow list "^046d:"`},
+		{"usb id denied", map[string]string{DiscoveryDenyUSBID: "^534d:2109$"}, "/dev/video0", hdmiDongle,
+			`USB ID "534d:2109" matches the deny list "^534d:2109$"`},
+		{"driver not allowed", map[string]string{DiscoveryAllowDriver: "^usb_video$"}, "/dev/video0", webcam,
+			`Driver "uvcvideo" does not match the allow list "^usb_video$"`},
+		{"path denied", map[string]string{DiscoveryDenyPath: "^/dev/video(0|1)$"}, "/dev/video0", webcam,
+			`Path "/dev/video0" matches the deny list "^/dev/video(0|1)$"`},
+		{"path not denied", map[string]string{DiscoveryDenyPath: "^/dev/video(0|1)$"}, "/dev/video10", webcam, ""},
+		{"fixed camera skipped", map[string]string{DiscoverySkipIntegratedCameras: "true"}, "/dev/video0", laptopCamera,
+			"the camera is built into the host"},
+		{"hardwired camera skipped", map[string]string{DiscoverySkipIntegratedCameras: "true"}, "/dev/video0", hardwiredCamera,
+			"the camera is built into the host"},
+		{"integrated camera name skipped", map[string]string{DiscoverySkipIntegratedCameras: "true"}, "/dev/video0", namedCamera,
+			"the camera is built into the host"},
+		{"removable camera not skipped", map[string]string{DiscoverySkipIntegratedCameras: "true"}, "/dev/video0", webcam, ""},
+		{"integrated camera not skipped", map[string]string{DiscoverySkipIntegratedCameras: "false"}, "/dev/video0", laptopCamera, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newDiscoveryFilter(tt.configs)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedReason, filter.skipReason(tt.path, tt.identity))
+		})
+	}
+
+	var noFilter *DiscoveryFilter
+	assert.Empty(t, noFilter.skipReason("/dev/video0", hdmiDongle))
+}
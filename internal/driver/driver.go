@@ -12,6 +12,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -31,6 +32,8 @@ import (
 	"github.com/edgexfoundry/device-sdk-go/v4/pkg/interfaces"
 	sdkModels "github.com/edgexfoundry/device-sdk-go/v4/pkg/models"
 
+	"github.com/edgexfoundry/device-usb-camera/internal/rtspserver"
+
 	"github.com/labstack/echo/v4"
 	"github.com/spf13/cast"
 	usbDevice "github.com/vladimirvivien/go4vl/device"
@@ -46,13 +49,46 @@ type Driver struct {
 	wg                          *sync.WaitGroup
 	asyncCh                     chan<- *sdkModels.AsyncValues
 	deviceCh                    chan<- []sdkModels.DiscoveredDevice
-	activeDevices               map[string]*Device
+	devices                     *deviceRegistry
 	rtspHostName                string
 	rtspTcpPort                 string
 	rtspAuthenticationServerUri string
 	mutex                       sync.Mutex
 	rtspAuthServer              *echo.Echo
-	rtspServerMode              RTSPServerMode
+	rtspServer                  *rtspserver.Server
+	rtspHealth                  *rtspHealthChecker
+	// rtspTls enables the RTSPS mode, where the streams are published and read with rtsps uris
+	rtspTls bool
+	// rtspCertificate is the certificate of the internal or embedded RTSP server in the RTSPS mode
+	rtspCertificate *tlsCertificate
+	// authServerCertificate is the certificate of the RTSP authentication server, which uses TLS if it is set
+	authServerCertificate *tlsCertificate
+	rtspServerMode        RTSPServerMode
+	sysfsRoot             string
+	identityMode          DeviceIdentityMode
+	discoveryFilter       *DiscoveryFilter
+	previewConfig         previewConfig
+	authGuard             *authGuard
+	streamTokenConfig     streamTokenConfig
+	// streamTokenRandomKey signs the stream tokens if no key is stored in the secret store
+	streamTokenRandomKey []byte
+	streamTokenKeyOnce   sync.Once
+	// defaultViewerNetworks are the networks allowed to read the streams of the devices without the
+	// ViewerAllowedCidrs protocol property
+	defaultViewerNetworks []*net.IPNet
+	ffmpegStopPolicy      ffmpegStopPolicy
+	// scheduleDone stops the streaming schedules of the devices
+	scheduleDone chan struct{}
+	// onDemandIdleTimeout is the default idle timeout of the on demand streams, and onDemandDone stops their checks
+	onDemandIdleTimeout time.Duration
+	onDemandDone        chan struct{}
+	// rtspServerApiAddress is the address of the API of the internal RTSP server
+	rtspServerApiAddress string
+	// admissionConfig limits the transcoders, admissionMutex checks the starts of the streaming one after the other
+	admissionConfig admissionConfig
+	admissionMutex  sync.Mutex
+	// streamSnapshots adds an output of the snapshots to the transcoders, see Device.streamSnapshots
+	streamSnapshots bool
 }
 
 // NewProtocolDriver initializes the singleton Driver and returns it to the caller
@@ -84,14 +120,71 @@ func (d *Driver) Initialize(sdk interfaces.DeviceServiceSDK) error {
 	d.asyncCh = sdk.AsyncValuesChannel()
 	d.deviceCh = sdk.DiscoveredDeviceChannel()
 	d.ds = sdk
-	d.activeDevices = make(map[string]*Device)
+	d.devices = newDeviceRegistry()
 	d.wg = new(sync.WaitGroup)
 
 	if err := d.ds.AddCustomRoute(common.ApiBase+ApiRefreshDevicePaths, interfaces.Unauthenticated, echo.WrapHandler(http.HandlerFunc(d.RefreshExistingDevicePathsRoute)), http.MethodPost); err != nil {
 		return fmt.Errorf("failed to add API route %s, error: %s", ApiRefreshDevicePaths, err.Error())
 	}
+	if err := d.ds.AddCustomRoute(common.ApiBase+ApiDiscoveryReport, interfaces.Authenticated, echo.WrapHandler(http.HandlerFunc(d.DiscoveryReportRoute)), http.MethodGet); err != nil {
+		return fmt.Errorf("failed to add API route %s, error: %s", ApiDiscoveryReport, err.Error())
+	}
+	if err := d.ds.AddCustomRoute(common.ApiBase+ApiCameras, interfaces.Authenticated, echo.WrapHandler(http.HandlerFunc(d.CamerasRoute)), http.MethodGet); err != nil {
+		return fmt.Errorf("failed to add API route %s, error: %s", ApiCameras, err.Error())
+	}
+	if err := d.ds.AddCustomRoute(common.ApiBase+ApiCameraByName, interfaces.Authenticated, d.CameraRoute, http.MethodGet); err != nil {
+		return fmt.Errorf("failed to add API route %s, error: %s", ApiCameraByName, err.Error())
+	}
+	if err := d.ds.AddCustomRoute(common.ApiBase+ApiPreview, interfaces.Unauthenticated, d.PreviewRoute, http.MethodGet); err != nil {
+		return fmt.Errorf("failed to add API route %s, error: %s", ApiPreview, err.Error())
+	}
+	if err := d.ds.AddCustomRoute(common.ApiBase+ApiSnapshot, interfaces.Unauthenticated, d.SnapshotRoute, http.MethodGet); err != nil {
+		return fmt.Errorf("failed to add API route %s, error: %s", ApiSnapshot, err.Error())
+	}
+
+	d.sysfsRoot = DefaultSysfsRoot
+	if sysfsRoot, ok := d.ds.DriverConfigs()[SysfsRoot]; ok && sysfsRoot != "" {
+		d.sysfsRoot = sysfsRoot
+	}
+	d.lc.Debugf("sysfs root: %s", d.sysfsRoot)
+
+	identityMode, err := parseIdentityMode(d.ds.DriverConfigs()[IdentityMode])
+	if err != nil {
+		return err
+	}
+	d.identityMode = identityMode
+	d.lc.Infof("device identity mode: %s", d.identityMode)
+
+	if d.discoveryFilter, err = newDiscoveryFilter(d.ds.DriverConfigs()); err != nil {
+		return err
+	}
+	if d.previewConfig, err = parsePreviewConfig(d.ds.DriverConfigs()); err != nil {
+		return err
+	}
+	if d.streamSnapshots, err = parseBoolConfig(d.ds.DriverConfigs(), StreamSnapshots, false); err != nil {
+		return err
+	}
+	authGuardConfig, err := parseAuthGuardConfig(d.ds.DriverConfigs())
+	if err != nil {
+		return err
+	}
+	d.authGuard = newAuthGuard(authGuardConfig)
+	if d.streamTokenConfig, err = parseStreamTokenConfig(d.ds.DriverConfigs()); err != nil {
+		return err
+	}
+	if d.ffmpegStopPolicy, err = parseFFmpegStopPolicy(d.ds.DriverConfigs()); err != nil {
+		return err
+	}
+	if d.defaultViewerNetworks, err = parseViewerNetworks(d.ds.DriverConfigs()[ViewerAllowedCidrs]); err != nil {
+		return fmt.Errorf("%s value of \"%s\" is invalid: %w", ViewerAllowedCidrs, d.ds.DriverConfigs()[ViewerAllowedCidrs], err)
+	}
+	if d.onDemandIdleTimeout, err = parseOnDemandIdleTimeout(d.ds.DriverConfigs()[OnDemandIdleTimeout], DefaultOnDemandIdleTimeout); err != nil {
+		return err
+	}
+	if d.admissionConfig, err = parseAdmissionConfig(d.ds.DriverConfigs()); err != nil {
+		return err
+	}
 
-	var err error
 	// if RtspServerMode config parameter is empty, then it should default to
 	// "internal" to retain backwards-compatibility
 	d.rtspServerMode = RTSPServerMode(strings.ToLower(d.ds.DriverConfigs()[RtspServerMode]))
@@ -99,8 +192,9 @@ func (d *Driver) Initialize(sdk interfaces.DeviceServiceSDK) error {
 		d.rtspServerMode = RTSPServerModeInternal
 	} else if d.rtspServerMode == RTSPServerModeNone {
 		return nil // nothing left to do
-	} else if d.rtspServerMode != RTSPServerModeInternal && d.rtspServerMode != RTSPServerModeExternal {
-		return fmt.Errorf("%s value of \"%s\" is invalid. valid options are \"internal\", \"external\", and \"none\"",
+	} else if d.rtspServerMode != RTSPServerModeInternal && d.rtspServerMode != RTSPServerModeExternal &&
+		d.rtspServerMode != RTSPServerModeEmbedded {
+		return fmt.Errorf("%s value of \"%s\" is invalid. valid options are \"internal\", \"external\", \"embedded\", and \"none\"",
 			RtspServerMode, d.rtspServerMode)
 	}
 
@@ -111,6 +205,24 @@ func (d *Driver) Initialize(sdk interfaces.DeviceServiceSDK) error {
 	}
 	d.lc.Infof("RtspAuthenticationServer: %s", rtspAuthenticationServerUri)
 	d.rtspAuthenticationServerUri = rtspAuthenticationServerUri
+	if d.rtspServerMode != RTSPServerModeEmbedded {
+		d.authServerCertificate, err = d.loadTLSOption(d.ds.DriverConfigs(), RtspAuthServerTls,
+			RtspAuthServerTlsSecretName, RtspAuthServerTlsCertFile, RtspAuthServerTlsKeyFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.rtspTls, err = parseBoolConfig(d.ds.DriverConfigs(), RtspTls, false); err != nil {
+		return err
+	}
+	// the certificate of an external RTSP server is configured in the server itself
+	if d.rtspTls && d.rtspServerMode != RTSPServerModeExternal {
+		d.rtspCertificate, err = d.loadTLSOption(d.ds.DriverConfigs(), RtspTls, RtspTlsSecretName, RtspTlsCertFile, RtspTlsKeyFile)
+		if err != nil {
+			return err
+		}
+	}
 
 	if err := d.ds.SecretProvider().RegisterSecretUpdatedCallback(RtspAuthSecretName, d.secretUpdated); err != nil {
 		d.lc.Errorf("failed to register secret update callback: %v", err)
@@ -132,6 +244,15 @@ func (d *Driver) Initialize(sdk interfaces.DeviceServiceSDK) error {
 	d.lc.Infof("RTSP TCP port: %s", rtspPort)
 	d.rtspTcpPort = rtspPort
 
+	if d.rtspServerMode == RTSPServerModeExternal {
+		healthConfig, err := parseRTSPHealthConfig(d.ds.DriverConfigs())
+		if err != nil {
+			return err
+		}
+		healthConfig.Tls = d.rtspTls
+		d.rtspHealth = newRTSPHealthChecker(d.lc, net.JoinHostPort(d.rtspHostName, d.rtspTcpPort), healthConfig)
+	}
+
 	if d.rtspServerMode != RTSPServerModeInternal {
 		return nil // nothing left to do
 	}
@@ -153,9 +274,23 @@ func (d *Driver) Initialize(sdk interfaces.DeviceServiceSDK) error {
 		}
 	}
 
-	rtspProc := exec.Command(rtspExecutable)
+	rtspConfig, err := d.newMediamtxConfig(d.ds.DriverConfigs(), d.ds.Devices())
+	if err != nil {
+		return err
+	}
+	rtspConfigFile, err := d.writeMediamtxConfig(d.ds.DriverConfigs(), rtspConfig)
+	if err != nil {
+		return err
+	}
+	d.rtspServerApiAddress = rtspConfig.APIAddress
+
+	rtspProc := exec.Command(rtspExecutable, rtspConfigFile)
 	rtspProc.Stdout = os.Stdout
 	rtspProc.Stderr = os.Stderr
+	if d.authServerCertificate != nil {
+		// the certificate of the authentication server may be self-signed, so it is the one the RTSP server trusts
+		rtspProc.Env = append(os.Environ(), "SSL_CERT_FILE="+d.authServerCertificate.CertFile)
+	}
 	err = rtspProc.Start()
 	if err != nil {
 		return fmt.Errorf("unable to start %s process: %s", rtspExecutable, err.Error())
@@ -173,7 +308,9 @@ func (d *Driver) Start() error {
 			d.lc.Error(edgexErr.Error())
 			continue
 		}
-		d.activeDevices[dev.Name] = activeDevice
+		if err := d.devices.add(activeDevice, nil); err != nil {
+			d.lc.Error(err.Error())
+		}
 	}
 
 	// Make sure the paths of existing devices are up-to-date.
@@ -184,22 +321,42 @@ func (d *Driver) Start() error {
 		return nil
 	}
 
-	d.wg.Add(1)
-	go func() {
-		defer d.wg.Done()
-		d.StartRTSPCredentialServer()
-	}()
+	if d.rtspServerMode == RTSPServerModeEmbedded {
+		if err := d.startEmbeddedRTSPServer(); err != nil {
+			return err
+		}
+	} else {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.StartRTSPCredentialServer()
+		}()
+	}
+	if d.rtspHealth != nil {
+		d.startRTSPHealthChecks()
+	}
 
-	for _, dev := range d.activeDevices {
+	for _, dev := range d.devices.list() {
 		if dev.autoStreaming {
-			dev.streamingStatus.TranscoderInputPath = dev.paths[0]
+			dev.setTranscoderInputPath(dev.paths[0])
+			if d.rtspHealth != nil && !d.rtspHealth.status().Available {
+				d.lc.Warnf("Video streaming for device %s is queued until the external rtsp server is available", dev.name)
+				d.rtspHealth.queue(dev.name)
+				continue
+			}
+			unlock := d.devices.lock(dev.name)
 			edgexErr := d.startStreaming(dev)
+			unlock()
 			if edgexErr != nil {
 				d.lc.Errorf("failed to start video streaming for device %s, error: %s", dev.name, edgexErr)
 			}
 
 		}
 	}
+	if d.rtspServerMode != RTSPServerModeNone {
+		d.startStreamingSchedules()
+		d.startOnDemandIdleChecks()
+	}
 
 	return nil
 }
@@ -210,10 +367,16 @@ func (d *Driver) StartRTSPCredentialServer() {
 	e := echo.New()
 	e.HideBanner = true
 	e.Server.ReadHeaderTimeout = 5 * time.Second // G112: A configured ReadHeaderTimeout in the http.Server averts a potential Slowloris Attack
+	e.TLSServer.ReadHeaderTimeout = 5 * time.Second
 	e.Router().Add(http.MethodPost, "/rtspauth", d.RTSPCredentialsHandler)
 	d.rtspAuthServer = e
 
-	err := e.Start(d.rtspAuthenticationServerUri)
+	var err error
+	if d.authServerCertificate != nil {
+		err = e.StartTLS(d.rtspAuthenticationServerUri, d.authServerCertificate.Cert, d.authServerCertificate.Key)
+	} else {
+		err = e.Start(d.rtspAuthenticationServerUri)
+	}
 	if err != nil && err != http.ErrServerClosed {
 		d.lc.Errorf("RTSP Auth Web server failed: %v", err)
 		d.rtspAuthServer = nil
@@ -238,35 +401,83 @@ func (d *Driver) RTSPCredentialsHandler(c echo.Context) error {
 		return nil
 	}
 
-	if rtspAuthRequest.User == "" || rtspAuthRequest.Password == "" {
+	status, message := d.authenticate(rtspAuthRequest)
+	if status == http.StatusOK {
+		return nil
+	}
+	c.Response().WriteHeader(status)
+	if message != "" {
+		if _, err = c.Response().Write([]byte(message)); err != nil {
+			d.lc.Errorf("Error writing message: %v", err.Error())
+		}
+	}
+	return nil
+}
+
+// authenticate checks the credentials of a request to publish or read a video stream, and returns the HTTP status
+// code of the result along with a message for the client if any
+func (d *Driver) authenticate(authRequest RTSPAuthRequest) (int, string) {
+	if (authRequest.Action == "read" || authRequest.Action == "playback") &&
+		!viewerAllowed(d.viewerNetworks(authRequest.Path), authRequest.IP) && !d.localServiceRead(authRequest) {
+		d.auditAuthentication(authRequest, "denied", "the IP is not allowed to view the stream")
+		return http.StatusForbidden, fmt.Sprintf("%s is not allowed to view %s", authRequest.IP, authRequest.Path)
+	}
+	now := time.Now()
+	if hasToken, err := d.authenticateStreamToken(authRequest, now); hasToken {
+		if l, locked := d.authGuard.locked(authRequest.IP, "", now); locked {
+			d.auditAuthentication(authRequest, "locked", fmt.Sprintf("%s %s is locked out until %s", l.Kind, l.Key, l.LockedUntil.Format(time.RFC3339)))
+			return http.StatusTooManyRequests, "too many failed authentication attempts, try again later"
+		}
+		if err != nil {
+			d.auditAuthentication(authRequest, "failure", "stream token rejected: "+err.Error())
+			// the tokens carry no user, so only the IP is counted
+			d.reportLockouts(authRequest, d.authGuard.recordFailure(authRequest.IP, "", now))
+			return http.StatusUnauthorized, ""
+		}
+		d.auditAuthentication(authRequest, "success", "stream token")
+		d.startOnDemandStreaming(authRequest, now)
+		return http.StatusOK, ""
+	}
+	if authRequest.User == "" || authRequest.Password == "" {
 		d.lc.Debug("rtsp authentication: username or password is empty")
 		// From https://github.com/aler9/mediamtx#authentication README:
 		// Please be aware that it's perfectly normal for the authentication server to receive requests with
 		// empty users and passwords. This happens because a RTSP client doesn't provide credentials until it
 		// is asked to. In order to receive the credentials, the authentication server must reply with status code 401,
 		// then the client will send credentials.
-		c.Response().WriteHeader(http.StatusUnauthorized)
-		return nil
+		return http.StatusUnauthorized, ""
+	}
+	if l, locked := d.authGuard.locked(authRequest.IP, "", now); locked {
+		d.auditAuthentication(authRequest, "locked", fmt.Sprintf("%s %s is locked out until %s", l.Kind, l.Key, l.LockedUntil.Format(time.RFC3339)))
+		return http.StatusTooManyRequests, "too many failed authentication attempts, try again later"
 	}
 	credential, edgexErr := d.tryGetCredentials(RtspAuthSecretName)
 	if edgexErr != nil {
 		d.lc.Warnf("Failed to retrieve credentials for rtsp authentication from the secret store. Have you stored credentials yet for secretName %s?", RtspAuthSecretName)
-		c.Response().WriteHeader(http.StatusInternalServerError)
-		if _, err = c.Response().Write([]byte("RTSP Authentication has not been fully configured!")); err != nil {
-			d.lc.Errorf("Error writing message: %v", err.Error())
-		}
-		return nil
+		return http.StatusInternalServerError, "RTSP Authentication has not been fully configured!"
 	}
 
-	if credential.Username != rtspAuthRequest.User ||
-		credential.Password != rtspAuthRequest.Password {
-		d.lc.Warn("rtsp authentication: user or password do not match")
-		c.Response().WriteHeader(http.StatusUnauthorized)
-		return nil
+	if !credentialsMatch(credential, authRequest.User, authRequest.Password) {
+		// all the clients share the user of the service, locking it out would lock out every client, so only the
+		// IPs are locked out for its failures. The user lockouts are checked once the credentials are known to be
+		// wrong, so that the right credentials always get through.
+		user := authRequest.User
+		if user == credential.Username {
+			user = ""
+		}
+		if l, locked := d.authGuard.locked(authRequest.IP, user, now); locked {
+			d.auditAuthentication(authRequest, "locked", fmt.Sprintf("%s %s is locked out until %s", l.Kind, l.Key, l.LockedUntil.Format(time.RFC3339)))
+			return http.StatusTooManyRequests, "too many failed authentication attempts, try again later"
+		}
+		d.auditAuthentication(authRequest, "failure", "user or password do not match")
+		d.reportLockouts(authRequest, d.authGuard.recordFailure(authRequest.IP, user, now))
+		return http.StatusUnauthorized, ""
 	}
 
-	d.lc.Debug("rtsp authentication: passwords match")
-	return nil
+	d.auditAuthentication(authRequest, "success", "")
+	d.authGuard.recordSuccess(authRequest.IP, authRequest.User)
+	d.startOnDemandStreaming(authRequest, now)
+	return http.StatusOK, ""
 }
 
 func (d *Driver) HandleReadCommands(deviceName string, protocols map[string]models.ProtocolProperties,
@@ -400,12 +611,22 @@ func (d *Driver) ExecuteReadCommands(device *Device, req sdkModels.CommandReques
 				"rtsp server is not enabled, cannot get stream URI for device %s", device.name), nil)
 		}
 		cv, err = sdkModels.NewCommandValue(req.DeviceResourceName, req.Type, device.rtspUri)
+	case VideoStreamToken:
+		if d.rtspServerMode == RTSPServerModeNone {
+			return nil, errors.NewCommonEdgeX(errors.KindServerError, fmt.Sprintf(
+				"rtsp server is not enabled, cannot issue a stream token for device %s", device.name), nil)
+		}
+		uri, edgexErr := d.streamTokenUri(device, queryParams, time.Now())
+		if edgexErr != nil {
+			return nil, edgexErr
+		}
+		cv, err = sdkModels.NewCommandValue(req.DeviceResourceName, req.Type, uri)
 	case VideoStreamingStatus:
 		if d.rtspServerMode == RTSPServerModeNone {
 			return nil, errors.NewCommonEdgeX(errors.KindServerError, fmt.Sprintf(
 				"rtsp server is not enabled, cannot get streaming status for device %s", device.name), nil)
 		}
-		cv, err = sdkModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, device.streamingStatus)
+		cv, err = sdkModels.NewCommandValue(req.DeviceResourceName, common.ValueTypeObject, d.streamingStatus(device))
 	default:
 		return nil, errors.NewCommonEdgeX(errors.KindContractInvalid, fmt.Sprintf("unsupported command %s", command), nil)
 	}
@@ -449,6 +670,11 @@ func (d *Driver) HandleWriteCommands(deviceName string, protocols map[string]mod
 }
 
 func (d *Driver) ExecuteWriteCommands(device *Device, req sdkModels.CommandRequest, param *sdkModels.CommandValue, command interface{}) error {
+	// the write commands start, stop and reconfigure the camera, so they are serialized with the other
+	// lifecycle operations of the device
+	unlock := d.devices.lock(device.name)
+	defer unlock()
+
 	queryParams, edgexErr := getQueryParameters(req)
 	if edgexErr != nil {
 		return errors.NewCommonEdgeXWrapper(edgexErr)
@@ -472,12 +698,16 @@ func (d *Driver) ExecuteWriteCommands(device *Device, req sdkModels.CommandReque
 		if err != nil {
 			return err
 		}
-		device.streamingStatus.TranscoderInputPath = videoPath
 		options, edgexErr := param.ObjectValue()
 		if edgexErr != nil {
 			return errors.NewCommonEdgeXWrapper(edgexErr)
 		}
-		edgexErr = setupFFmpegOptions(device, options, req.Attributes)
+		caps, err := getInputCapabilities(cameraDevice)
+		if err != nil {
+			// do not block streaming on devices which cannot be enumerated, let ffmpeg report any problems instead
+			d.lc.Warnf("Unable to enumerate the input formats of device %s, input options will not be validated: %v", device.name, err)
+		}
+		edgexErr = setupFFmpegOptions(device, options, req.Attributes, caps)
 		if edgexErr != nil {
 			return errors.NewCommonEdgeXWrapper(edgexErr)
 		}
@@ -545,26 +775,46 @@ func (d *Driver) Stop(force bool) error {
 	defer d.mutex.Unlock()
 
 	// The wait group is used here as well as in the startStreaming functions.
-	// The call to Wait() waits for StopStreaming to return and startStreaming to end.
-	defer d.wg.Wait()
+	// The call to Wait() waits for StopStreaming to return and startStreaming to end, but no longer than
+	// the stop sequence of the transcoders, so that a hung transcoder cannot block the shutdown.
+	defer d.waitForStreaming(d.ffmpegStopPolicy.timeout() + streamingStatusPublishTimeout)
 
 	if d.rtspServerMode == RTSPServerModeNone {
 		return nil
 	}
 
+	if d.scheduleDone != nil {
+		close(d.scheduleDone)
+		d.scheduleDone = nil
+	}
+	if d.onDemandDone != nil {
+		close(d.onDemandDone)
+		d.onDemandDone = nil
+	}
+	if d.rtspHealth != nil {
+		d.rtspHealth.stop()
+	}
 	if d.rtspAuthServer != nil {
 		err := d.rtspAuthServer.Shutdown(context.Background())
 		if err != nil {
 			d.lc.Errorf("Error occurred while shutting down the rtsp auth server: %v", err)
 		}
 	}
+	if d.rtspServer != nil {
+		if err := d.rtspServer.Close(); err != nil {
+			d.lc.Errorf("Error occurred while shutting down the embedded rtsp server: %v", err)
+		}
+	}
 
-	d.wg.Add(len(d.activeDevices))
+	devices := d.devices.list()
+	d.wg.Add(len(devices))
 
-	for _, device := range d.activeDevices {
+	for _, device := range devices {
 		go func(device *Device) {
+			defer d.wg.Done()
+			unlock := d.devices.lock(device.name)
+			defer unlock()
 			device.StopStreaming()
-			d.wg.Done()
 		}(device)
 	}
 	return nil
@@ -623,44 +873,62 @@ func (d *Driver) pathMatchesStreamFormat(path, streamFormat string) bool {
 // when a new Device associated with this Device Service is added
 func (d *Driver) AddDevice(deviceName string, protocols map[string]models.ProtocolProperties,
 	adminState models.AdminState) error {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
+	unlock := d.devices.lock(deviceName)
+	defer unlock()
 	_, err := d.addDeviceInternal(deviceName, protocols)
 	return err
 }
 
-// addDeviceInternal attempts to add a device to the device service's active devices
+// addDeviceInternal attempts to add a device to the device service's active devices, the caller must hold the
+// lifecycle lock of the device
 func (d *Driver) addDeviceInternal(deviceName string, protocols map[string]models.ProtocolProperties) (*Device, error) {
 	paths, err := d.getPaths(protocols)
 	if err != nil {
 		return nil, err
 	}
 
-	_, sn, edgexErr := getUSBDeviceIdInfo(paths[0])
+	identity, edgexErr := d.getUSBDeviceIdentity(paths[0])
 	if edgexErr != nil {
 		return nil, errors.NewCommonEdgeX(errors.KindServerError,
 			fmt.Sprintf("could not find the serial number of the device %s", deviceName), edgexErr)
 	}
-	for _, ad := range d.activeDevices {
-		if ad.serialNumber == sn {
-			return nil, errors.NewCommonEdgeX(errors.KindServerError,
-				fmt.Sprintf("the serial number %s conflicts with existing device %s", sn, ad.name), nil)
+	portBound := d.identityModeOf(protocols) == IdentityModePort
+	conflict := func(ad *Device) error {
+		if portBound && ad.busPath == identity.BusPath {
+			return errors.NewCommonEdgeX(errors.KindServerError,
+				fmt.Sprintf("the USB port %s conflicts with existing device %s", identity.BusPath, ad.name), nil)
+		}
+		if !portBound && ad.serialNumber == identity.SerialNumber {
+			return errors.NewCommonEdgeX(errors.KindServerError,
+				fmt.Sprintf("the serial number %s conflicts with existing device %s", identity.SerialNumber, ad.name), nil)
+		}
+		return nil
+	}
+	// check the conflicts before opening the camera, the registry checks them again when the device is added
+	for _, ad := range d.devices.list() {
+		if err := conflict(ad); err != nil {
+			return nil, err
 		}
 	}
 	activeDevice, edgexErr := d.newDevice(deviceName, protocols)
 	if edgexErr != nil {
 		return nil, errors.NewCommonEdgeXWrapper(edgexErr)
 	}
-	d.activeDevices[deviceName] = activeDevice
+	if err := d.devices.add(activeDevice, conflict); err != nil {
+		return nil, err
+	}
 	d.lc.Debugf("a new Device is added: %s", deviceName)
 	if activeDevice.autoStreaming {
-		activeDevice.streamingStatus.TranscoderInputPath = paths[0]
+		activeDevice.setTranscoderInputPath(paths[0])
 		edgexErr = d.startStreaming(activeDevice)
 		if edgexErr != nil {
 			return nil, errors.NewCommonEdgeXWrapper(edgexErr)
 		}
 
 	}
+	if activeDevice.schedule != nil && d.rtspServerMode != RTSPServerModeNone {
+		d.applyStreamingSchedule(activeDevice, time.Now())
+	}
 	return activeDevice, nil
 }
 
@@ -668,10 +936,10 @@ func (d *Driver) addDeviceInternal(deviceName string, protocols map[string]model
 // when a Device associated with this Device Service is updated
 func (d *Driver) UpdateDevice(deviceName string, protocols map[string]models.ProtocolProperties,
 	adminState models.AdminState) error {
-	if edgexErr := d.RemoveDevice(deviceName, protocols); edgexErr != nil {
-		return errors.NewCommonEdgeXWrapper(edgexErr)
-	}
-	if edgexErr := d.AddDevice(deviceName, protocols, adminState); edgexErr != nil {
+	unlock := d.devices.lock(deviceName)
+	defer unlock()
+	d.removeDeviceInternal(deviceName)
+	if _, edgexErr := d.addDeviceInternal(deviceName, protocols); edgexErr != nil {
 		return errors.NewCommonEdgeXWrapper(edgexErr)
 	}
 	d.lc.Debugf("Device %s is updated", deviceName)
@@ -681,16 +949,22 @@ func (d *Driver) UpdateDevice(deviceName string, protocols map[string]models.Pro
 // RemoveDevice is a callback function that is invoked
 // when a Device associated with this Device Service is removed
 func (d *Driver) RemoveDevice(deviceName string, protocols map[string]models.ProtocolProperties) error {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
-	if device, ok := d.activeDevices[deviceName]; ok {
+	unlock := d.devices.lock(deviceName)
+	defer unlock()
+	d.removeDeviceInternal(deviceName)
+	return nil
+}
+
+// removeDeviceInternal stops the streaming of the device and removes it from the active devices, the caller
+// must hold the lifecycle lock of the device
+func (d *Driver) removeDeviceInternal(deviceName string) {
+	if device, ok := d.devices.get(deviceName); ok {
 		if d.rtspServerMode != RTSPServerModeNone {
 			device.StopStreaming()
 		}
-		delete(d.activeDevices, deviceName)
+		d.devices.remove(deviceName)
 		d.lc.Debugf("Device %s is removed", deviceName)
 	}
-	return nil
 }
 
 // RefreshAllDevicePaths runs RefreshDevicePaths for every connected device
@@ -733,12 +1007,12 @@ func (d *Driver) RefreshDevicePaths(cd models.Device) {
 		d.lc.Errorf("Failed to get paths for device %s", cd.Name)
 	}
 	for _, fdPath := range paths {
-		_, sn, err := getUSBDeviceIdInfo(fdPath)
+		identity, err := d.getUSBDeviceIdentity(fdPath)
 		if err != nil {
 			d.lc.Errorf("failed to get the serial number of device %s, error: %s", cd.Name, err.Error())
 		}
-		// If the serial number is different, it means that the path of the device has changed.
-		if sn != cd.Protocols[UsbProtocol][SerialNumber] || !d.isVideoCaptureDevice(fdPath) {
+		// If the identity is different, it means that the path of the device has changed.
+		if !d.matchesIdentity(identity, cd.Protocols) || !d.isVideoCaptureDevice(fdPath) {
 			// Delete the paths and start fresh
 			cd.Protocols[UsbProtocol][Paths] = nil
 			go d.updateDevicePaths(cd)
@@ -751,46 +1025,14 @@ func (d *Driver) RefreshDevicePaths(cd models.Device) {
 // Devices found as part of this discovery operation are written to the channel devices.
 func (d *Driver) Discover() error {
 	d.lc.Info("Discovery is triggered")
-	devices := make(map[string]sdkModels.DiscoveredDevice)
-
-	// Convert the slice of cached devices to map in order to improve the performance in the subsequent for loop.
-	currentDevices := d.cachedDeviceMap()
+	scan := d.scanVideoDevices()
+	d.logDiscoveryReport(scan.report)
 
-	allDevices, _ := usbDevice.GetAllDevicePaths()
-	for _, fdPath := range allDevices {
-		if ok := d.isVideoCaptureDevice(fdPath); ok {
-			cn, sn, err := getUSBDeviceIdInfo(fdPath)
-			if err != nil {
-				d.lc.Errorf("failed to get device serial number, error: %s", err.Error())
-				continue
-			}
-			// Update existing device if it's path has changed
-			if _, ok := currentDevices[cn+sn]; ok {
-				d.RefreshDevicePaths(currentDevices[cn+sn])
-				continue
-			}
-			if _, found := devices[cn+sn]; !found {
-				discovered := sdkModels.DiscoveredDevice{
-					Name: buildDeviceName(cn, sn),
-					Protocols: map[string]models.ProtocolProperties{
-						UsbProtocol: {
-							Paths:        []string{fdPath},
-							SerialNumber: sn,
-							CardName:     cn,
-						},
-					},
-					Description: fmt.Sprintf("USB camera %s", cn),
-					Labels:      []string{"auto-discovery", cn},
-				}
-				devices[cn+sn] = discovered
-				d.lc.Infof("discovered device: %s", discovered.Name)
-			} else {
-				devices[cn+sn].Protocols[UsbProtocol][Paths] = append(devices[cn+sn].Protocols[UsbProtocol][Paths].([]string), fdPath)
-			}
-		}
+	for _, cd := range scan.existingDevices {
+		d.RefreshDevicePaths(cd)
 	}
 	var discoveredDevices []sdkModels.DiscoveredDevice
-	for _, device := range devices {
+	for _, device := range scan.discovered {
 		discoveredDevices = append(discoveredDevices, device)
 	}
 	d.deviceCh <- discoveredDevices
@@ -859,7 +1101,7 @@ func (d *Driver) newDevice(name string, protocols map[string]models.ProtocolProp
 	fdPath := paths[0]
 
 	rtspUri := &url.URL{
-		Scheme: RtspUriScheme,
+		Scheme: d.rtspUriScheme(),
 		Host:   fmt.Sprintf("%s:%s", d.rtspHostName, d.rtspTcpPort),
 	}
 	rtspUri.Path = path.Join(Stream, name)
@@ -874,6 +1116,16 @@ func (d *Driver) newDevice(name string, protocols map[string]models.ProtocolProp
 	}
 	trans.MediaFile().SetOutputFormat(RtspUriScheme)
 
+	viewerNetworks, edgexErr := d.deviceViewerNetworks(name, protocols)
+	if edgexErr != nil {
+		return nil, edgexErr
+	}
+
+	schedule, edgexErr := deviceStreamingSchedule(name, protocols)
+	if edgexErr != nil {
+		return nil, edgexErr
+	}
+
 	autoStreaming := false
 	autoStreamingStr, edgexErr := d.getProtocolProperty(protocols, UsbProtocol, AutoStreaming)
 	if edgexErr != nil {
@@ -885,13 +1137,31 @@ func (d *Driver) newDevice(name string, protocols map[string]models.ProtocolProp
 				AutoStreaming, autoStreaming)
 		}
 	}
+	onDemand, onDemandIdleTimeout, edgexErr := d.deviceOnDemandStreaming(name, protocols)
+	if edgexErr != nil {
+		return nil, edgexErr
+	}
+	if onDemand && schedule != nil {
+		return nil, errors.NewCommonEdgeX(errors.KindContractInvalid,
+			fmt.Sprintf("the streaming of device %s cannot be both on demand and scheduled", name), nil)
+	}
+	if autoStreaming && (schedule != nil || onDemand) {
+		d.lc.Warnf("The streaming of device %s is started by its schedule or on demand, protocol property %s is ignored", name, AutoStreaming)
+		autoStreaming = false
+	}
+	if onDemand && d.rtspServerMode == RTSPServerModeExternal {
+		d.lc.Warnf("The readers of the external rtsp server are unknown, the on demand streaming of device %s stops once no reader has been authenticated for %s",
+			name, onDemandIdleTimeout)
+	}
 
 	var streamingStatusResourceName string
+	var streamingDefaults map[string]interface{}
 	for _, r := range profile.DeviceResources {
-		command, ok := r.Attributes[GetFunction]
-		if ok && command == VideoStreamingStatus {
+		if command, ok := r.Attributes[GetFunction]; ok && command == VideoStreamingStatus && streamingStatusResourceName == "" {
 			streamingStatusResourceName = r.Name
-			break
+		}
+		if command, ok := r.Attributes[SetFunction]; ok && command == VideoStartStreaming {
+			streamingDefaults = r.Attributes
 		}
 	}
 	if len(streamingStatusResourceName) == 0 {
@@ -905,34 +1175,53 @@ func (d *Driver) newDevice(name string, protocols map[string]models.ProtocolProp
 	}
 	defer cameraDevice.Close()
 
-	cn, sn, err := getUSBDeviceIdInfo(fdPath)
-	if err != nil {
+	identity, edgexErr := d.getUSBDeviceIdentity(fdPath)
+	if edgexErr != nil {
 		return nil, errors.NewCommonEdgeX(errors.KindServerError,
-			fmt.Sprintf("could not find the serial number of the device on the specified path: %s", fdPath), err)
+			fmt.Sprintf("could not find the serial number of the device on the specified path: %s", fdPath), edgexErr)
 	}
+	cn, sn, bp := identity.CardName, identity.SerialNumber, identity.BusPath
+	portBound := d.identityModeOf(protocols) == IdentityModePort
 
-	// if the user provided a serial number or card name, but it does not match the device's serial number or card name,
-	// then return an error, as this may not be the correct device
 	psn, psnOK := protocols[UsbProtocol][SerialNumber].(string)
-	if psnOK && psn != "" && psn != sn {
-		return nil, errors.NewCommonEdgeX(errors.KindServerError,
-			fmt.Sprintf("wrong device serial number, expected %s=%s, actual %s=%s", SerialNumber, psn, SerialNumber, sn), nil)
-	}
 	pcn, pcnOK := protocols[UsbProtocol][CardName].(string)
-	if pcnOK && pcn != "" && pcn != cn {
-		return nil, errors.NewCommonEdgeX(errors.KindServerError,
-			fmt.Sprintf("wrong device card name, expected %s=%s, actual %s=%s", CardName, pcn, CardName, cn), nil)
+	pbp, pbpOK := protocols[UsbProtocol][BusPath].(string)
+	if portBound {
+		// if the user provided a USB port, but it does not match the device's port, then return an error,
+		// as this may not be the correct device
+		if pbpOK && pbp != "" && pbp != bp {
+			return nil, errors.NewCommonEdgeX(errors.KindServerError,
+				fmt.Sprintf("wrong device bus path, expected %s=%s, actual %s=%s", BusPath, pbp, BusPath, bp), nil)
+		}
+	} else {
+		// if the user provided a serial number or card name, but it does not match the device's serial number or card name,
+		// then return an error, as this may not be the correct device
+		if psnOK && psn != "" && psn != sn {
+			return nil, errors.NewCommonEdgeX(errors.KindServerError,
+				fmt.Sprintf("wrong device serial number, expected %s=%s, actual %s=%s", SerialNumber, psn, SerialNumber, sn), nil)
+		}
+		if pcnOK && pcn != "" && pcn != cn {
+			return nil, errors.NewCommonEdgeX(errors.KindServerError,
+				fmt.Sprintf("wrong device card name, expected %s=%s, actual %s=%s", CardName, pcn, CardName, cn), nil)
+		}
 	}
 
 	shouldUpdate := false
-	if !psnOK || psn == "" { // pre-defined devices may not include serial number information
+	if !psnOK || psn != sn { // pre-defined devices may not include serial number information
+		if psnOK && psn != "" {
+			d.lc.Infof("the camera of the device %s on USB port %s has been replaced, serial number %s is now %s", name, bp, psn, sn)
+		}
 		device.Protocols[UsbProtocol][SerialNumber] = sn
 		shouldUpdate = true
 	}
-	if !pcnOK || pcn == "" { // pre-defined devices may not include card name information
+	if !pcnOK || pcn != cn { // pre-defined devices may not include card name information
 		device.Protocols[UsbProtocol][CardName] = cn
 		shouldUpdate = true
 	}
+	if portBound && (!pbpOK || pbp == "") { // the bus path is learnt from the first path of the device
+		device.Protocols[UsbProtocol][BusPath] = bp
+		shouldUpdate = true
+	}
 
 	if shouldUpdate {
 		if err := d.ds.PatchDevice(dtos.UpdateDevice{
@@ -949,16 +1238,24 @@ func (d *Driver) newDevice(name string, protocols map[string]models.ProtocolProp
 		name:                        name,
 		paths:                       paths,
 		serialNumber:                sn,
+		busPath:                     bp,
 		rtspUri:                     rtspUri.String(),
 		transcoder:                  trans,
 		autoStreaming:               autoStreaming,
+		viewerNetworks:              viewerNetworks,
+		stopPolicy:                  d.ffmpegStopPolicy,
+		streamSnapshots:             d.streamSnapshots,
+		schedule:                    schedule,
+		onDemand:                    onDemand,
+		onDemandIdleTimeout:         onDemandIdleTimeout,
+		streamingDefaults:           streamingDefaults,
 		streamingStatusResourceName: streamingStatusResourceName,
 	}, nil
 }
 
 func (d *Driver) getAuthenticatedRTSPUri(name string) string {
 	rtspAuthenticatedUri := &url.URL{
-		Scheme: RtspUriScheme,
+		Scheme: d.rtspUriScheme(),
 		Host:   fmt.Sprintf("%s:%s", d.rtspHostName, d.rtspTcpPort),
 	}
 	rtspAuthenticatedUri.Path = path.Join(Stream, name)
@@ -973,9 +1270,13 @@ func (d *Driver) getAuthenticatedRTSPUri(name string) string {
 
 // getDevice gets an active device by name, which is managed by device service.
 func (d *Driver) getDevice(name string) (*Device, errors.EdgeX) {
-	d.mutex.Lock()
-	defer d.mutex.Unlock()
-	device, ok := d.activeDevices[name]
+	if device, ok := d.devices.get(name); ok {
+		return device, nil
+	}
+	unlock := d.devices.lock(name)
+	defer unlock()
+	// the device may have been added while waiting for the lock
+	device, ok := d.devices.get(name)
 	if ok {
 		return device, nil
 	} else {
@@ -1000,8 +1301,22 @@ func (d *Driver) startStreaming(device *Device) errors.EdgeX {
 		return errors.NewCommonEdgeX(errors.KindServerError, fmt.Sprintf(
 			"rtsp server is not enabled, cannot start streaming for device %s", device.name), nil)
 	}
+	// the camera can only be captured once, ffmpeg would fail with "resource busy"
+	if device.previewActive() {
+		return errors.NewCommonEdgeX(errors.KindStatusConflict, fmt.Sprintf(
+			"cannot start streaming for device %s, the camera is used by a preview or a snapshot", device.name), nil)
+	}
+	if edgexErr := d.checkRTSPServerAvailable(device); edgexErr != nil {
+		return edgexErr
+	}
+	release, edgexErr := d.admitStreaming(device)
+	if edgexErr != nil {
+		d.rejectStreaming(device, edgexErr)
+		return edgexErr
+	}
 
 	progressChan, errChan, err := device.StartStreaming()
+	release()
 	if err != nil {
 		return errors.NewCommonEdgeX(errors.KindServerError, fmt.Sprintf(
 			"failed to start video streaming for device %s", device.name), err)
@@ -1013,7 +1328,9 @@ func (d *Driver) startStreaming(device *Device) errors.EdgeX {
 		go d.publishStreamingStatus(device)
 	}()
 
+	// the wait group allows Stop to wait until the transcoding process has exited and the final status is published
 	waitForFinishAndPublish := func() {
+		defer d.wg.Done()
 		d.lc.Debugf("Waiting for ffmpeg errChan to be done")
 		<-errChan
 		d.lc.Debugf("Done waiting for ffmpeg errChan to be done")
@@ -1029,6 +1346,7 @@ func (d *Driver) startStreaming(device *Device) errors.EdgeX {
 			// this should rarely happen, as ffmpeg should print progress on the first frame. If it does happen,
 			// then either progress has been disabled or the process could be having issues.
 			d.lc.Warnf("Video streaming for device %s has started but has not sent progress messages yet.", device.name)
+			d.wg.Add(1)
 			go waitForFinishAndPublish() // track process in the background
 			return nil
 		case startErr, ok := <-errChan:
@@ -1044,18 +1362,42 @@ func (d *Driver) startStreaming(device *Device) errors.EdgeX {
 			}
 			// if we got a progress message, that means that the transcoding is successful
 			d.lc.Infof("Video streaming for device %s has started without error", device.name)
+			d.wg.Add(1)
 			go waitForFinishAndPublish() // track process in the background
 			return nil
 		}
 	}
 }
 
+// waitForStreaming waits until the transcoders have exited and published their final status, or until the
+// timeout has elapsed
+func (d *Driver) waitForStreaming(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		d.lc.Errorf("Gave up waiting for the video streaming of the devices to stop after %s", timeout)
+	}
+}
+
+// streamingStatus returns the StreamingStatus of the device along with the health of the external RTSP server
+func (d *Driver) streamingStatus(device *Device) StreamingStatus {
+	status := device.status()
+	status.RtspServer = d.rtspServerHealth()
+	status.Schedule = scheduleStatus(device.schedule, time.Now())
+	return status
+}
+
 // publishStreamingStatus asynchronously sends an event of StreamingStatus to the Core Metadata service.
 func (d *Driver) publishStreamingStatus(device *Device) {
 	if len(device.streamingStatusResourceName) == 0 {
 		return
 	}
-	cv, err := sdkModels.NewCommandValue(device.streamingStatusResourceName, common.ValueTypeObject, device.streamingStatus)
+	cv, err := sdkModels.NewCommandValue(device.streamingStatusResourceName, common.ValueTypeObject, d.streamingStatus(device))
 	if err != nil {
 		d.lc.Error(err.Error())
 		return
@@ -1067,15 +1409,14 @@ func (d *Driver) publishStreamingStatus(device *Device) {
 	d.asyncCh <- asyncValues
 }
 
-// cachedDeviceMap return a map of cached devices. Key is a string consists of card name and serial number.
+// cachedDeviceMap return a map of cached devices. Key is a string consists of card name and serial number,
+// or the USB port for the devices bound to the port.
 func (d *Driver) cachedDeviceMap() map[string]models.Device {
 	cds := d.ds.Devices()
 	cdm := make(map[string]models.Device, len(cds))
 	for _, cd := range cds {
-		cn, cnOK := cd.Protocols[UsbProtocol][CardName].(string)
-		sn, snOK := cd.Protocols[UsbProtocol][SerialNumber].(string)
-		if len(cn) > 0 && cnOK && len(sn) > 0 && snOK {
-			cdm[cn+sn] = cd
+		if key, ok := d.deviceIdentityKey(cd); ok {
+			cdm[key] = cd
 		}
 	}
 	return cdm
@@ -1099,12 +1440,12 @@ func (d *Driver) updateDevicePaths(device models.Device) {
 	allDevices, _ := usbDevice.GetAllDevicePaths()
 	for _, fdPath := range allDevices {
 		if ok := d.isVideoCaptureDevice(fdPath); ok {
-			cn, sn, err := getUSBDeviceIdInfo(fdPath)
+			identity, err := d.getUSBDeviceIdentity(fdPath)
 			if err != nil {
 				d.lc.Errorf("failed to get device serial number, path=%s, error: %s", fdPath, err.Error())
 				continue
 			}
-			if cn == device.Protocols[UsbProtocol][CardName] && sn == device.Protocols[UsbProtocol][SerialNumber] {
+			if d.matchesIdentity(identity, device.Protocols) {
 				device.Protocols[UsbProtocol][Paths] = append(device.Protocols[UsbProtocol][Paths].([]string), fdPath)
 			}
 		}
@@ -1129,39 +1470,6 @@ func getQueryParameters(req sdkModels.CommandRequest) (url.Values, errors.EdgeX)
 	return queryParams, nil
 }
 
-// getUSBDeviceIdInfo returns the serial number and the card name of the device on the specified path
-func getUSBDeviceIdInfo(path string) (cardName string, serialNumber string, err error) {
-	cmd := exec.Command("udevadm", "info", "--query=property", path)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", "", errors.NewCommonEdgeX(errors.KindServerError,
-			fmt.Sprintf("failed to run command: %s: %s", cmd.String(), output), err)
-	}
-	props := strings.Split(string(output), "\n")
-	m := make(map[string]string, len(props))
-	for _, prop := range props {
-		kvp := strings.Split(prop, "=")
-		if len(kvp) == 2 {
-			m[kvp[0]] = kvp[1]
-		}
-	}
-	cardName = m[UdevV4lProduct]
-	if len(cardName) == 0 {
-		return "", "", errors.NewCommonEdgeX(errors.KindServerError,
-			fmt.Sprintf("could not find the card name of the device on the specified path %s", path), nil)
-	}
-	if len(m[UdevSerialShort]) > 0 {
-		serialNumber = m[UdevSerialShort]
-	} else {
-		serialNumber = m[UdevSerial]
-	}
-	if len(serialNumber) == 0 {
-		return "", "", errors.NewCommonEdgeX(errors.KindServerError,
-			fmt.Sprintf("could not find the serial number of the device on the specified path %s", path), nil)
-	}
-	return
-}
-
 func (d *Driver) ValidateDevice(device models.Device) error {
 	_, err := d.getPaths(device.Protocols)
 	if err != nil {
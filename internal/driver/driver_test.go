@@ -28,17 +28,16 @@ func createDriverWithMockService() (*Driver, *sdkMocks.DeviceServiceSDK) {
 }
 
 func NewDriver() *Driver {
-	return &Driver{
-		activeDevices: map[string]*Device{
-			"testDeviceRealsense": {
-				paths: []string{
-					"/dev/video0",
-					"/dev/video2",
-					"/dev/video4",
-				},
-			},
+	driver := &Driver{devices: newDeviceRegistry()}
+	_ = driver.devices.add(&Device{
+		name: "testDeviceRealsense",
+		paths: []string{
+			"/dev/video0",
+			"/dev/video2",
+			"/dev/video4",
 		},
-	}
+	}, nil)
+	return driver
 }
 
 func createTestDevice(a, b, c int) models.Device {
@@ -55,6 +54,12 @@ func createTestDevice(a, b, c int) models.Device {
 	}}
 }
 
+func createPortBoundTestDevice(device models.Device, busPath string) models.Device {
+	device.Protocols[UsbProtocol][IdentityMode] = string(IdentityModePort)
+	device.Protocols[UsbProtocol][BusPath] = busPath
+	return device
+}
+
 func TestDriver_cachedDeviceMap(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -85,6 +90,20 @@ func TestDriver_cachedDeviceMap(t *testing.T) {
 				"testDevice18182022": createTestDevice(18, 20, 22),
 			},
 		},
+		{
+			name: "devices bound to the USB port",
+			devices: []models.Device{
+				createPortBoundTestDevice(createTestDevice(0, 2, 4), "1-2.1"),
+				createPortBoundTestDevice(createTestDevice(0, 2, 4), "1-2.2"),
+				createPortBoundTestDevice(createTestDevice(6, 8, 10), ""),
+			},
+			expected: map[string]models.Device{
+				"port:1-2.1": createPortBoundTestDevice(createTestDevice(0, 2, 4), "1-2.1"),
+				"port:1-2.2": createPortBoundTestDevice(createTestDevice(0, 2, 4), "1-2.2"),
+				// the bus path is not known yet
+				"testDevice66810": createPortBoundTestDevice(createTestDevice(6, 8, 10), ""),
+			},
+		},
 	}
 	for _, test := range tests {
 		test := test
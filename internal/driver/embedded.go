@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/edgexfoundry/device-usb-camera/internal/rtspserver"
+)
+
+// startEmbeddedRTSPServer starts the RTSP server in the device service process. The publishers and readers are
+// authenticated directly, without the RTSP authentication server used by the internal and external RTSP servers.
+func (d *Driver) startEmbeddedRTSPServer() error {
+	server := rtspserver.NewServer(d.lc, d.authenticateRTSP)
+	server.SetPublisherWait(d.rtspPublisherWait)
+	address := ":" + d.rtspTcpPort
+	var err error
+	if d.rtspCertificate != nil {
+		var certificate tls.Certificate
+		if certificate, err = tls.X509KeyPair(d.rtspCertificate.Cert, d.rtspCertificate.Key); err == nil {
+			err = server.StartTLS(address, &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12})
+		}
+	} else {
+		err = server.Start(address)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to start the embedded rtsp server on %s: %s", address, err.Error())
+	}
+	d.lc.Infof("Embedded rtsp server listening on %s", address)
+	d.rtspServer = server
+	return nil
+}
+
+// authenticateRTSP authenticates the publishers and readers of the embedded RTSP server
+func (d *Driver) authenticateRTSP(req rtspserver.AuthRequest) bool {
+	status, _ := d.authenticate(RTSPAuthRequest{
+		IP:       req.IP,
+		User:     req.User,
+		Password: req.Password,
+		Path:     req.Path,
+		Protocol: "rtsp",
+		Action:   req.Action,
+		Query:    req.Query,
+	})
+	return status == http.StatusOK
+}
+
+// rtspPublisherWait returns how long the readers of the embedded RTSP server wait for the stream of the path to be
+// published, which is only started by its readers for the on demand devices
+func (d *Driver) rtspPublisherWait(streamPath string) time.Duration {
+	name, ok := streamDeviceName(streamPath)
+	if !ok {
+		return 0
+	}
+	if device, ok := d.devices.get(name); ok && device.onDemand {
+		return onDemandPublishTimeout
+	}
+	return 0
+}
+
+// rtspReaders returns the addresses of the readers of the stream of the device, which are only known
+// to the embedded RTSP server
+func (d *Driver) rtspReaders(name string) []string {
+	if d.rtspServer == nil {
+		return nil
+	}
+	stream, ok := d.rtspServer.Stream(path.Join(Stream, name))
+	if !ok {
+		return nil
+	}
+	return stream.Readers
+}
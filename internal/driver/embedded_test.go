@@ -0,0 +1,114 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/device-usb-camera/internal/rtspserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticateRTSP(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		expected bool
+	}{
+		{"valid credentials", testRtspUser, testRtspPassword, true},
+		{"wrong password", testRtspUser, "wrong", false},
+		{"no credentials", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, d.authenticateRTSP(rtspserver.AuthRequest{
+				IP:       "127.0.0.1",
+				User:     tt.user,
+				Password: tt.password,
+				Path:     "stream/camera",
+				Action:   rtspserver.ActionPublish,
+			}))
+		})
+	}
+}
+
+func TestStartEmbeddedRTSPServer(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspServerMode = RTSPServerModeEmbedded
+	d.rtspTcpPort = "0"
+	require.NoError(t, d.startEmbeddedRTSPServer())
+	require.NotNil(t, d.rtspServer)
+	conn, err := net.Dial("tcp", d.rtspServer.Addr().String())
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.Nil(t, d.rtspReaders("camera"), "there are no readers without stream")
+
+	require.NoError(t, d.Stop(false))
+	_, err = net.Dial("tcp", d.rtspServer.Addr().String())
+	assert.Error(t, err, "the embedded rtsp server is stopped with the driver")
+
+	// the port is already in use
+	other, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+	_, other.rtspTcpPort, err = net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	assert.Error(t, other.startEmbeddedRTSPServer())
+}
+
+func TestEmbeddedRTSPServerStartsOnDemandStream(t *testing.T) {
+	installFakeFFmpeg(t, "publish", "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspServerMode = RTSPServerModeEmbedded
+	d.rtspTcpPort = "0"
+	require.NoError(t, d.startEmbeddedRTSPServer())
+	defer func() { _ = d.Stop(false) }()
+	address := d.rtspServer.Addr().String()
+	_, d.rtspTcpPort, _ = net.SplitHostPort(address)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.onDemand, dev.onDemandIdleTimeout = true, time.Minute
+	defer dev.StopStreaming()
+	require.False(t, dev.isStreaming())
+
+	// the reader of the stopped stream waits for the stream it has started to be published
+	reader, err := dialFakeRTSPClient(address)
+	require.NoError(t, err)
+	defer reader.conn.Close()
+	streamUrl := &url.URL{Scheme: "rtsp", Host: address, Path: "/stream/camera", User: url.UserPassword(testRtspUser, testRtspPassword)}
+	resp, err := reader.request("DESCRIBE", streamUrl, nil, "")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.status)
+	assert.Equal(t, fakeRTSPSDP, resp.body)
+	assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+
+	trackUrl := *streamUrl
+	trackUrl.Path += "/streamid=0"
+	resp, err = reader.request("SETUP", &trackUrl, map[string]string{"Transport": "RTP/AVP/TCP;unicast;interleaved=0-1"}, "")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.status)
+	resp, err = reader.request("PLAY", streamUrl, nil, "")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.status)
+	assert.Eventually(t, func() bool { return len(d.rtspReaders("camera")) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// the readers of the devices which are not on demand do not wait
+	addFakeStreamingDevice(t, d, "always")
+	other, err := dialFakeRTSPClient(address)
+	require.NoError(t, err)
+	defer other.conn.Close()
+	resp, err = other.request("DESCRIBE", &url.URL{Scheme: "rtsp", Host: address, Path: "/stream/always", User: streamUrl.User}, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.status)
+}
@@ -7,9 +7,16 @@
 package driver
 
 import (
+	"cmp"
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
 
@@ -19,6 +26,8 @@ import (
 type FFmpeg struct {
 	inputOptions  []string
 	outputOptions []string
+	// overlay is drawn on the video by a filter graph, which is built once all the overlay options are known
+	overlay overlayOptions
 }
 
 func (f FFmpeg) ObtainOutputFrames(value string) []string {
@@ -84,6 +93,48 @@ func (f FFmpeg) ObtainInputPixelFormat(value string) []string {
 	return nil
 }
 
+func (f *FFmpeg) ObtainOutputOverlayTimestamp(value string) []string {
+	f.overlay.configured = true
+	f.overlay.timestamp = value
+	return nil
+}
+
+func (f *FFmpeg) ObtainOutputOverlayTimezone(value string) []string {
+	f.overlay.configured = true
+	f.overlay.timezone = value
+	return nil
+}
+
+func (f *FFmpeg) ObtainOutputOverlayDeviceName(value string) []string {
+	f.overlay.configured = true
+	f.overlay.deviceName = value == "true"
+	return nil
+}
+
+func (f *FFmpeg) ObtainOutputOverlayText(value string) []string {
+	f.overlay.configured = true
+	f.overlay.text = value
+	return nil
+}
+
+func (f *FFmpeg) ObtainOutputOverlayPosition(value string) []string {
+	f.overlay.configured = true
+	f.overlay.position = value
+	return nil
+}
+
+func (f *FFmpeg) ObtainOutputOverlayFontSize(value string) []string {
+	f.overlay.configured = true
+	f.overlay.fontSize = value
+	return nil
+}
+
+func (f *FFmpeg) ObtainOutputOverlayFontColor(value string) []string {
+	f.overlay.configured = true
+	f.overlay.fontColor = value
+	return nil
+}
+
 func (f *FFmpeg) setOptions(name, val string) bool {
 	opt := reflect.ValueOf(f).MethodByName(fmt.Sprintf("Obtain%s", name))
 	if (opt != reflect.Value{}) {
@@ -102,7 +153,7 @@ func (f *FFmpeg) setOptions(name, val string) bool {
 	return false
 }
 
-func setupFFmpegOptions(dev *Device, opts interface{}, attr map[string]interface{}) errors.EdgeX {
+func setupFFmpegOptions(dev *Device, opts interface{}, attr map[string]interface{}, caps *InputCapabilities) errors.EdgeX {
 	options, ok := opts.(map[string]interface{})
 	if !ok {
 		return errors.NewCommonEdgeX(errors.KindContractInvalid,
@@ -110,6 +161,7 @@ func setupFFmpegOptions(dev *Device, opts interface{}, attr map[string]interface
 	}
 
 	ffmpeg := &FFmpeg{}
+	optionValues := make(map[string]string)
 	// obtain FFmpeg options defined in request body
 	for optName, value := range options {
 		optVal, err := parseOptionValue(optName, value)
@@ -118,7 +170,7 @@ func setupFFmpegOptions(dev *Device, opts interface{}, attr map[string]interface
 				"failed to parse option value", err)
 		}
 		if ffmpeg.setOptions(optName, optVal) {
-			dev.updateFFmpegOptions(optName, optVal)
+			optionValues[optName] = optVal
 			continue
 		}
 		return errors.NewCommonEdgeX(errors.KindContractInvalid,
@@ -140,19 +192,45 @@ func setupFFmpegOptions(dev *Device, opts interface{}, attr map[string]interface
 				"failed to parse option value", err)
 		}
 		if ffmpeg.setOptions(optName, optVal) {
-			dev.updateFFmpegOptions(optName, optVal)
+			optionValues[optName] = optVal
 			continue
 		}
 		return errors.NewCommonEdgeX(errors.KindContractInvalid,
 			fmt.Sprintf("unsupported option: %s", optName), nil)
 	}
 
+	videoFilter := ffmpeg.overlay.filterGraph(dev.name)
+	if err := validatePassthrough(optionValues); err != nil {
+		return errors.NewCommonEdgeX(errors.KindContractInvalid,
+			fmt.Sprintf("invalid passthrough options for device %s", dev.name), err)
+	}
+
+	// make sure the camera is able to capture the requested input before ffmpeg is launched
+	if caps != nil {
+		if err := caps.validate(optionValues); err != nil {
+			return errors.NewCommonEdgeX(errors.KindContractInvalid,
+				fmt.Sprintf("invalid input options for device %s", dev.name), err)
+		}
+	}
+
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	dev.optionsConfigured = true
+	for optName, optVal := range optionValues {
+		dev.updateFFmpegOptions(optName, optVal)
+	}
 	if len(ffmpeg.inputOptions) > 0 {
 		dev.transcoder.MediaFile().SetRawInputArgs(ffmpeg.inputOptions)
 	}
 	if len(ffmpeg.outputOptions) > 0 {
 		dev.transcoder.MediaFile().SetRawOutputArgs(ffmpeg.outputOptions)
 	}
+	// the overlay is replaced along with the output options, so that a new output without overlay options
+	// removes the previous overlay
+	if len(ffmpeg.outputOptions) > 0 || ffmpeg.overlay.configured {
+		dev.transcoder.MediaFile().SetVideoFilter(videoFilter)
+		dev.overlayTimezone = ffmpeg.overlay.timezone
+	}
 	return nil
 }
 
@@ -174,12 +252,420 @@ func parseOptionValue(name string, value interface{}) (string, error) {
 		case v4l2.PixelFormats[v4l2.PixelFmtMJPEG], FFmpegPixelFmtMJPEG:
 			// mjpeg is not in the list of available FFmpeg pixel formats, but it does work.
 			return FFmpegPixelFmtMJPEG, nil
-		default:
-			// No corresponding pixel formats of FFmpeg for the following v4l2.PixelFormats:
-			// v4l2.PixelFmtJPEG, v4l2.PixelFmtMPEG, v4l2.PixelFmtH264, and v4l2.PixelFmtMPEG4
-			// For a full list of available FFmpeg pixel formats, use this command "ffmpeg -pix_fmts" with FFmpeg command-line tool
-			return stringValue, fmt.Errorf(`invalid value "%s" for %s option`, value, name)
+		case v4l2.PixelFormats[v4l2.PixelFmtH264], FFmpegPixelFmtH264:
+			// h264 is an input format of the v4l2 demuxer of FFmpeg, like mjpeg
+			return FFmpegPixelFmtH264, nil
+		}
+		// the four character codes of PixelFormatV4l2Mappings, e.g. YUYV, MJPG or H264
+		if pixelFormat, ok := PixelFormatV4l2Mappings[strings.ToUpper(stringValue)]; ok {
+			for ffmpegPixelFormat, pixelFormats := range FFmpegPixelFormatV4l2Mappings {
+				if slices.Contains(pixelFormats, pixelFormat) {
+					return ffmpegPixelFormat, nil
+				}
+			}
 		}
+		// No corresponding pixel formats of FFmpeg for the following v4l2.PixelFormats:
+		// v4l2.PixelFmtMPEG and v4l2.PixelFmtMPEG4
+		// For a full list of available FFmpeg pixel formats, use this command "ffmpeg -pix_fmts" with FFmpeg command-line tool
+		return stringValue, fmt.Errorf(`invalid value "%s" for %s option`, value, name)
+	}
+	if strings.HasPrefix(name, OutputOverlayPrefix) {
+		return parseOverlayOptionValue(name, stringValue)
 	}
 	return stringValue, nil
 }
+
+// overlayFontColorRegex matches the FFmpeg colors of the overlay, i.e. a color name or a #RRGGBB[AA] or
+// 0xRRGGBB[AA] value, optionally followed by @ and the opacity
+var overlayFontColorRegex = regexp.MustCompile(`^([a-zA-Z]+|(#|0x)[0-9a-fA-F]{6}([0-9a-fA-F]{2})?)(@(0|1|0?\.[0-9]+|1\.0+))?$`)
+
+// parseOverlayOptionValue validates the value of an overlay option
+func parseOverlayOptionValue(name, value string) (string, error) {
+	invalid := func(expected string) (string, error) {
+		return value, fmt.Errorf(`invalid value "%s" for %s option, expected %s`, value, name, expected)
+	}
+	switch name {
+	case OutputOverlayTimestamp:
+		// the timestamp is drawn with the default format if it is simply enabled
+		if value == "true" {
+			return DefaultOverlayTimestamp, nil
+		}
+		if value == "false" {
+			return "", nil
+		}
+	case OutputOverlayTimezone:
+		if value != "" {
+			if _, err := time.LoadLocation(value); err != nil || value == "Local" {
+				return invalid("an IANA time zone such as Europe/London")
+			}
+		}
+	case OutputOverlayDeviceName:
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid("true or false")
+		}
+		return strconv.FormatBool(enabled), nil
+	case OutputOverlayPosition:
+		switch value {
+		case OverlayPositionTopLeft, OverlayPositionTopRight, OverlayPositionBottomLeft, OverlayPositionBottomRight:
+		default:
+			return invalid(fmt.Sprintf("one of [%s, %s, %s, %s]", OverlayPositionTopLeft, OverlayPositionTopRight,
+				OverlayPositionBottomLeft, OverlayPositionBottomRight))
+		}
+	case OutputOverlayFontSize:
+		if size, err := strconv.Atoi(value); err != nil || size <= 0 || size > 1000 {
+			return invalid("a font size in pixels between 1 and 1000")
+		}
+	case OutputOverlayFontColor:
+		if !overlayFontColorRegex.MatchString(value) {
+			return invalid("a color name such as white, or #RRGGBB, optionally followed by @ and the opacity")
+		}
+	}
+	return value, nil
+}
+
+// overlayOptions are the overlays drawn on the video, from the top: the device name, the text and the timestamp
+type overlayOptions struct {
+	// configured is set if any overlay option is specified
+	configured bool
+	// timestamp is the strftime format of the timestamp, and timezone the time zone it is drawn in
+	timestamp  string
+	timezone   string
+	deviceName bool
+	text       string
+	position   string
+	fontSize   string
+	fontColor  string
+}
+
+// overlayMargin is the distance in pixels between the overlay and the edges of the video
+const overlayMargin = "10"
+
+// filterGraph returns the FFmpeg filter graph drawing the overlay on the video, it is empty without overlay
+func (o overlayOptions) filterGraph(deviceName string) string {
+	var lines []string
+	if o.deviceName {
+		lines = append(lines, escapeDrawtextText(deviceName))
+	}
+	if o.text != "" {
+		lines = append(lines, escapeDrawtextText(o.text))
+	}
+	if o.timestamp != "" {
+		// the local time of the ffmpeg process is in the time zone of the overlay, see transcoderEnv
+		lines = append(lines, "%{localtime:"+escapeFilterValue(o.timestamp, `\:}`)+"}")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	fontSize, fontColor := cmp.Or(o.fontSize, DefaultOverlayFontSize), cmp.Or(o.fontColor, DefaultOverlayFontColor)
+	x, y := overlayMargin, overlayMargin
+	switch o.position {
+	case OverlayPositionTopRight:
+		x = "w-tw-" + overlayMargin
+	case OverlayPositionBottomLeft:
+		y = "h-th-" + overlayMargin
+	case OverlayPositionBottomRight:
+		x, y = "w-tw-"+overlayMargin, "h-th-"+overlayMargin
+	}
+	args := []string{
+		// the value of an option of the filter escapes the separator of the options
+		"text=" + escapeFilterValue(strings.Join(lines, "\n"), `\':`),
+		"fontsize=" + fontSize,
+		"fontcolor=" + escapeFilterValue(fontColor, `\':`),
+		"x=" + x,
+		"y=" + y,
+		// the box keeps the overlay readable on any background
+		"box=1",
+		"boxcolor=black@0.5",
+		"boxborderw=6",
+	}
+	// the filter graph escapes the separators of the filters
+	return "drawtext=" + escapeFilterValue(strings.Join(args, ":"), `\'[],;`)
+}
+
+// escapeDrawtextText escapes the text expansion of the drawtext filter in a literal text
+func escapeDrawtextText(text string) string {
+	return escapeFilterValue(text, `\%`)
+}
+
+// escapeFilterValue escapes the special characters of one of the levels of the FFmpeg filter graph syntax with
+// a backslash
+func escapeFilterValue(value, special string) string {
+	var escaped strings.Builder
+	for _, c := range value {
+		if strings.ContainsRune(special, c) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(c)
+	}
+	return escaped.String()
+}
+
+// passthroughPixelFormats are the compressed input pixel formats which can be published without re-encoding
+var passthroughPixelFormats = []string{FFmpegPixelFmtH264, FFmpegPixelFmtMJPEG}
+
+// reencodingOptions are the output options which need the video to be re-encoded
+var reencodingOptions = []string{OutputFps, OutputImageSize, OutputVideoQuality}
+
+// validatePassthrough checks the options of the passthrough mode, where the OutputVideoCodec copy publishes the
+// compressed stream of the camera as it is. The InputPixelFormat must be one of the compressed formats, and the
+// options which change the video cannot be used.
+func validatePassthrough(options map[string]string) error {
+	if options[OutputVideoCodec] != FFmpegCodecCopy {
+		return nil
+	}
+	pixelFormat, ok := options[InputPixelFormat]
+	if !ok {
+		return fmt.Errorf("the %s %s option requires the %s option, one of [%s]", OutputVideoCodec, FFmpegCodecCopy,
+			InputPixelFormat, strings.Join(passthroughPixelFormats, ", "))
+	}
+	if !slices.Contains(passthroughPixelFormats, pixelFormat) {
+		return fmt.Errorf("the %s %s option cannot publish the %s %s without re-encoding it, supported values are: [%s]",
+			OutputVideoCodec, FFmpegCodecCopy, InputPixelFormat, pixelFormat, strings.Join(passthroughPixelFormats, ", "))
+	}
+	for _, name := range reencodingOptions {
+		if _, ok := options[name]; ok {
+			return fmt.Errorf("the %s option cannot be used with the %s %s option, which does not re-encode the video",
+				name, OutputVideoCodec, FFmpegCodecCopy)
+		}
+	}
+	for name := range options {
+		if strings.HasPrefix(name, OutputOverlayPrefix) {
+			return fmt.Errorf("the overlay option %s cannot be used with the %s %s option, which does not re-encode the video",
+				name, OutputVideoCodec, FFmpegCodecCopy)
+		}
+	}
+	return nil
+}
+
+// InputCapabilities holds the capture capabilities of a camera along with its current format. It is used
+// to validate the FFmpeg input options before the transcoder is started.
+type InputCapabilities struct {
+	Formats []InputFormat
+	Current v4l2.PixFormat
+}
+
+// UnsupportedInputOptionError is returned when an FFmpeg input option cannot be satisfied by the camera.
+// Supported lists the values the camera is able to provide instead.
+type UnsupportedInputOptionError struct {
+	Option    string
+	Value     string
+	Supported []string
+}
+
+func (e UnsupportedInputOptionError) Error() string {
+	return fmt.Sprintf("%s %s is not supported by the device, supported values are: [%s]",
+		e.Option, e.Value, strings.Join(e.Supported, ", "))
+}
+
+// ffmpegFrameSizeAbbreviations maps the frame size abbreviations accepted by FFmpeg to their width and height.
+var ffmpegFrameSizeAbbreviations = map[string][2]uint32{
+	"sqcif":   {128, 96},
+	"qqvga":   {160, 120},
+	"qcif":    {176, 144},
+	"qvga":    {320, 240},
+	"cif":     {352, 288},
+	"vga":     {640, 480},
+	"ntsc":    {720, 480},
+	"pal":     {720, 576},
+	"svga":    {800, 600},
+	"hd480":   {852, 480},
+	"xga":     {1024, 768},
+	"hd720":   {1280, 720},
+	"sxga":    {1280, 1024},
+	"hd1080":  {1920, 1080},
+	"uhd2160": {3840, 2160},
+}
+
+// ffmpegFrameRateAbbreviations maps the frame rate abbreviations accepted by FFmpeg to their rational value.
+var ffmpegFrameRateAbbreviations = map[string]v4l2.Fract{
+	"ntsc":      {Numerator: 30000, Denominator: 1001},
+	"pal":       {Numerator: 25, Denominator: 1},
+	"film":      {Numerator: 24, Denominator: 1},
+	"ntsc-film": {Numerator: 24000, Denominator: 1001},
+}
+
+// validate checks the InputPixelFormat, InputImageSize and InputFps options against the formats, frame sizes
+// and frame rates enumerated from the camera. Options which are not specified fall back to the current format
+// of the camera, the same way FFmpeg does.
+func (c *InputCapabilities) validate(options map[string]string) error {
+	formats := c.currentFormats()
+	if value, ok := options[InputPixelFormat]; ok {
+		formats = c.formatsForPixelFormat(value)
+		if len(formats) == 0 {
+			return UnsupportedInputOptionError{Option: InputPixelFormat, Value: value, Supported: c.supportedPixelFormats()}
+		}
+	}
+
+	width, height := c.Current.Width, c.Current.Height
+	value, sizeSpecified := options[InputImageSize]
+	if sizeSpecified {
+		var err error
+		width, height, err = parseFrameSize(value)
+		if err != nil {
+			return err
+		}
+	}
+	var frameSizes []InputFrameSize
+	for _, format := range formats {
+		for _, frameSize := range format.FrameSizes {
+			if frameSizeContains(frameSize, width, height) {
+				frameSizes = append(frameSizes, frameSize)
+			}
+		}
+	}
+	if sizeSpecified && len(frameSizes) == 0 {
+		return UnsupportedInputOptionError{Option: InputImageSize, Value: value, Supported: supportedFrameSizes(formats)}
+	}
+
+	if value, ok := options[InputFps]; ok {
+		fps, err := parseFrameRate(value)
+		if err != nil {
+			return err
+		}
+		if len(frameSizes) == 0 {
+			// the current frame size is not enumerated by the device, so there is nothing to compare against
+			return nil
+		}
+		var supported []string
+		for _, frameSize := range frameSizes {
+			if len(frameSize.FrameRates) == 0 {
+				return nil // the device does not report discrete frame rates, so leave it up to the driver
+			}
+			for _, frameRate := range frameSize.FrameRates {
+				if frameRatesAreEqual(frameRate, fps) {
+					return nil
+				}
+				supported = appendUnique(supported, formatFrameRate(frameRate))
+			}
+		}
+		return UnsupportedInputOptionError{Option: InputFps, Value: value, Supported: supported}
+	}
+	return nil
+}
+
+// currentFormats returns the format matching the current pixel format of the camera, which is what FFmpeg
+// captures when no InputPixelFormat is specified.
+func (c *InputCapabilities) currentFormats() []InputFormat {
+	for _, format := range c.Formats {
+		if format.PixelFormat == c.Current.PixelFormat {
+			return []InputFormat{format}
+		}
+	}
+	return c.Formats
+}
+
+func (c *InputCapabilities) formatsForPixelFormat(ffmpegPixelFormat string) []InputFormat {
+	var formats []InputFormat
+	for _, pixelFormat := range FFmpegPixelFormatV4l2Mappings[ffmpegPixelFormat] {
+		for _, format := range c.Formats {
+			if format.PixelFormat == pixelFormat {
+				formats = append(formats, format)
+			}
+		}
+	}
+	return formats
+}
+
+func (c *InputCapabilities) supportedPixelFormats() []string {
+	var supported []string
+	for ffmpegPixelFormat := range FFmpegPixelFormatV4l2Mappings {
+		if len(c.formatsForPixelFormat(ffmpegPixelFormat)) > 0 {
+			supported = append(supported, ffmpegPixelFormat)
+		}
+	}
+	sort.Strings(supported)
+	return supported
+}
+
+func supportedFrameSizes(formats []InputFormat) []string {
+	var supported []string
+	for _, format := range formats {
+		for _, frameSize := range format.FrameSizes {
+			size := frameSize.Size
+			if frameSize.Type == v4l2.FrameSizeTypeDiscrete {
+				supported = appendUnique(supported, fmt.Sprintf("%dx%d", size.MaxWidth, size.MaxHeight))
+			} else {
+				supported = appendUnique(supported, fmt.Sprintf("%dx%d-%dx%d (step %dx%d)",
+					size.MinWidth, size.MinHeight, size.MaxWidth, size.MaxHeight, size.StepWidth, size.StepHeight))
+			}
+		}
+	}
+	return supported
+}
+
+func frameSizeContains(frameSize InputFrameSize, width, height uint32) bool {
+	size := frameSize.Size
+	if width < size.MinWidth || width > size.MaxWidth || height < size.MinHeight || height > size.MaxHeight {
+		return false
+	}
+	if frameSize.Type == v4l2.FrameSizeTypeStepwise {
+		if size.StepWidth > 0 && (width-size.MinWidth)%size.StepWidth != 0 {
+			return false
+		}
+		if size.StepHeight > 0 && (height-size.MinHeight)%size.StepHeight != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFrameSize parses an FFmpeg frame size, which is either <width>x<height> or one of the FFmpeg abbreviations.
+func parseFrameSize(value string) (uint32, uint32, error) {
+	if size, ok := ffmpegFrameSizeAbbreviations[value]; ok {
+		return size[0], size[1], nil
+	}
+	parts := strings.Split(value, "x")
+	if len(parts) == 2 {
+		width, widthErr := strconv.ParseUint(parts[0], 10, 32)
+		height, heightErr := strconv.ParseUint(parts[1], 10, 32)
+		if widthErr == nil && heightErr == nil {
+			return uint32(width), uint32(height), nil
+		}
+	}
+	return 0, 0, fmt.Errorf(`invalid value "%s" for %s option, expected <width>x<height>`, value, InputImageSize)
+}
+
+// parseFrameRate parses an FFmpeg frame rate, which is either a number, a <numerator>/<denominator> rational,
+// or one of the FFmpeg abbreviations.
+func parseFrameRate(value string) (float64, error) {
+	if fps, ok := ffmpegFrameRateAbbreviations[value]; ok {
+		return float64(fps.Numerator) / float64(fps.Denominator), nil
+	}
+	numerator, denominator, isRational := strings.Cut(value, "/")
+	fps, err := strconv.ParseFloat(numerator, 64)
+	if err == nil && isRational {
+		var d float64
+		d, err = strconv.ParseFloat(denominator, 64)
+		if err == nil && d == 0 {
+			err = fmt.Errorf("denominator is zero")
+		}
+		fps /= d
+	}
+	if err != nil || fps <= 0 {
+		return 0, fmt.Errorf(`invalid value "%s" for %s option`, value, InputFps)
+	}
+	return fps, nil
+}
+
+func frameRatesAreEqual(frameRate v4l2.Fract, fps float64) bool {
+	if frameRate.Denominator == 0 {
+		return false
+	}
+	return math.Abs(float64(frameRate.Numerator)/float64(frameRate.Denominator)-fps) < 0.01
+}
+
+func formatFrameRate(frameRate v4l2.Fract) string {
+	if frameRate.Denominator == 1 {
+		return strconv.FormatUint(uint64(frameRate.Numerator), 10)
+	}
+	return fmt.Sprintf("%d/%d", frameRate.Numerator, frameRate.Denominator)
+}
+
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -7,9 +7,12 @@
 package driver
 
 import (
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/vladimirvivien/go4vl/v4l2"
 )
 
@@ -28,6 +31,12 @@ func TestParseOptionValuePixelFormat(t *testing.T) {
 		{"yuyv (FFmpeg)", FFmpegPixelFmtYUYV, FFmpegPixelFmtYUYV, false},
 		{"mjpeg (go4vl)", v4l2.PixelFormats[v4l2.PixelFmtMJPEG], FFmpegPixelFmtMJPEG, false},
 		{"mjpeg (FFmpeg)", FFmpegPixelFmtMJPEG, FFmpegPixelFmtMJPEG, false},
+		{"h264 (go4vl)", v4l2.PixelFormats[v4l2.PixelFmtH264], FFmpegPixelFmtH264, false},
+		{"h264 (FFmpeg)", FFmpegPixelFmtH264, FFmpegPixelFmtH264, false},
+		{"h264 (four character code)", "H264", FFmpegPixelFmtH264, false},
+		{"mjpeg (four character code)", "MJPG", FFmpegPixelFmtMJPEG, false},
+		{"yuyv (four character code)", "yuyv", FFmpegPixelFmtYUYV, false},
+		{"four character code without FFmpeg format", "MPEG4", "", true},
 		{"unsupported value", "rgb8", "", true},
 		{"wrong value type", 123, "", true},
 	}
@@ -42,3 +51,261 @@ func TestParseOptionValuePixelFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestInputCapabilitiesValidate(t *testing.T) {
+	discrete := func(width, height uint32, rates ...uint32) InputFrameSize {
+		frameSize := InputFrameSize{
+			Type: v4l2.FrameSizeTypeDiscrete,
+			Size: v4l2.FrameSize{MinWidth: width, MaxWidth: width, MinHeight: height, MaxHeight: height},
+		}
+		for _, rate := range rates {
+			frameSize.FrameRates = append(frameSize.FrameRates, v4l2.Fract{Numerator: rate, Denominator: 1})
+		}
+		return frameSize
+	}
+	caps := &InputCapabilities{
+		Formats: []InputFormat{
+			{
+				PixelFormat: v4l2.PixelFmtYUYV,
+				FrameSizes:  []InputFrameSize{discrete(640, 480, 30, 15), discrete(1280, 720, 10)},
+			},
+			{
+				PixelFormat: v4l2.PixelFmtMJPEG,
+				FrameSizes:  []InputFrameSize{discrete(640, 480, 30), discrete(1920, 1080, 30)},
+			},
+			{
+				PixelFormat: v4l2.PixelFmtGrey,
+				FrameSizes: []InputFrameSize{{
+					Type: v4l2.FrameSizeTypeStepwise,
+					Size: v4l2.FrameSize{MinWidth: 320, MaxWidth: 1280, StepWidth: 16, MinHeight: 240, MaxHeight: 960, StepHeight: 8},
+				}},
+			},
+		},
+		Current: v4l2.PixFormat{PixelFormat: v4l2.PixelFmtYUYV, Width: 640, Height: 480},
+	}
+
+	tests := []struct {
+		name              string
+		options           map[string]string
+		expectedOption    string
+		expectedSupported []string
+		expectErr         bool
+	}{
+		{"no input options", map[string]string{}, "", nil, false},
+		{"supported pixel format", map[string]string{InputPixelFormat: FFmpegPixelFmtMJPEG}, "", nil, false},
+		{"unsupported pixel format", map[string]string{InputPixelFormat: FFmpegPixelFmtRGB24},
+			InputPixelFormat, []string{FFmpegPixelFmtGray, FFmpegPixelFmtMJPEG, FFmpegPixelFmtYUYV}, true},
+		{"unsupported compressed pixel format", map[string]string{InputPixelFormat: FFmpegPixelFmtH264},
+			InputPixelFormat, []string{FFmpegPixelFmtGray, FFmpegPixelFmtMJPEG, FFmpegPixelFmtYUYV}, true},
+		{"supported size for current format", map[string]string{InputImageSize: "1280x720"}, "", nil, false},
+		{"supported size abbreviation", map[string]string{InputImageSize: "hd720"}, "", nil, false},
+		{"unsupported size for current format", map[string]string{InputImageSize: "1920x1080"},
+			InputImageSize, []string{"640x480", "1280x720"}, true},
+		{"supported size for requested format", map[string]string{InputPixelFormat: FFmpegPixelFmtMJPEG, InputImageSize: "1920x1080"}, "", nil, false},
+		{"supported stepwise size", map[string]string{InputPixelFormat: FFmpegPixelFmtGray, InputImageSize: "336x248"}, "", nil, false},
+		{"unsupported stepwise size", map[string]string{InputPixelFormat: FFmpegPixelFmtGray, InputImageSize: "330x248"},
+			InputImageSize, []string{"320x240-1280x960 (step 16x8)"}, true},
+		{"invalid size", map[string]string{InputImageSize: "big"}, "", nil, true},
+		{"supported fps for current size", map[string]string{InputFps: "15"}, "", nil, false},
+		{"supported rational fps", map[string]string{InputFps: "30/1"}, "", nil, false},
+		{"unsupported fps for current size", map[string]string{InputFps: "60"},
+			InputFps, []string{"30", "15"}, true},
+		{"unsupported fps for requested size", map[string]string{InputImageSize: "1280x720", InputFps: "30"},
+			InputFps, []string{"10"}, true},
+		{"any fps for stepwise size", map[string]string{InputPixelFormat: FFmpegPixelFmtGray, InputImageSize: "320x240", InputFps: "60"}, "", nil, false},
+		{"invalid fps", map[string]string{InputFps: "fast"}, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := caps.validate(tt.options)
+			if !tt.expectErr {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			if tt.expectedOption == "" {
+				return
+			}
+			var optionErr UnsupportedInputOptionError
+			require.ErrorAs(t, err, &optionErr)
+			assert.Equal(t, tt.expectedOption, optionErr.Option)
+			assert.Equal(t, tt.expectedSupported, optionErr.Supported)
+		})
+	}
+}
+
+func TestValidatePassthrough(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     map[string]string
+		errorSubstr string
+	}{
+		{"re-encoding", map[string]string{OutputVideoCodec: "libx264", OutputFps: "10"}, ""},
+		{"h264", map[string]string{OutputVideoCodec: FFmpegCodecCopy, InputPixelFormat: FFmpegPixelFmtH264, InputImageSize: "1920x1080"}, ""},
+		{"mjpeg", map[string]string{OutputVideoCodec: FFmpegCodecCopy, InputPixelFormat: FFmpegPixelFmtMJPEG, InputFps: "30"}, ""},
+		{"missing pixel format", map[string]string{OutputVideoCodec: FFmpegCodecCopy}, "requires the InputPixelFormat option"},
+		{"raw pixel format", map[string]string{OutputVideoCodec: FFmpegCodecCopy, InputPixelFormat: FFmpegPixelFmtYUYV}, "without re-encoding"},
+		{"output size", map[string]string{OutputVideoCodec: FFmpegCodecCopy, InputPixelFormat: FFmpegPixelFmtH264, OutputImageSize: "640x480"}, OutputImageSize},
+		{"output fps", map[string]string{OutputVideoCodec: FFmpegCodecCopy, InputPixelFormat: FFmpegPixelFmtH264, OutputFps: "10"}, OutputFps},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePassthrough(tt.options)
+			if tt.errorSubstr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorSubstr)
+		})
+	}
+}
+
+func TestSetupFFmpegOptionsPassthrough(t *testing.T) {
+	installFakeFFmpeg(t)
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	caps := &InputCapabilities{
+		Formats: []InputFormat{
+			{PixelFormat: v4l2.PixelFmtYUYV, FrameSizes: []InputFrameSize{{Type: v4l2.FrameSizeTypeDiscrete,
+				Size: v4l2.FrameSize{MinWidth: 640, MaxWidth: 640, MinHeight: 480, MaxHeight: 480}}}},
+			{PixelFormat: v4l2.PixelFmtH264, FrameSizes: []InputFrameSize{{Type: v4l2.FrameSizeTypeDiscrete,
+				Size: v4l2.FrameSize{MinWidth: 1920, MaxWidth: 1920, MinHeight: 1080, MaxHeight: 1080}}}},
+		},
+		Current: v4l2.PixFormat{PixelFormat: v4l2.PixelFmtYUYV, Width: 640, Height: 480},
+	}
+
+	options := map[string]interface{}{OutputVideoCodec: FFmpegCodecCopy, InputPixelFormat: "H264", InputImageSize: "1920x1080"}
+	require.NoError(t, setupFFmpegOptions(dev, options, nil, caps))
+	mediaFile := dev.transcoder.MediaFile()
+	assert.Subset(t, mediaFile.RawInputArgs(), []string{FFmpegInputFormat, FFmpegPixelFmtH264})
+	assert.Subset(t, mediaFile.RawOutputArgs(), []string{FFmpegVCodec, FFmpegCodecCopy})
+	assert.Equal(t, FFmpegCodecCopy, dev.status().OutputVideoCodec)
+
+	// the passthrough is validated against the formats enumerated from the camera
+	options = map[string]interface{}{OutputVideoCodec: FFmpegCodecCopy, InputPixelFormat: "MJPG"}
+	edgexErr := setupFFmpegOptions(dev, options, nil, caps)
+	require.Error(t, edgexErr)
+	var optionErr UnsupportedInputOptionError
+	require.ErrorAs(t, edgexErr, &optionErr)
+	assert.Equal(t, []string{FFmpegPixelFmtH264, FFmpegPixelFmtYUYV}, optionErr.Supported)
+}
+
+func TestParseOptionValueOverlay(t *testing.T) {
+	tests := []struct {
+		name          string
+		option        string
+		value         string
+		expectedValue string
+		expectErr     bool
+	}{
+		{"timestamp format", OutputOverlayTimestamp, "%H:%M:%S", "%H:%M:%S", false},
+		{"default timestamp format", OutputOverlayTimestamp, "true", DefaultOverlayTimestamp, false},
+		{"no timestamp", OutputOverlayTimestamp, "false", "", false},
+		{"timezone", OutputOverlayTimezone, "Europe/London", "Europe/London", false},
+		{"invalid timezone", OutputOverlayTimezone, "Mars/Olympus", "", true},
+		{"device name", OutputOverlayDeviceName, "TRUE", "true", false},
+		{"invalid device name", OutputOverlayDeviceName, "yes please", "", true},
+		{"position", OutputOverlayPosition, OverlayPositionBottomRight, OverlayPositionBottomRight, false},
+		{"invalid position", OutputOverlayPosition, "middle", "", true},
+		{"font size", OutputOverlayFontSize, "32", "32", false},
+		{"invalid font size", OutputOverlayFontSize, "0", "", true},
+		{"color name", OutputOverlayFontColor, "yellow", "yellow", false},
+		{"color with opacity", OutputOverlayFontColor, "#FF0000@0.5", "#FF0000@0.5", false},
+		{"hexadecimal color", OutputOverlayFontColor, "0x00FF00CC", "0x00FF00CC", false},
+		{"invalid color", OutputOverlayFontColor, "#FF00", "", true},
+		{"color injecting a filter", OutputOverlayFontColor, "white,scale=1:1", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := parseOptionValue(tt.option, tt.value)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedValue, value)
+		})
+	}
+}
+
+func TestOverlayFilterGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		overlay  overlayOptions
+		expected string
+	}{
+		{"no overlay", overlayOptions{configured: true, position: OverlayPositionTopRight}, ""},
+		{"device name", overlayOptions{deviceName: true},
+			`drawtext=text=camera-1:fontsize=24:fontcolor=white:x=10:y=10:box=1:boxcolor=black@0.5:boxborderw=6`},
+		{"timestamp", overlayOptions{timestamp: "%Y-%m-%d %H:%M:%S", position: OverlayPositionBottomRight, fontSize: "32", fontColor: "yellow"},
+			`drawtext=text=%{localtime\\:%Y-%m-%d %H\\\\\\:%M\\\\\\:%S}:fontsize=32:fontcolor=yellow:x=w-tw-10:y=h-th-10:box=1:boxcolor=black@0.5:boxborderw=6`},
+		{"special characters", overlayOptions{text: `Gate: 'A', 100%`, position: OverlayPositionBottomLeft},
+			`drawtext=text=Gate\\: \\\'A\\\'\, 100\\\\%:fontsize=24:fontcolor=white:x=10:y=h-th-10:box=1:boxcolor=black@0.5:boxborderw=6`},
+		{"lines", overlayOptions{deviceName: true, text: "Gate", timestamp: "%T"},
+			"drawtext=text=camera-1\nGate\n%{localtime\\\\:%T}:fontsize=24:fontcolor=white:x=10:y=10:box=1:boxcolor=black@0.5:boxborderw=6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.overlay.filterGraph("camera-1"))
+		})
+	}
+}
+
+func TestOverlayFilterGraphFFmpeg(t *testing.T) {
+	ffmpegBin, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		t.Skip("ffmpeg is not available")
+	}
+	filters, err := exec.Command(ffmpegBin, "-hide_banner", "-filters").Output()
+	if err != nil || !strings.Contains(string(filters), " drawtext ") {
+		t.Skip("ffmpeg has no drawtext filter")
+	}
+
+	overlays := map[string]overlayOptions{
+		"device name":        {deviceName: true},
+		"timestamp":          {timestamp: DefaultOverlayTimestamp, position: OverlayPositionBottomRight, fontColor: "#FFCC00@0.8"},
+		"special characters": {text: `Gate: 'A', [B]; 100% \ done`, position: OverlayPositionBottomLeft},
+		"lines":              {deviceName: true, text: "Gate", timestamp: "%T", position: OverlayPositionTopRight},
+	}
+	for name, overlay := range overlays {
+		t.Run(name, func(t *testing.T) {
+			filter := overlay.filterGraph("camera-1")
+			// the snapshot output draws the overlay before its own filter
+			for _, graph := range []string{filter, filter + ",fps=" + snapshotStreamFps} {
+				output, err := exec.Command(ffmpegBin, "-hide_banner", "-loglevel", "error", "-f", "lavfi",
+					"-i", "color=c=gray:s=320x240:r=1", "-filter_complex", graph, "-frames:v", "1", "-f", "null", "-").CombinedOutput()
+				if strings.Contains(string(output), "Cannot find a valid font") {
+					t.Skip("ffmpeg has no font")
+				}
+				assert.NoError(t, err, "ffmpeg rejects the filter graph %q: %s", graph, output)
+			}
+		})
+	}
+}
+
+func TestSetupFFmpegOptionsOverlay(t *testing.T) {
+	installFakeFFmpeg(t)
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	options := map[string]interface{}{OutputOverlayTimestamp: "true", OutputOverlayDeviceName: "true", OutputOverlayTimezone: "Asia/Tokyo"}
+	attributes := map[string]interface{}{SetFunction: VideoStartStreaming, "defaultOutputOverlayFontSize": "18"}
+	require.NoError(t, setupFFmpegOptions(dev, options, attributes, nil))
+	filter := dev.transcoder.MediaFile().VideoFilter()
+	assert.Contains(t, filter, "text=camera\n%{localtime")
+	assert.Contains(t, filter, "fontsize=18", "the default overlay options of the profile are used")
+	assert.Subset(t, dev.transcoder.GetCommand(), []string{"-vf", filter})
+	dev.mutex.Lock()
+	assert.Contains(t, dev.transcoderEnv(), "TZ=Asia/Tokyo")
+	dev.mutex.Unlock()
+
+	// new output options without overlay remove the overlay
+	require.NoError(t, setupFFmpegOptions(dev, map[string]interface{}{"OutputFps": "10"}, nil, nil))
+	assert.Empty(t, dev.transcoder.MediaFile().VideoFilter())
+	assert.Nil(t, dev.transcoderEnv())
+
+	// the overlay needs the video to be re-encoded
+	options = map[string]interface{}{OutputVideoCodec: FFmpegCodecCopy, InputPixelFormat: FFmpegPixelFmtH264, OutputOverlayText: "Gate"}
+	assert.Error(t, setupFFmpegOptions(dev, options, nil, nil))
+}
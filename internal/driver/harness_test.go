@@ -0,0 +1,499 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	bootstrapMocks "github.com/edgexfoundry/go-mod-bootstrap/v4/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v4/bootstrap/secret"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/xfrr/goffmpeg/transcoder"
+
+	sdkMocks "github.com/edgexfoundry/device-sdk-go/v4/pkg/interfaces/mocks"
+	sdkModels "github.com/edgexfoundry/device-sdk-go/v4/pkg/models"
+)
+
+// The streaming tests run the real transcoder code against a fake ffmpeg. The fake is this very test binary:
+// installFakeFFmpeg links it into a temporary directory as "ffmpeg" and "ffprobe", and TestMain switches to the
+// fake implementation when the binary is invoked under one of those names.
+const (
+	// fakeFFmpegScriptEnv holds the newline separated steps executed by the fake ffmpeg, see runFakeFFmpeg
+	fakeFFmpegScriptEnv = "FAKE_FFMPEG_SCRIPT"
+	// fakeFFmpegArgsFileEnv is the file the fake ffmpeg writes its command line arguments to, one per line
+	fakeFFmpegArgsFileEnv = "FAKE_FFMPEG_ARGS_FILE"
+	// fakeFFmpegAuthUrlEnv is the url of the rtsp authentication server used by the "auth" step
+	fakeFFmpegAuthUrlEnv = "FAKE_FFMPEG_AUTH_URL"
+
+	testRtspUser     = "rtsp-user"
+	testRtspPassword = "rtsp-password"
+)
+
+func TestMain(m *testing.M) {
+	switch filepath.Base(os.Args[0]) {
+	case "ffmpeg":
+		os.Exit(runFakeFFmpeg(os.Args[1:]))
+	case "ffprobe":
+		// transcoder.Initialize only requires valid json metadata
+		fmt.Println("{}")
+		os.Exit(0)
+	}
+	code := m.Run()
+	if fakeFFmpegDir.path != "" {
+		_ = os.RemoveAll(fakeFFmpegDir.path)
+	}
+	os.Exit(code)
+}
+
+// runFakeFFmpeg executes the steps found in fakeFFmpegScriptEnv and returns the exit code. Supported steps are:
+//
+//	progress         print a progress line, the same way ffmpeg does when stats are enabled
+//	info <text>      print a log line at the given level, also supports warning, error and fatal
+//	sleep <duration> sleep for the given duration, returns early with exit code 0 when 'q' is received
+//	wait             block until 'q' is received on stdin and exit with code 0
+//	exit <code>      exit with the given code
+//	auth             authenticate the output url against the rtsp authentication server, exit with code 1 if rejected
+//	publish          publish a stream to the output url, exit with code 1 if the rtsp server rejects it
+//	snapshot         write a JPEG frame to the snapshot pipe, the same way the snapshot output of ffmpeg does
+//	ignore <signal>  ignore SIGINT or SIGTERM, so that the stop sequence has to escalate
+//	hang             block forever, ignoring 'q' on stdin
+//
+// Once all steps are executed the fake exits with code 0.
+func runFakeFFmpeg(args []string) int {
+	if len(args) > 0 && args[len(args)-1] == "pipe:1" {
+		// a snapshot read from the published stream
+		_, _ = os.Stdout.Write(fakeSnapshotFrame())
+		return 0
+	}
+	if argsFile := os.Getenv(fakeFFmpegArgsFileEnv); argsFile != "" {
+		if err := os.WriteFile(argsFile, []byte(strings.Join(args, "\n")), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "[fatal] unable to write args file: %s\n", err)
+			return 1
+		}
+	}
+
+	quit := make(chan struct{})
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return
+			}
+			if b == 'q' {
+				close(quit)
+				return
+			}
+		}
+	}()
+
+	frame := 0
+	var publisher *fakeRTSPClient
+	defer func() {
+		if publisher != nil {
+			_ = publisher.conn.Close()
+		}
+	}()
+	for _, step := range strings.Split(os.Getenv(fakeFFmpegScriptEnv), "\n") {
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(step), " ")
+		switch cmd {
+		case "":
+		case "progress":
+			frame++
+			fmt.Fprintf(os.Stderr, "[info] frame=%5d fps= 30 q=-0.0 size=N/A time=00:00:0%d.00 bitrate=N/A speed=1x\r", frame, frame)
+		case "info", "warning", "error", "fatal":
+			fmt.Fprintf(os.Stderr, "[%s] %s\n", cmd, arg)
+		case "sleep":
+			duration, err := time.ParseDuration(arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[fatal] invalid sleep duration %s\n", arg)
+				return 1
+			}
+			select {
+			case <-time.After(duration):
+			case <-quit:
+				return 0
+			}
+		case "wait":
+			<-quit
+			fmt.Fprintln(os.Stderr, "[info] Exiting normally, received signal q.")
+			return 0
+		case "exit":
+			code, err := strconv.Atoi(arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[fatal] invalid exit code %s\n", arg)
+				return 1
+			}
+			return code
+		case "auth":
+			outputUrl, err := url.Parse(args[len(args)-1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[fatal] invalid output url: %s\n", err)
+				return 1
+			}
+			client := fakeRTSPAuthClient{url: os.Getenv(fakeFFmpegAuthUrlEnv)}
+			password, _ := outputUrl.User.Password()
+			status, err := client.Authenticate(RTSPAuthRequest{
+				User:     outputUrl.User.Username(),
+				Password: password,
+				Path:     strings.TrimPrefix(outputUrl.Path, "/"),
+				Action:   "publish",
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[fatal] %s\n", err)
+				return 1
+			}
+			if status != http.StatusOK {
+				fmt.Fprintf(os.Stderr, "[error] method ANNOUNCE failed: %d %s\n", status, http.StatusText(status))
+				return 1
+			}
+		case "publish":
+			var err error
+			if publisher, err = publishFakeStream(args[len(args)-1]); err != nil {
+				fmt.Fprintf(os.Stderr, "[error] %s\n", err)
+				return 1
+			}
+		case "ignore":
+			sig, ok := map[string]os.Signal{"SIGINT": syscall.SIGINT, "SIGTERM": syscall.SIGTERM}[arg]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "[fatal] invalid signal %s\n", arg)
+				return 1
+			}
+			signal.Ignore(sig)
+		case "hang":
+			time.Sleep(time.Hour)
+		case "snapshot":
+			if _, err := os.NewFile(3, "snapshot").Write(fakeSnapshotFrame()); err != nil {
+				fmt.Fprintf(os.Stderr, "[fatal] unable to write the snapshot: %s\n", err)
+				return 1
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "[fatal] unknown fake ffmpeg step %s\n", cmd)
+			return 1
+		}
+	}
+	return 0
+}
+
+// fakeSnapshotFrame returns the JPEG frame written by the snapshot step of the fake ffmpeg
+func fakeSnapshotFrame() []byte {
+	var buf bytes.Buffer
+	img := image.NewGray(image.Rect(0, 0, 8, 6))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 4)
+	}
+	_ = jpeg.Encode(&buf, img, nil)
+	return buf.Bytes()
+}
+
+// fakeRTSPSDP is the session description of the streams published by the fake ffmpeg
+const fakeRTSPSDP = "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=fake\r\nt=0 0\r\nm=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=control:streamid=0\r\n"
+
+// fakeRTSPClient sends requests to an rtsp server over a single connection, with the credentials of the request url
+type fakeRTSPClient struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	cseq    int
+	session string
+}
+
+// fakeRTSPResponse is the response to a request of the fakeRTSPClient
+type fakeRTSPResponse struct {
+	status int
+	header textproto.MIMEHeader
+	body   string
+}
+
+func dialFakeRTSPClient(address string) (*fakeRTSPClient, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRTSPClient{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+func (c *fakeRTSPClient) request(method string, requestUrl *url.URL, header map[string]string, body string) (fakeRTSPResponse, error) {
+	c.cseq++
+	target := *requestUrl
+	target.User = nil
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %s RTSP/1.0\r\nCSeq: %d\r\n", method, target.String(), c.cseq)
+	if requestUrl.User != nil {
+		password, _ := requestUrl.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(requestUrl.User.Username() + ":" + password))
+		builder.WriteString("Authorization: Basic " + credentials + "\r\n")
+	}
+	if c.session != "" {
+		fmt.Fprintf(&builder, "Session: %s\r\n", c.session)
+	}
+	for key, value := range header {
+		fmt.Fprintf(&builder, "%s: %s\r\n", key, value)
+	}
+	if body != "" {
+		fmt.Fprintf(&builder, "Content-Length: %d\r\n", len(body))
+	}
+	builder.WriteString("\r\n" + body)
+	if _, err := c.conn.Write([]byte(builder.String())); err != nil {
+		return fakeRTSPResponse{}, err
+	}
+
+	if err := c.conn.SetReadDeadline(time.Now().Add(30 * time.Second)); err != nil {
+		return fakeRTSPResponse{}, err
+	}
+	tp := textproto.NewReader(c.reader)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return fakeRTSPResponse{}, err
+	}
+	fields := strings.SplitN(line, " ", 3)
+	if len(fields) < 2 {
+		return fakeRTSPResponse{}, fmt.Errorf("invalid status line %q", line)
+	}
+	var resp fakeRTSPResponse
+	if resp.status, err = strconv.Atoi(fields[1]); err != nil {
+		return fakeRTSPResponse{}, err
+	}
+	if resp.header, err = tp.ReadMIMEHeader(); err != nil {
+		return fakeRTSPResponse{}, err
+	}
+	if length := resp.header.Get("Content-Length"); length != "" {
+		size, err := strconv.Atoi(length)
+		if err != nil {
+			return fakeRTSPResponse{}, err
+		}
+		data := make([]byte, size)
+		if _, err = io.ReadFull(c.reader, data); err != nil {
+			return fakeRTSPResponse{}, err
+		}
+		resp.body = string(data)
+	}
+	if session := resp.header.Get("Session"); session != "" {
+		c.session, _, _ = strings.Cut(session, ";")
+	}
+	return resp, nil
+}
+
+// publishFakeStream publishes a stream to the rtsp url the same way ffmpeg does, the stream is published until the
+// connection of the returned client is closed
+func publishFakeStream(rawUrl string) (*fakeRTSPClient, error) {
+	outputUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output url: %w", err)
+	}
+	client, err := dialFakeRTSPClient(outputUrl.Host)
+	if err != nil {
+		return nil, err
+	}
+	trackUrl := *outputUrl
+	trackUrl.Path += "/streamid=0"
+	for _, step := range []struct {
+		method     string
+		requestUrl *url.URL
+		header     map[string]string
+		body       string
+	}{
+		{"ANNOUNCE", outputUrl, map[string]string{"Content-Type": "application/sdp"}, fakeRTSPSDP},
+		{"SETUP", &trackUrl, map[string]string{"Transport": "RTP/AVP/TCP;unicast;interleaved=0-1;mode=record"}, ""},
+		{"RECORD", outputUrl, nil, ""},
+	} {
+		resp, err := client.request(step.method, step.requestUrl, step.header, step.body)
+		if err == nil && resp.status != http.StatusOK {
+			err = fmt.Errorf("method %s failed: %d", step.method, resp.status)
+		}
+		if err != nil {
+			_ = client.conn.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// fakeRTSPAuthClient sends authentication requests to the rtsp authentication server the same way the rtsp server does.
+type fakeRTSPAuthClient struct {
+	url string
+}
+
+// Authenticate mimics the rtsp server by first asking without credentials, and only sending them
+// once the authentication server has replied with 401. The final status code is returned.
+func (c fakeRTSPAuthClient) Authenticate(req RTSPAuthRequest) (int, error) {
+	anonymous := req
+	anonymous.User, anonymous.Password = "", ""
+	status, err := c.send(anonymous)
+	if err != nil || status != http.StatusUnauthorized || req.User == "" {
+		return status, err
+	}
+	return c.send(req)
+}
+
+func (c fakeRTSPAuthClient) send(req RTSPAuthRequest) (int, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.Post(c.url, "application/json", bytes.NewReader(body)) // #nosec G107 -- test server url
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// startFakeRTSPAuthServer serves the driver's RTSPCredentialsHandler and configures the fake ffmpeg to use it.
+func startFakeRTSPAuthServer(t *testing.T, d *Driver) fakeRTSPAuthClient {
+	e := echo.New()
+	e.POST("/rtspauth", d.RTSPCredentialsHandler)
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	client := fakeRTSPAuthClient{url: server.URL + "/rtspauth"}
+	t.Setenv(fakeFFmpegAuthUrlEnv, client.url)
+	return client
+}
+
+var fakeFFmpegDir struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+// installFakeFFmpeg puts the fake ffmpeg and ffprobe in front of the PATH and sets the script for the fake ffmpeg
+// to execute. It returns the path of the file the fake ffmpeg writes its arguments to.
+func installFakeFFmpeg(t *testing.T, steps ...string) string {
+	fakeFFmpegDir.once.Do(func() {
+		var exe string
+		exe, fakeFFmpegDir.err = os.Executable()
+		if fakeFFmpegDir.err != nil {
+			return
+		}
+		fakeFFmpegDir.path, fakeFFmpegDir.err = os.MkdirTemp("", "fake-ffmpeg")
+		if fakeFFmpegDir.err != nil {
+			return
+		}
+		for _, name := range []string{"ffmpeg", "ffprobe"} {
+			if fakeFFmpegDir.err = os.Symlink(exe, filepath.Join(fakeFFmpegDir.path, name)); fakeFFmpegDir.err != nil {
+				return
+			}
+		}
+	})
+	require.NoError(t, fakeFFmpegDir.err, "failed to install the fake ffmpeg")
+
+	argsFile := filepath.Join(t.TempDir(), "args")
+	t.Setenv("PATH", fakeFFmpegDir.path+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(fakeFFmpegScriptEnv, strings.Join(steps, "\n"))
+	t.Setenv(fakeFFmpegArgsFileEnv, argsFile)
+	// a fake built with the race detector otherwise sleeps for a second before exiting
+	t.Setenv("GORACE", "atexit_sleep_ms=0")
+	return argsFile
+}
+
+// readFakeFFmpegArgs returns the arguments of the last fake ffmpeg process
+func readFakeFFmpegArgs(t *testing.T, argsFile string) []string {
+	data, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	return strings.Split(string(data), "\n")
+}
+
+// newFakeStreamingDriver creates a Driver with an internal rtsp server whose secret store holds the given rtsp credentials.
+// If username is empty, the secret store behaves as if the credentials were never stored.
+func newFakeStreamingDriver(t *testing.T, username, password string) (*Driver, chan *sdkModels.AsyncValues) {
+	asyncCh := make(chan *sdkModels.AsyncValues, 32)
+	secretProvider := &bootstrapMocks.SecretProviderExt{}
+	if username == "" {
+		secretProvider.On("GetSecret", RtspAuthSecretName, secret.UsernameKey, secret.PasswordKey).
+			Return(nil, fmt.Errorf("no secret stored for %s", RtspAuthSecretName))
+	} else {
+		secretProvider.On("GetSecret", RtspAuthSecretName, secret.UsernameKey, secret.PasswordKey).
+			Return(map[string]string{secret.UsernameKey: username, secret.PasswordKey: password}, nil)
+	}
+	mockService := &sdkMocks.DeviceServiceSDK{}
+	mockService.On("SecretProvider").Return(secretProvider)
+
+	d := &Driver{
+		ds:               mockService,
+		lc:               logger.MockLogger{},
+		wg:               new(sync.WaitGroup),
+		asyncCh:          asyncCh,
+		devices:          newDeviceRegistry(),
+		rtspHostName:     DefaultRtspServerHostName,
+		rtspTcpPort:      DefaultRtspTcpPort,
+		rtspServerMode:   RTSPServerModeInternal,
+		ffmpegStopPolicy: defaultFFmpegStopPolicy,
+		authGuard:        newAuthGuard(authGuardConfig{MaxFailures: DefaultRtspAuthMaxFailures, FailureWindow: DefaultRtspAuthFailureWindow, LockoutDuration: DefaultRtspAuthLockoutDuration}),
+	}
+	return d, asyncCh
+}
+
+// addFakeStreamingDevice adds a device to the driver whose transcoder runs the fake ffmpeg.
+// installFakeFFmpeg must be called beforehand.
+func addFakeStreamingDevice(t *testing.T, d *Driver, name string) *Device {
+	trans := new(transcoder.Transcoder)
+	require.NoError(t, trans.Initialize("/dev/video0", d.getAuthenticatedRTSPUri(name)))
+	trans.MediaFile().SetOutputFormat(RtspUriScheme)
+
+	dev := &Device{
+		lc:                          d.lc,
+		name:                        name,
+		paths:                       []string{"/dev/video0"},
+		transcoder:                  trans,
+		streamingStatusResourceName: "StreamingStatus",
+		stopPolicy:                  d.ffmpegStopPolicy,
+	}
+	dev.streamingStatus.TranscoderInputPath = dev.paths[0]
+	require.NoError(t, d.devices.add(dev, nil))
+	return dev
+}
+
+// nextStreamingStatus waits for the next StreamingStatus published by the driver
+func nextStreamingStatus(t *testing.T, asyncCh <-chan *sdkModels.AsyncValues) StreamingStatus {
+	select {
+	case asyncValues := <-asyncCh:
+		require.Len(t, asyncValues.CommandValues, 1)
+		status, ok := asyncValues.CommandValues[0].Value.(StreamingStatus)
+		require.True(t, ok, "unexpected value type %T", asyncValues.CommandValues[0].Value)
+		return status
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for the streaming status to be published")
+	}
+	return StreamingStatus{}
+}
+
+// waitForChannelClose fails the test if errChan is not closed in time
+func waitForChannelClose(t *testing.T, errChan <-chan error) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-errChan:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			require.FailNow(t, "timed out waiting for the transcoder to exit")
+		}
+	}
+}
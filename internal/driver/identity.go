@@ -0,0 +1,89 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+)
+
+const (
+	// portKeyPrefix prevents the key of a port bound device from colliding with the key of a serial bound one
+	portKeyPrefix = "port:"
+)
+
+// parseIdentityMode parses the identity mode, an empty value means the default serial identity mode
+func parseIdentityMode(value string) (DeviceIdentityMode, error) {
+	switch mode := DeviceIdentityMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "":
+		return IdentityModeSerial, nil
+	case IdentityModeSerial, IdentityModePort:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%s value of \"%s\" is invalid. valid options are \"%s\" and \"%s\"",
+			IdentityMode, value, IdentityModeSerial, IdentityModePort)
+	}
+}
+
+// identityModeOf returns the identity mode of the device. The IdentityMode protocol property of the device
+// takes precedence over the IdentityMode driver config.
+func (d *Driver) identityModeOf(protocols map[string]models.ProtocolProperties) DeviceIdentityMode {
+	if value, ok := protocols[UsbProtocol][IdentityMode].(string); ok && value != "" {
+		mode, err := parseIdentityMode(value)
+		if err == nil {
+			return mode
+		}
+		d.lc.Warnf("%s, use the driver config instead", err.Error())
+	}
+	if d.identityMode == "" {
+		return IdentityModeSerial
+	}
+	return d.identityMode
+}
+
+// identityKey returns the key used to match the cameras to the devices in the specified identity mode
+func identityKey(mode DeviceIdentityMode, cardName, serialNumber, busPath string) string {
+	if mode == IdentityModePort {
+		return portKeyPrefix + busPath
+	}
+	return cardName + serialNumber
+}
+
+// deviceIdentityKey returns the key of a device defined in the metadata, or false if the protocol properties
+// do not contain enough information to identify the camera
+func (d *Driver) deviceIdentityKey(device models.Device) (string, bool) {
+	cn, _ := device.Protocols[UsbProtocol][CardName].(string)
+	sn, _ := device.Protocols[UsbProtocol][SerialNumber].(string)
+	bp, _ := device.Protocols[UsbProtocol][BusPath].(string)
+	// a port bound device without bus path falls back to the serial number until the bus path is known
+	if d.identityModeOf(device.Protocols) == IdentityModePort && len(bp) > 0 {
+		return identityKey(IdentityModePort, cn, sn, bp), true
+	}
+	if len(cn) > 0 && len(sn) > 0 {
+		return identityKey(IdentityModeSerial, cn, sn, bp), true
+	}
+	return "", false
+}
+
+// matchesIdentity checks whether the camera identity is the one the device is bound to
+func (d *Driver) matchesIdentity(identity USBDeviceIdentity, protocols map[string]models.ProtocolProperties) bool {
+	if bp, ok := protocols[UsbProtocol][BusPath].(string); ok && len(bp) > 0 && d.identityModeOf(protocols) == IdentityModePort {
+		return identity.BusPath == bp
+	}
+	return identity.CardName == protocols[UsbProtocol][CardName] && identity.SerialNumber == protocols[UsbProtocol][SerialNumber]
+}
+
+// buildDiscoveredDeviceName builds the name of a discovered device, which includes the port in the port identity mode
+// as the serial number of identical cameras may be the same
+func buildDiscoveredDeviceName(mode DeviceIdentityMode, identity USBDeviceIdentity) string {
+	if mode == IdentityModePort {
+		return buildDeviceName(identity.CardName, "port-"+identity.BusPath)
+	}
+	return buildDeviceName(identity.CardName, identity.SerialNumber)
+}
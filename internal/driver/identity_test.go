@@ -0,0 +1,110 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIdentityMode(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  DeviceIdentityMode
+		expectErr bool
+	}{
+		{"", IdentityModeSerial, false},
+		{"serial", IdentityModeSerial, false},
+		{"Port", IdentityModePort, false},
+		{"topology", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			mode, err := parseIdentityMode(tt.value)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, mode)
+		})
+	}
+}
+
+func TestIdentityModeOf(t *testing.T) {
+	tests := []struct {
+		name         string
+		driverMode   DeviceIdentityMode
+		deviceMode   any
+		expectedMode DeviceIdentityMode
+	}{
+		{"default", "", nil, IdentityModeSerial},
+		{"driver config", IdentityModePort, nil, IdentityModePort},
+		{"protocol property overrides driver config", IdentityModePort, "serial", IdentityModeSerial},
+		{"protocol property", IdentityModeSerial, "port", IdentityModePort},
+		{"invalid protocol property", IdentityModePort, "unknown", IdentityModePort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Driver{lc: logger.MockLogger{}, identityMode: tt.driverMode}
+			protocols := map[string]models.ProtocolProperties{UsbProtocol: {}}
+			if tt.deviceMode != nil {
+				protocols[UsbProtocol][IdentityMode] = tt.deviceMode
+			}
+			assert.Equal(t, tt.expectedMode, d.identityModeOf(protocols))
+		})
+	}
+}
+
+func TestMatchesIdentity(t *testing.T) {
+	camera := USBDeviceIdentity{CardName: "USB 2.0 Camera", SerialNumber: "SN0001", BusPath: "1-2.1"}
+	// a camera of the same model plugged into the same port
+	replacement := USBDeviceIdentity{CardName: "USB 2.0 Camera", SerialNumber: "SN0002", BusPath: "1-2.1"}
+	// a camera of the same model with the same serial number plugged into another port
+	identical := USBDeviceIdentity{CardName: "USB 2.0 Camera", SerialNumber: "SN0001", BusPath: "1-2.2"}
+
+	serialBound := map[string]models.ProtocolProperties{
+		UsbProtocol: {CardName: "USB 2.0 Camera", SerialNumber: "SN0001"},
+	}
+	portBound := map[string]models.ProtocolProperties{
+		UsbProtocol: {CardName: "USB 2.0 Camera", SerialNumber: "SN0001", BusPath: "1-2.1", IdentityMode: "port"},
+	}
+	portBoundWithoutBusPath := map[string]models.ProtocolProperties{
+		UsbProtocol: {CardName: "USB 2.0 Camera", SerialNumber: "SN0001", IdentityMode: "port"},
+	}
+
+	d := &Driver{lc: logger.MockLogger{}}
+	tests := []struct {
+		name      string
+		identity  USBDeviceIdentity
+		protocols map[string]models.ProtocolProperties
+		expected  bool
+	}{
+		{"serial bound device", camera, serialBound, true},
+		{"serial bound device with replaced camera", replacement, serialBound, false},
+		{"serial bound device with identical camera", identical, serialBound, true},
+		{"port bound device", camera, portBound, true},
+		{"port bound device with replaced camera", replacement, portBound, true},
+		{"port bound device with identical camera", identical, portBound, false},
+		{"port bound device without bus path", camera, portBoundWithoutBusPath, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, d.matchesIdentity(tt.identity, tt.protocols))
+		})
+	}
+}
+
+func TestBuildDiscoveredDeviceName(t *testing.T) {
+	identity := USBDeviceIdentity{CardName: "USB 2.0 Camera", SerialNumber: "SN0001", BusPath: "1-2.1"}
+	assert.Equal(t, "USB_2_0_Camera-SN0001", buildDiscoveredDeviceName(IdentityModeSerial, identity))
+	assert.Equal(t, "USB_2_0_Camera-port-1-2_1", buildDiscoveredDeviceName(IdentityModePort, identity))
+}
@@ -0,0 +1,245 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/spf13/cast"
+)
+
+const (
+	// defaultRtspServerConfigFileName is the name of the generated configuration of the internal RTSP server in
+	// the temporary directory, unless RtspServerConfigFile is configured
+	defaultRtspServerConfigFileName = "device-usb-camera-mediamtx.yml"
+	// rtspCertificateName and rtspAuthCertificateName name the files the certificates loaded from the secret store
+	// are written to, next to the generated configuration
+	rtspCertificateName     = "device-usb-camera-rtsp"
+	rtspAuthCertificateName = "device-usb-camera-rtspauth"
+	// mediamtxAllOthersPath is the path configuration of mediamtx matching the paths which are not configured
+	mediamtxAllOthersPath = "all_others"
+	// mediamtxOnDemandCommand is the on demand command of the paths of the on demand devices, it does not publish
+	// anything and only makes mediamtx hold the readers until the driver publishes the stream
+	mediamtxOnDemandCommand = "sleep 86400"
+)
+
+// mediamtxTransports are the RTSP transports supported by mediamtx
+var mediamtxTransports = []string{"udp", "multicast", "tcp"}
+
+// mediamtxConfig is the subset of the mediamtx configuration generated for the internal RTSP server, see
+// https://github.com/bluenviron/mediamtx/blob/main/mediamtx.yml for the meaning of the settings
+type mediamtxConfig struct {
+	LogLevel        string                        `json:"logLevel"`
+	LogDestinations []string                      `json:"logDestinations"`
+	AuthMethod      string                        `json:"authMethod"`
+	AuthHTTPAddress string                        `json:"authHTTPAddress"`
+	API             bool                          `json:"api"`
+	APIAddress      string                        `json:"apiAddress,omitempty"`
+	RTSP            bool                          `json:"rtsp"`
+	RTSPTransports  []string                      `json:"rtspTransports"`
+	RTSPAddress     string                        `json:"rtspAddress"`
+	RTMP            bool                          `json:"rtmp"`
+	HLS             bool                          `json:"hls"`
+	HLSAddress      string                        `json:"hlsAddress"`
+	WebRTC          bool                          `json:"webrtc"`
+	WebRTCAddress   string                        `json:"webrtcAddress"`
+	SRT             bool                          `json:"srt"`
+	Paths           map[string]mediamtxPathConfig `json:"paths"`
+
+	// the encryption settings are only set in the RTSPS mode, where all the listeners require TLS
+	RTSPEncryption   string `json:"rtspEncryption,omitempty"`
+	RTSPSAddress     string `json:"rtspsAddress,omitempty"`
+	RTSPServerKey    string `json:"rtspServerKey,omitempty"`
+	RTSPServerCert   string `json:"rtspServerCert,omitempty"`
+	RTMPEncryption   string `json:"rtmpEncryption,omitempty"`
+	RTMPServerKey    string `json:"rtmpServerKey,omitempty"`
+	RTMPServerCert   string `json:"rtmpServerCert,omitempty"`
+	HLSEncryption    bool   `json:"hlsEncryption,omitempty"`
+	HLSServerKey     string `json:"hlsServerKey,omitempty"`
+	HLSServerCert    string `json:"hlsServerCert,omitempty"`
+	WebRTCEncryption bool   `json:"webrtcEncryption,omitempty"`
+	WebRTCServerKey  string `json:"webrtcServerKey,omitempty"`
+	WebRTCServerCert string `json:"webrtcServerCert,omitempty"`
+}
+
+// mediamtxPathConfig is the configuration of a path of mediamtx
+type mediamtxPathConfig struct {
+	MaxReaders int `json:"maxReaders,omitempty"`
+	// the on demand command only holds the readers until the stream started by the driver is published
+	RunOnDemand             string `json:"runOnDemand,omitempty"`
+	RunOnDemandStartTimeout string `json:"runOnDemandStartTimeout,omitempty"`
+	RunOnDemandCloseAfter   string `json:"runOnDemandCloseAfter,omitempty"`
+}
+
+// newMediamtxConfig generates the configuration of the internal RTSP server from the driver configs and the
+// protocol properties of the devices
+func (d *Driver) newMediamtxConfig(configs map[string]string, devices []models.Device) (mediamtxConfig, error) {
+	config := mediamtxConfig{
+		LogLevel:        "info",
+		LogDestinations: []string{"stdout"},
+		AuthMethod:      "http",
+		AuthHTTPAddress: rtspAuthHookURL(d.rtspAuthenticationServerUri, d.authServerCertificate != nil),
+		RTSP:            true,
+		RTSPAddress:     ":" + d.rtspTcpPort,
+		HLSAddress:      ":" + stringOrDefault(configs[RtspServerHlsPort], DefaultRtspServerHlsPort),
+		WebRTCAddress:   ":" + stringOrDefault(configs[RtspServerWebRtcPort], DefaultRtspServerWebRtcPort),
+		Paths:           map[string]mediamtxPathConfig{mediamtxAllOthersPath: {}},
+		// the API tells the readers of the on demand streams, it is only reachable from the host of the service
+		API:        true,
+		APIAddress: net.JoinHostPort("127.0.0.1", stringOrDefault(configs[RtspServerApiPort], DefaultRtspServerApiPort)),
+	}
+
+	for _, transport := range strings.Split(stringOrDefault(configs[RtspServerTransports], DefaultRtspServerTransports), ",") {
+		transport = strings.ToLower(strings.TrimSpace(transport))
+		if !slices.Contains(mediamtxTransports, transport) {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, valid transports are %s",
+				RtspServerTransports, configs[RtspServerTransports], strings.Join(mediamtxTransports, ", "))
+		}
+		if !slices.Contains(config.RTSPTransports, transport) {
+			config.RTSPTransports = append(config.RTSPTransports, transport)
+		}
+	}
+
+	var err error
+	for key, toggle := range map[string]*bool{
+		RtspServerEnableRtmp:   &config.RTMP,
+		RtspServerEnableHls:    &config.HLS,
+		RtspServerEnableWebRtc: &config.WebRTC,
+	} {
+		// RTMP and HLS were enabled before the configuration was generated, so they are enabled by default
+		if *toggle, err = parseBoolConfig(configs, key, key != RtspServerEnableWebRtc); err != nil {
+			return config, err
+		}
+	}
+
+	for _, device := range devices {
+		pathConfig := mediamtxPathConfig{}
+		if value, ok := device.Protocols[UsbProtocol][RtspMaxReaders]; ok && fmt.Sprint(value) != "" {
+			maxReaders, err := cast.ToIntE(value)
+			if err != nil || maxReaders < 0 {
+				d.lc.Warnf("ignoring the %s protocol property of device %s, %v is not a valid number of readers",
+					RtspMaxReaders, device.Name, value)
+			} else {
+				pathConfig.MaxReaders = maxReaders
+			}
+		}
+		onDemand, idleTimeout, edgexErr := d.deviceOnDemandStreaming(device.Name, device.Protocols)
+		if edgexErr != nil {
+			d.lc.Warnf("ignoring the on demand streaming of device %s: %s", device.Name, edgexErr.Error())
+		} else if onDemand {
+			// the driver starts the streaming when the reader is authenticated, the readers wait for it to be
+			// published instead of being rejected. The path closes after the driver has stopped the idle stream.
+			pathConfig.RunOnDemand = mediamtxOnDemandCommand
+			pathConfig.RunOnDemandStartTimeout = onDemandPublishTimeout.String()
+			pathConfig.RunOnDemandCloseAfter = (idleTimeout + 2*onDemandCheckInterval).String()
+		}
+		config.Paths[path.Join(Stream, device.Name)] = pathConfig
+	}
+
+	dir := filepath.Dir(mediamtxConfigFile(configs))
+	if d.authServerCertificate != nil {
+		// the RTSP server must trust the certificate of the authentication server, which is passed to its process as SSL_CERT_FILE
+		if _, _, err := d.authServerCertificate.files(dir, rtspAuthCertificateName); err != nil {
+			return config, err
+		}
+	}
+	if d.rtspCertificate != nil {
+		certFile, keyFile, err := d.rtspCertificate.files(dir, rtspCertificateName)
+		if err != nil {
+			return config, err
+		}
+		// the RTSPS listener replaces the RTSP listener on the same port, so that the stream uris only differ by scheme
+		config.RTSPEncryption = "strict"
+		config.RTSPSAddress = config.RTSPAddress
+		config.RTSPServerCert, config.RTSPServerKey = certFile, keyFile
+		config.RTMPEncryption = "strict"
+		config.RTMPServerCert, config.RTMPServerKey = certFile, keyFile
+		config.HLSEncryption = true
+		config.HLSServerCert, config.HLSServerKey = certFile, keyFile
+		config.WebRTCEncryption = true
+		config.WebRTCServerCert, config.WebRTCServerKey = certFile, keyFile
+	}
+	return config, nil
+}
+
+// mediamtxConfigFile returns the path of the generated configuration of the internal RTSP server
+func mediamtxConfigFile(configs map[string]string) string {
+	if configFile := strings.TrimSpace(configs[RtspServerConfigFile]); configFile != "" {
+		return configFile
+	}
+	return filepath.Join(os.TempDir(), defaultRtspServerConfigFileName)
+}
+
+// rtspAuthHookURL returns the url mediamtx sends the authentication requests to, the authentication server
+// listens on the address, which may not specify the host
+func rtspAuthHookURL(address string, useTls bool) string {
+	scheme := "http://"
+	if useTls {
+		scheme = "https://"
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return scheme + address + "/rtspauth"
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	return scheme + net.JoinHostPort(host, port) + "/rtspauth"
+}
+
+// writeMediamtxConfig writes the configuration of the internal RTSP server to the configured file, and returns
+// the path of the file
+func (d *Driver) writeMediamtxConfig(configs map[string]string, config mediamtxConfig) (string, error) {
+	configFile := mediamtxConfigFile(configs)
+	// JSON is valid YAML, and the paths of the devices must be quoted anyway
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to generate the rtsp server configuration: %s", err.Error())
+	}
+	header := "# Generated by device-usb-camera from its driver configuration, changes are overwritten at startup\n"
+	if err := os.WriteFile(configFile, append([]byte(header), data...), 0600); err != nil {
+		return "", fmt.Errorf("failed to write the rtsp server configuration to %s: %s", configFile, err.Error())
+	}
+
+	paths := make([]string, 0, len(config.Paths))
+	for p := range config.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	d.lc.Infof("Generated the rtsp server configuration %s with rtsp address %s, transports %v and paths %v",
+		configFile, config.RTSPAddress, config.RTSPTransports, paths)
+	return configFile, nil
+}
+
+// parseBoolConfig parses a boolean driver config, which is set to the default value if it is empty
+func parseBoolConfig(configs map[string]string, key string, defaultValue bool) (bool, error) {
+	value := strings.TrimSpace(configs[key])
+	if value == "" {
+		return defaultValue, nil
+	}
+	parsed, err := cast.ToBoolE(value)
+	if err != nil {
+		return false, fmt.Errorf("%s value of \"%s\" is invalid, it must be a boolean", key, value)
+	}
+	return parsed, nil
+}
+
+func stringOrDefault(value, defaultValue string) string {
+	if value = strings.TrimSpace(value); value == "" {
+		return defaultValue
+	}
+	return value
+}
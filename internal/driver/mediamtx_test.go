@@ -0,0 +1,131 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMediamtxConfig(t *testing.T) {
+	devices := []models.Device{
+		{Name: "camera1", Protocols: map[string]models.ProtocolProperties{UsbProtocol: {Paths: "/dev/video0", RtspMaxReaders: "2"}}},
+		{Name: "camera2", Protocols: map[string]models.ProtocolProperties{UsbProtocol: {Paths: "/dev/video2", RtspMaxReaders: "many"}}},
+		{Name: "camera3", Protocols: map[string]models.ProtocolProperties{UsbProtocol: {Paths: "/dev/video4"}}},
+		{Name: "camera4", Protocols: map[string]models.ProtocolProperties{UsbProtocol: {Paths: "/dev/video6", OnDemandStreaming: "true", OnDemandIdleTimeout: "1m"}}},
+	}
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		check     func(t *testing.T, config mediamtxConfig)
+		expectErr bool
+	}{
+		{"defaults", map[string]string{}, func(t *testing.T, config mediamtxConfig) {
+			assert.Equal(t, "http", config.AuthMethod)
+			assert.Equal(t, "http://localhost:8000/rtspauth", config.AuthHTTPAddress)
+			assert.Equal(t, ":8554", config.RTSPAddress)
+			assert.Equal(t, []string{"tcp"}, config.RTSPTransports)
+			assert.True(t, config.RTMP)
+			assert.True(t, config.HLS)
+			assert.Equal(t, ":"+DefaultRtspServerHlsPort, config.HLSAddress)
+			assert.False(t, config.WebRTC)
+			assert.True(t, config.API)
+			assert.Equal(t, "127.0.0.1:"+DefaultRtspServerApiPort, config.APIAddress)
+			assert.Equal(t, map[string]mediamtxPathConfig{
+				mediamtxAllOthersPath: {},
+				"stream/camera1":      {MaxReaders: 2},
+				"stream/camera2":      {},
+				"stream/camera3":      {},
+				"stream/camera4": {
+					RunOnDemand:             mediamtxOnDemandCommand,
+					RunOnDemandStartTimeout: "15s",
+					RunOnDemandCloseAfter:   "1m20s",
+				},
+			}, config.Paths)
+		}, false},
+		{"configured", map[string]string{
+			RtspServerTransports:   "UDP, tcp,tcp",
+			RtspServerEnableRtmp:   "false",
+			RtspServerEnableHls:    "false",
+			RtspServerEnableWebRtc: "true",
+			RtspServerWebRtcPort:   "9000",
+			RtspServerApiPort:      "9100",
+		}, func(t *testing.T, config mediamtxConfig) {
+			assert.Equal(t, []string{"udp", "tcp"}, config.RTSPTransports)
+			assert.Equal(t, "127.0.0.1:9100", config.APIAddress)
+			assert.False(t, config.RTMP)
+			assert.False(t, config.HLS)
+			assert.True(t, config.WebRTC)
+			assert.Equal(t, ":9000", config.WebRTCAddress)
+		}, false},
+		{"invalid transport", map[string]string{RtspServerTransports: "tcp,http"}, nil, true},
+		{"invalid toggle", map[string]string{RtspServerEnableHls: "maybe"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Driver{lc: logger.MockLogger{}, rtspTcpPort: DefaultRtspTcpPort, rtspAuthenticationServerUri: DefaultRtspAuthenticationServer}
+			config, err := d.newMediamtxConfig(tt.configs, devices)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, config)
+		})
+	}
+}
+
+func TestRtspAuthHookURL(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected string
+	}{
+		{"localhost:8000", "http://localhost:8000/rtspauth"},
+		{":8000", "http://localhost:8000/rtspauth"},
+		{"0.0.0.0:8000", "http://localhost:8000/rtspauth"},
+		{"[::]:8000", "http://localhost:8000/rtspauth"},
+		{"10.0.0.1:9000", "http://10.0.0.1:9000/rtspauth"},
+		{"[fd00::1]:9000", "http://[fd00::1]:9000/rtspauth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rtspAuthHookURL(tt.address, false))
+		})
+	}
+}
+
+func TestWriteMediamtxConfig(t *testing.T) {
+	d := &Driver{lc: logger.MockLogger{}, rtspTcpPort: "9554", rtspAuthenticationServerUri: DefaultRtspAuthenticationServer}
+	config, err := d.newMediamtxConfig(map[string]string{}, []models.Device{{Name: "camera"}})
+	require.NoError(t, err)
+
+	configFile := filepath.Join(t.TempDir(), "mediamtx.yml")
+	written, err := d.writeMediamtxConfig(map[string]string{RtspServerConfigFile: configFile}, config)
+	require.NoError(t, err)
+	assert.Equal(t, configFile, written)
+
+	data, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "#"))
+	_, content, _ := strings.Cut(string(data), "\n")
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(content), &parsed))
+	assert.Equal(t, ":9554", parsed["rtspAddress"])
+	assert.Equal(t, []any{"tcp"}, parsed["rtspTransports"])
+	assert.Contains(t, parsed["paths"], "stream/camera")
+
+	_, err = d.writeMediamtxConfig(map[string]string{RtspServerConfigFile: filepath.Join(configFile, "invalid")}, config)
+	assert.Error(t, err)
+}
@@ -311,3 +311,80 @@ func getPixFormatDesc(usbDevice *usbdevice.Device, pixFmt uint32) (string, error
 	}
 	return "", nil
 }
+
+// getInputFormats enumerates the pixel formats, frame sizes and frame rates the device is able to capture.
+func getInputFormats(d *usbdevice.Device) ([]InputFormat, error) {
+	descs, err := d.GetFormatDescriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var formats []InputFormat
+	for _, desc := range descs {
+		fss, err := v4l2.GetFormatFrameSizes(d.Fd(), desc.PixelFormat)
+		if err != nil {
+			return nil, err
+		}
+		format := InputFormat{
+			PixelFormat: desc.PixelFormat,
+			Description: desc.Description,
+		}
+		for _, frameSize := range fss {
+			inputFrameSize := InputFrameSize{
+				Type: frameSize.Type,
+				Size: frameSize.Size,
+			}
+			if frameSize.Type == v4l2.FrameSizeTypeDiscrete {
+				inputFrameSize.FrameRates = getDiscreteFrameRates(d, desc.PixelFormat, frameSize.Size.MaxWidth, frameSize.Size.MaxHeight)
+			}
+			format.FrameSizes = append(format.FrameSizes, inputFrameSize)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// getDiscreteFrameRates returns the discrete frame rates supported for the given pixel format and frame size.
+// If the device reports stepwise or continuous frame intervals, nil is returned.
+func getDiscreteFrameRates(d *usbdevice.Device, pixelFormat, width, height uint32) []v4l2.Fract {
+	var frameRates []v4l2.Fract
+	for index := uint32(0); ; index++ {
+		interval, err := v4l2.GetFormatFrameInterval(d.Fd(), index, pixelFormat, width, height)
+		if err != nil {
+			break
+		}
+		if interval.Type != v4l2.FrameIntervalTypeDiscrete {
+			return nil
+		}
+		// this swaps the internally tracked frame interval (seconds per frame)
+		// to user-friendly frame rate (frames per second)
+		frameRates = append(frameRates, v4l2.Fract{
+			Denominator: interval.Interval.Max.Numerator,
+			Numerator:   interval.Interval.Max.Denominator,
+		})
+	}
+	return frameRates
+}
+
+// getInputCapabilities returns the capture capabilities and the current pixel format of the device.
+func getInputCapabilities(d *usbdevice.Device) (*InputCapabilities, error) {
+	formats, err := getInputFormats(d)
+	if err != nil {
+		return nil, err
+	}
+	current, err := d.GetPixFormat()
+	if err != nil {
+		return nil, err
+	}
+	return &InputCapabilities{Formats: formats, Current: current}, nil
+}
+
+// getPathInputCapabilities returns the input capabilities of the camera on the specified path
+func getPathInputCapabilities(path string) (*InputCapabilities, error) {
+	cameraDevice, err := usbdevice.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer cameraDevice.Close()
+	return getInputCapabilities(cameraDevice)
+}
@@ -0,0 +1,242 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/spf13/cast"
+)
+
+const (
+	// OnDemandStreaming is the protocol property making the device stream only while it is viewed, the streaming
+	// starts when a client is authenticated to read the stream and stops once it has had no reader for the idle timeout
+	OnDemandStreaming = "OnDemandStreaming"
+	// OnDemandIdleTimeout is the driver config of how long an on demand stream runs without reader, the protocol
+	// property of the same name replaces it for a device
+	OnDemandIdleTimeout        = "OnDemandIdleTimeout"
+	DefaultOnDemandIdleTimeout = 2 * time.Minute
+	// RtspServerApiPort is the driver config of the port of the API of the internal RTSP server, which only listens
+	// on the loopback interface and tells the driver the readers of the streams
+	RtspServerApiPort        = "RtspServerApiPort"
+	DefaultRtspServerApiPort = "9997"
+
+	// onDemandCheckInterval is how often the readers of the on demand streams are checked
+	onDemandCheckInterval = 10 * time.Second
+	// onDemandPublishTimeout is how long the readers of a stopped on demand stream wait for it to be published
+	onDemandPublishTimeout = 15 * time.Second
+	// rtspServerApiTimeout is the timeout of the requests to the API of the internal RTSP server
+	rtspServerApiTimeout = 2 * time.Second
+)
+
+// parseOnDemandIdleTimeout parses the idle timeout of the on demand streams, it returns the default value if
+// the value is empty
+func parseOnDemandIdleTimeout(value any, defaultValue time.Duration) (time.Duration, error) {
+	s := strings.TrimSpace(cast.ToString(value))
+	if s == "" {
+		return defaultValue, nil
+	}
+	timeout, err := time.ParseDuration(s)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("%s value of \"%s\" is invalid, it must be a positive duration such as 2m", OnDemandIdleTimeout, s)
+	}
+	return timeout, nil
+}
+
+// deviceOnDemandStreaming returns whether the device streams on demand, and its idle timeout
+func (d *Driver) deviceOnDemandStreaming(name string, protocols map[string]models.ProtocolProperties) (bool, time.Duration, errors.EdgeX) {
+	value, ok := protocols[UsbProtocol][OnDemandStreaming]
+	if !ok || fmt.Sprint(value) == "" {
+		return false, 0, nil
+	}
+	onDemand, err := cast.ToBoolE(value)
+	if err != nil {
+		return false, 0, errors.NewCommonEdgeX(errors.KindContractInvalid,
+			fmt.Sprintf("invalid %s protocol property of device %s", OnDemandStreaming, name), err)
+	}
+	if !onDemand {
+		return false, 0, nil
+	}
+	timeout, err := parseOnDemandIdleTimeout(protocols[UsbProtocol][OnDemandIdleTimeout], d.onDemandIdleTimeout)
+	if err != nil {
+		return false, 0, errors.NewCommonEdgeX(errors.KindContractInvalid,
+			fmt.Sprintf("invalid %s protocol property of device %s", OnDemandIdleTimeout, name), err)
+	}
+	return true, timeout, nil
+}
+
+// streamDeviceName returns the name of the device streaming on the path
+func streamDeviceName(streamPath string) (string, bool) {
+	return strings.CutPrefix(strings.TrimPrefix(streamPath, "/"), Stream+"/")
+}
+
+// touchOnDemandStreaming records that the stream of the device has been viewed
+func (dev *Device) touchOnDemandStreaming(now time.Time) {
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	if now.After(dev.lastViewedAt) {
+		dev.lastViewedAt = now
+	}
+}
+
+// startOnDemandStreaming starts the streaming of the on demand device the client has been authenticated to read.
+// The streaming is started in the background so that the authentication is not held up by the camera. The embedded
+// RTSP server and the on demand paths of the internal RTSP server hold the readers for up to onDemandPublishTimeout
+// until the stream is published, the readers of the external RTSP servers are rejected until then and must retry.
+// The preview and snapshot APIs read the camera directly, so they do not start the streaming, and the devices with a
+// running preview are not started since the camera is busy.
+func (d *Driver) startOnDemandStreaming(authRequest RTSPAuthRequest, now time.Time) {
+	if authRequest.Action != "read" || authRequest.Protocol == "http" || d.rtspServerMode == RTSPServerModeNone {
+		return
+	}
+	name, ok := streamDeviceName(authRequest.Path)
+	if !ok {
+		return
+	}
+	device, ok := d.devices.get(name)
+	if !ok || !device.onDemand {
+		return
+	}
+	device.touchOnDemandStreaming(now)
+	if device.isStreaming() {
+		return
+	}
+	if device.previewActive() {
+		d.lc.Infof("Not starting the on demand video streaming of device %s for %s, the camera is used by a preview", name, authRequest.IP)
+		return
+	}
+
+	go func() {
+		unlock := d.devices.lock(name)
+		defer unlock()
+		// another reader may have started the streaming while waiting for the lock
+		if device.isStreaming() {
+			return
+		}
+		d.lc.Infof("Starting the on demand video streaming of device %s for %s", name, authRequest.IP)
+		if edgexErr := d.startDefaultStreaming(device); edgexErr != nil {
+			d.lc.Errorf("failed to start the on demand video streaming for device %s, error: %s", name, edgexErr)
+			return
+		}
+		device.touchOnDemandStreaming(time.Now())
+	}()
+}
+
+// startOnDemandIdleChecks stops the on demand streams which have had no reader for their idle timeout, until Stop
+func (d *Driver) startOnDemandIdleChecks() {
+	done := make(chan struct{})
+	d.onDemandDone = done
+	go func() {
+		ticker := time.NewTicker(onDemandCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				d.stopIdleOnDemandStreaming(done, time.Now())
+			}
+		}
+	}()
+}
+
+// stopIdleOnDemandStreaming stops the on demand streams which have had no reader for their idle timeout. The
+// streams whose readers are unknown are idle once no client has been authenticated to read them for the timeout.
+func (d *Driver) stopIdleOnDemandStreaming(done <-chan struct{}, now time.Time) {
+	for _, device := range d.devices.list() {
+		if !device.onDemand || !device.isStreaming() {
+			continue
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if readers, known := d.streamReaderCount(device.name); known && readers > 0 {
+			device.touchOnDemandStreaming(now)
+			continue
+		}
+		device.mutex.Lock()
+		idle := now.Sub(device.lastViewedAt)
+		device.mutex.Unlock()
+		if idle < device.onDemandIdleTimeout {
+			continue
+		}
+
+		unlock := d.devices.lock(device.name)
+		device.mutex.Lock()
+		// the stream may have been viewed while waiting for the lock
+		idle = now.Sub(device.lastViewedAt)
+		device.mutex.Unlock()
+		if idle >= device.onDemandIdleTimeout {
+			d.lc.Infof("Stopping the on demand video streaming of device %s, it has had no reader for %s", device.name, device.onDemandIdleTimeout)
+			device.StopStreaming()
+		}
+		unlock()
+	}
+}
+
+// streamReaderCount returns the number of readers of the stream of the device, and whether it is known. The
+// readers are known to the embedded RTSP server and to the API of the internal RTSP server.
+func (d *Driver) streamReaderCount(name string) (int, bool) {
+	switch {
+	case d.rtspServer != nil:
+		return len(d.rtspReaders(name)), true
+	case d.rtspServerApiAddress != "":
+		readers, err := d.mediamtxReaderCount(name)
+		if err != nil {
+			d.lc.Warnf("Failed to get the readers of the stream of device %s from the rtsp server: %s", name, err.Error())
+			return 0, false
+		}
+		return readers, true
+	}
+	return 0, false
+}
+
+// mediamtxPath is the subset of the state of a path returned by the API of mediamtx
+type mediamtxPath struct {
+	Readers []json.RawMessage `json:"readers"`
+}
+
+// mediamtxReaderCount returns the number of readers of the stream of the device from the API of the internal
+// RTSP server, a path without publisher has no reader
+func (d *Driver) mediamtxReaderCount(name string) (int, error) {
+	apiUrl := url.URL{Scheme: "http", Host: d.rtspServerApiAddress, Path: "/v3/paths/get/" + path.Join(Stream, name)}
+	request, err := http.NewRequest(http.MethodGet, apiUrl.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	// the API requests are authenticated by the authentication hook like the RTSP requests
+	if credential, edgexErr := d.tryGetCredentials(RtspAuthSecretName); edgexErr == nil {
+		request.SetBasicAuth(credential.Username, credential.Password)
+	}
+	client := http.Client{Timeout: rtspServerApiTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%s returned status %d", apiUrl.String(), response.StatusCode)
+	}
+	var state mediamtxPath
+	if err := json.NewDecoder(response.Body).Decode(&state); err != nil {
+		return 0, fmt.Errorf("invalid response of %s: %w", apiUrl.String(), err)
+	}
+	return len(state.Readers), nil
+}
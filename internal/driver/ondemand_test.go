@@ -0,0 +1,183 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceOnDemandStreaming(t *testing.T) {
+	tests := []struct {
+		name           string
+		properties     map[string]any
+		expectOnDemand bool
+		expectTimeout  time.Duration
+		expectErr      bool
+	}{
+		{"not set", map[string]any{}, false, 0, false},
+		{"disabled", map[string]any{OnDemandStreaming: "false", OnDemandIdleTimeout: "1m"}, false, 0, false},
+		{"default timeout", map[string]any{OnDemandStreaming: "true"}, true, DefaultOnDemandIdleTimeout, false},
+		{"device timeout", map[string]any{OnDemandStreaming: true, OnDemandIdleTimeout: "30s"}, true, 30 * time.Second, false},
+		{"invalid flag", map[string]any{OnDemandStreaming: "sometimes"}, false, 0, true},
+		{"invalid timeout", map[string]any{OnDemandStreaming: "true", OnDemandIdleTimeout: "0s"}, false, 0, true},
+	}
+	d := &Driver{lc: logger.MockLogger{}, onDemandIdleTimeout: DefaultOnDemandIdleTimeout}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			onDemand, timeout, err := d.deviceOnDemandStreaming("camera", map[string]models.ProtocolProperties{UsbProtocol: tt.properties})
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectOnDemand, onDemand)
+			assert.Equal(t, tt.expectTimeout, timeout)
+		})
+	}
+}
+
+func TestOnDemandStreamingStartsOnRead(t *testing.T) {
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	client := startFakeRTSPAuthServer(t, d)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.onDemand, dev.onDemandIdleTimeout = true, time.Minute
+	always := addFakeStreamingDevice(t, d, "always")
+
+	read := func(name, protocol, action string) {
+		status, err := client.Authenticate(RTSPAuthRequest{IP: "10.0.0.5", User: testRtspUser, Password: testRtspPassword,
+			Path: "stream/" + name, Protocol: protocol, Action: action})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+	}
+	read("camera", "rtsp", "publish")
+	read("camera", "http", "read")
+	read("always", "rtsp", "read")
+	assert.False(t, dev.isStreaming(), "only the readers of the stream start it")
+	assert.False(t, always.isStreaming(), "the devices which are not on demand are not started")
+
+	read("camera", "rtsp", "read")
+	assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming, "the reader starts the stream")
+	assert.True(t, dev.isStreaming())
+
+	// the other readers join the running stream
+	read("camera", "hls", "read")
+	assert.True(t, dev.isStreaming())
+
+	dev.StopStreaming()
+	require.NoError(t, d.Stop(false))
+}
+
+func TestOnDemandStreamingSkipsPreview(t *testing.T) {
+	setFakeFrameCapture(t, fakeSnapshotFrame())
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.onDemand, dev.onDemandIdleTimeout = true, time.Minute
+
+	_, unsubscribe, err := dev.subscribePreview(dev.streamingStatus.TranscoderInputPath, frameCaptureConfig{}, false)
+	require.NoError(t, err)
+	defer unsubscribe()
+	d.startOnDemandStreaming(RTSPAuthRequest{Path: "stream/camera", Protocol: "rtsp", Action: "read"}, time.Now())
+	assert.False(t, dev.isStreaming(), "the camera is used by the preview")
+}
+
+func TestStopIdleOnDemandStreaming(t *testing.T) {
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	startFakeRTSPAuthServer(t, d)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.onDemand, dev.onDemandIdleTimeout = true, time.Minute
+
+	readers := `{"name":"stream/camera","readers":[{"type":"rtspSession","id":"1"}]}`
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/paths/get/stream/camera" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(readers))
+	}))
+	defer api.Close()
+	d.rtspServerApiAddress = strings.TrimPrefix(api.URL, "http://")
+
+	start := time.Now()
+	d.startOnDemandStreaming(RTSPAuthRequest{Path: "stream/camera", Protocol: "rtsp", Action: "read"}, start)
+	assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+
+	// the stream is kept while it has readers
+	d.stopIdleOnDemandStreaming(nil, start.Add(2*time.Minute))
+	assert.True(t, dev.isStreaming())
+
+	readers = `{"name":"stream/camera","readers":[]}`
+	d.stopIdleOnDemandStreaming(nil, start.Add(2*time.Minute+30*time.Second))
+	assert.True(t, dev.isStreaming(), "the stream runs until it has had no reader for the idle timeout")
+
+	d.stopIdleOnDemandStreaming(nil, start.Add(3*time.Minute))
+	assert.False(t, dev.isStreaming())
+	assert.False(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	require.NoError(t, d.Stop(false))
+}
+
+func TestStopIdleOnDemandStreamingUnknownReaders(t *testing.T) {
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	startFakeRTSPAuthServer(t, d)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.onDemand, dev.onDemandIdleTimeout = true, time.Minute
+
+	start := time.Now()
+	d.startOnDemandStreaming(RTSPAuthRequest{Path: "/stream/camera", Protocol: "rtsp", Action: "read"}, start)
+	assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+
+	// without reader information the authentications of the readers keep the stream running
+	d.startOnDemandStreaming(RTSPAuthRequest{Path: "/stream/camera", Protocol: "rtsp", Action: "read"}, start.Add(50*time.Second))
+	d.stopIdleOnDemandStreaming(nil, start.Add(90*time.Second))
+	assert.True(t, dev.isStreaming())
+
+	d.stopIdleOnDemandStreaming(nil, start.Add(110*time.Second))
+	assert.False(t, dev.isStreaming())
+	require.NoError(t, d.Stop(false))
+}
+
+func TestMediamtxReaderCount(t *testing.T) {
+	var status int
+	var user string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, _ = r.BasicAuth()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"readers":[{"type":"hlsMuxer"},{"type":"webRTCSession"}]}`))
+	}))
+	defer api.Close()
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspServerApiAddress = strings.TrimPrefix(api.URL, "http://")
+
+	status = http.StatusOK
+	readers, err := d.mediamtxReaderCount("camera")
+	require.NoError(t, err)
+	assert.Equal(t, 2, readers)
+	assert.Equal(t, testRtspUser, user, "the API requests are authenticated with the rtsp credentials")
+
+	status = http.StatusNotFound
+	readers, err = d.mediamtxReaderCount("camera")
+	require.NoError(t, err)
+	assert.Zero(t, readers, "a path without publisher has no reader")
+
+	status = http.StatusInternalServerError
+	_, err = d.mediamtxReaderCount("camera")
+	assert.Error(t, err)
+	_, known := d.streamReaderCount("camera")
+	assert.False(t, known)
+}
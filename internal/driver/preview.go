@@ -0,0 +1,487 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
+
+	"github.com/labstack/echo/v4"
+	usbDevice "github.com/vladimirvivien/go4vl/device"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+const (
+	previewBoundary = "frame"
+	previewRealm    = "device-usb-camera"
+)
+
+// previewConfig limits the frame rate and the frame size of the MJPEG previews
+type previewConfig struct {
+	MaxFps      uint32
+	MaxWidth    uint32
+	MaxHeight   uint32
+	JpegQuality int
+}
+
+// parsePreviewConfig reads the preview limits from the driver configs, using the defaults for the missing ones
+func parsePreviewConfig(configs map[string]string) (previewConfig, error) {
+	config := previewConfig{
+		MaxFps:      DefaultPreviewMaxFps,
+		MaxWidth:    DefaultPreviewMaxWidth,
+		MaxHeight:   DefaultPreviewMaxHeight,
+		JpegQuality: DefaultPreviewJpegQuality,
+	}
+	for key, value := range map[string]*uint32{
+		PreviewMaxFps:    &config.MaxFps,
+		PreviewMaxWidth:  &config.MaxWidth,
+		PreviewMaxHeight: &config.MaxHeight,
+	} {
+		if configs[key] == "" {
+			continue
+		}
+		parsed, err := strconv.ParseUint(configs[key], 10, 32)
+		if err != nil || parsed == 0 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be a positive integer", key, configs[key])
+		}
+		*value = uint32(parsed)
+	}
+	if configs[PreviewJpegQuality] != "" {
+		quality, err := strconv.Atoi(configs[PreviewJpegQuality])
+		if err != nil || quality < 1 || quality > 100 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be between 1 and 100", PreviewJpegQuality, configs[PreviewJpegQuality])
+		}
+		config.JpegQuality = quality
+	}
+	return config, nil
+}
+
+// frameCaptureConfig configures the capture of JPEG frames from a camera
+type frameCaptureConfig struct {
+	MaxWidth    uint32
+	MaxHeight   uint32
+	JpegQuality int
+}
+
+// frameCapture captures the frames of a camera as JPEG images
+type frameCapture interface {
+	// Frames returns the captured frames, the channel is closed when the capture stops
+	Frames() <-chan []byte
+	Close() error
+}
+
+// openFrameCapture starts capturing JPEG frames from the camera on the specified path
+var openFrameCapture = openV4l2FrameCapture
+
+// v4l2FrameCapture captures the frames of a camera in MJPG if supported, or in YUYV encoded to JPEG otherwise
+type v4l2FrameCapture struct {
+	device *usbDevice.Device
+	cancel context.CancelFunc
+	frames chan []byte
+}
+
+func openV4l2FrameCapture(path string, config frameCaptureConfig) (frameCapture, error) {
+	cameraDevice, err := usbDevice.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	formats, err := getInputFormats(cameraDevice)
+	_ = cameraDevice.Close()
+	if err != nil {
+		return nil, err
+	}
+	pixelFormat, width, height, err := selectPreviewFormat(formats, config.MaxWidth, config.MaxHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	cameraDevice, err = usbDevice.Open(path, usbDevice.WithPixFormat(v4l2.PixFormat{
+		PixelFormat: pixelFormat,
+		Width:       width,
+		Height:      height,
+		Field:       v4l2.FieldNone,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	// the driver may adjust the requested format
+	pixFmt, err := cameraDevice.GetPixFormat()
+	if err != nil {
+		_ = cameraDevice.Close()
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rawFrames := cameraDevice.GetFrames()
+	if err := cameraDevice.Start(ctx); err != nil {
+		cancel()
+		_ = cameraDevice.Close()
+		return nil, err
+	}
+
+	capture := &v4l2FrameCapture{
+		device: cameraDevice,
+		cancel: cancel,
+		frames: make(chan []byte, 1),
+	}
+	go func() {
+		defer close(capture.frames)
+		for frame := range rawFrames {
+			data, err := encodeJPEGFrame(frame.Data, pixFmt.PixelFormat, pixFmt.Width, pixFmt.Height, config.JpegQuality)
+			frame.Release()
+			if err != nil {
+				continue
+			}
+			select {
+			case capture.frames <- data:
+			default: // drop the frame if the previous one has not been consumed yet
+			}
+		}
+	}()
+	return capture, nil
+}
+
+func (c *v4l2FrameCapture) Frames() <-chan []byte {
+	return c.frames
+}
+
+func (c *v4l2FrameCapture) Close() error {
+	c.cancel()
+	return c.device.Close()
+}
+
+// selectPreviewFormat selects MJPG if the camera supports it, or YUYV otherwise, along with the largest
+// frame size which fits in the specified limits
+func selectPreviewFormat(formats []InputFormat, maxWidth, maxHeight uint32) (uint32, uint32, uint32, error) {
+	for _, pixelFormat := range []uint32{v4l2.PixelFmtMJPEG, v4l2.PixelFmtJPEG, v4l2.PixelFmtYUYV} {
+		for _, format := range formats {
+			if format.PixelFormat != pixelFormat {
+				continue
+			}
+			var width, height uint32
+			for _, frameSize := range format.FrameSizes {
+				w, h := fitFrameSize(frameSize, maxWidth, maxHeight)
+				if uint64(w)*uint64(h) > uint64(width)*uint64(height) {
+					width, height = w, h
+				}
+			}
+			if width > 0 && height > 0 {
+				return pixelFormat, width, height, nil
+			}
+		}
+	}
+	return 0, 0, 0, fmt.Errorf("the camera supports neither MJPG nor YUYV within %dx%d", maxWidth, maxHeight)
+}
+
+// fitFrameSize returns the largest size of the frame size which fits in the limits, or zeros if none fits
+func fitFrameSize(frameSize InputFrameSize, maxWidth, maxHeight uint32) (uint32, uint32) {
+	size := frameSize.Size
+	if frameSize.Type == v4l2.FrameSizeTypeDiscrete {
+		if size.MaxWidth <= maxWidth && size.MaxHeight <= maxHeight {
+			return size.MaxWidth, size.MaxHeight
+		}
+		return 0, 0
+	}
+	width, height := min(size.MaxWidth, maxWidth), min(size.MaxHeight, maxHeight)
+	if size.StepWidth > 1 && width > size.MinWidth {
+		width -= (width - size.MinWidth) % size.StepWidth
+	}
+	if size.StepHeight > 1 && height > size.MinHeight {
+		height -= (height - size.MinHeight) % size.StepHeight
+	}
+	if width < size.MinWidth || height < size.MinHeight {
+		return 0, 0
+	}
+	return width, height
+}
+
+// encodeJPEGFrame returns the frame as a JPEG image, MJPG frames are already JPEG images
+func encodeJPEGFrame(data []byte, pixelFormat, width, height uint32, quality int) ([]byte, error) {
+	switch pixelFormat {
+	case v4l2.PixelFmtMJPEG, v4l2.PixelFmtJPEG:
+		return bytes.Clone(data), nil
+	case v4l2.PixelFmtYUYV:
+		img, err := yuyvToYCbCr(data, int(width), int(height))
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported pixel format %s", fourCCString(pixelFormat))
+	}
+}
+
+// yuyvToYCbCr converts a YUYV frame, where each 4 bytes Y0 U Y1 V describe 2 pixels, to a 4:2:2 YCbCr image
+func yuyvToYCbCr(data []byte, width, height int) (*image.YCbCr, error) {
+	if width <= 0 || height <= 0 || width%2 != 0 || len(data) < width*height*2 {
+		return nil, fmt.Errorf("invalid YUYV frame of %d bytes for %dx%d", len(data), width, height)
+	}
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio422)
+	for y := 0; y < height; y++ {
+		row := data[y*width*2 : (y+1)*width*2]
+		for x := 0; x < width; x += 2 {
+			i := x * 2
+			img.Y[y*img.YStride+x] = row[i]
+			img.Y[y*img.YStride+x+1] = row[i+2]
+			img.Cb[y*img.CStride+x/2] = row[i+1]
+			img.Cr[y*img.CStride+x/2] = row[i+3]
+		}
+	}
+	return img, nil
+}
+
+// errPreviewMismatch is returned when a preview is requested with other parameters than the running one
+var errPreviewMismatch = errors.New("a preview with other parameters is running")
+
+// previewSource shares the frames captured from a camera between the preview clients
+type previewSource struct {
+	mutex       sync.Mutex
+	path        string
+	config      frameCaptureConfig
+	capture     frameCapture
+	subscribers map[chan []byte]struct{}
+	closed      bool
+}
+
+// run broadcasts the captured frames until the capture stops, and then closes the channels of the subscribers
+func (s *previewSource) run() {
+	for frame := range s.capture.Frames() {
+		s.mutex.Lock()
+		for ch := range s.subscribers {
+			// only keep the latest frame for slow subscribers
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- frame
+		}
+		s.mutex.Unlock()
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	for ch := range s.subscribers {
+		close(ch)
+	}
+	s.subscribers = nil
+}
+
+// subscribePreview subscribes to the JPEG frames of the camera on the specified path, starting the capture if
+// it is the first subscriber. The camera can only be captured once, so all the subscribers share the capture
+// started by the first one, and errPreviewMismatch is returned if it captures another path or with another config,
+// unless anyConfig is set. The returned function unsubscribes, and stops the capture once there are no
+// subscribers left.
+func (dev *Device) subscribePreview(path string, config frameCaptureConfig, anyConfig bool) (<-chan []byte, func(), error) {
+	dev.previewMutex.Lock()
+	defer dev.previewMutex.Unlock()
+
+	source := dev.preview
+	if source != nil && (source.path != path || (!anyConfig && source.config != config)) {
+		return nil, nil, fmt.Errorf("%w, it captures %s at most %dx%d with JPEG quality %d", errPreviewMismatch,
+			source.path, source.config.MaxWidth, source.config.MaxHeight, source.config.JpegQuality)
+	}
+	if source == nil {
+		capture, err := openFrameCapture(path, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		source = &previewSource{path: path, config: config, capture: capture, subscribers: make(map[chan []byte]struct{})}
+		dev.preview = source
+		go func() {
+			source.run()
+			dev.previewMutex.Lock()
+			if dev.preview == source {
+				dev.preview = nil
+			}
+			dev.previewMutex.Unlock()
+		}()
+	}
+
+	ch := make(chan []byte, 1)
+	source.mutex.Lock()
+	if source.closed {
+		close(ch)
+	} else {
+		source.subscribers[ch] = struct{}{}
+	}
+	source.mutex.Unlock()
+
+	unsubscribe := func() {
+		dev.previewMutex.Lock()
+		source.mutex.Lock()
+		delete(source.subscribers, ch)
+		last := len(source.subscribers) == 0 && !source.closed
+		source.mutex.Unlock()
+		if last && dev.preview == source {
+			dev.preview = nil
+		}
+		dev.previewMutex.Unlock()
+		if last {
+			if err := source.capture.Close(); err != nil {
+				dev.lc.Warnf("failed to stop the preview capture of device %s, error: %s", dev.name, err.Error())
+			}
+		}
+	}
+	return ch, unsubscribe, nil
+}
+
+// previewActive returns whether a preview of the camera is running
+func (dev *Device) previewActive() bool {
+	dev.previewMutex.Lock()
+	defer dev.previewMutex.Unlock()
+	return dev.preview != nil
+}
+
+// PreviewRoute serves a multipart/x-mixed-replace MJPEG preview of the camera specified by the name path parameter.
+// The clients authenticate with HTTP basic authentication using the same credentials as the RTSP streams.
+// The fps, width and height query parameters lower the limits of the preview configured for the driver. The fps
+// applies to each client, while the clients share the capture of the camera, so a preview requested with another
+// width or height than the running one is rejected with 409 Conflict.
+func (d *Driver) PreviewRoute(c echo.Context) error {
+	request := c.Request()
+	name := c.Param(common.Name)
+
+	if status, message := d.authenticateViewer(c, name); status != http.StatusOK {
+		return d.writePreviewError(c, status, message)
+	}
+
+	dev, ok := d.devices.get(name)
+	if !ok {
+		return d.writePreviewError(c, http.StatusNotFound, fmt.Sprintf("camera %s is not active", name))
+	}
+
+	queryParams := request.URL.Query()
+	config := d.previewConfig
+	fps, err := parsePreviewLimit(queryParams.Get("fps"), config.MaxFps)
+	if err != nil {
+		return d.writePreviewError(c, http.StatusBadRequest, fmt.Sprintf("invalid fps: %s", err.Error()))
+	}
+	maxWidth, err := parsePreviewLimit(queryParams.Get("width"), config.MaxWidth)
+	if err != nil {
+		return d.writePreviewError(c, http.StatusBadRequest, fmt.Sprintf("invalid width: %s", err.Error()))
+	}
+	maxHeight, err := parsePreviewLimit(queryParams.Get("height"), config.MaxHeight)
+	if err != nil {
+		return d.writePreviewError(c, http.StatusBadRequest, fmt.Sprintf("invalid height: %s", err.Error()))
+	}
+
+	if dev.isStreaming() {
+		return d.writePreviewError(c, http.StatusConflict, fmt.Sprintf("camera %s is busy streaming", name))
+	}
+	videoPath, edgexErr := d.getPathName(dev, queryParams)
+	if edgexErr != nil {
+		return d.writePreviewError(c, http.StatusBadRequest, edgexErr.Error())
+	}
+
+	frames, unsubscribe, err := dev.subscribePreview(videoPath, frameCaptureConfig{
+		MaxWidth:    maxWidth,
+		MaxHeight:   maxHeight,
+		JpegQuality: config.JpegQuality,
+	}, false)
+	if errors.Is(err, errPreviewMismatch) {
+		return d.writePreviewError(c, http.StatusConflict, fmt.Sprintf("camera %s cannot be previewed as requested: %s", name, err.Error()))
+	}
+	if err != nil {
+		return d.writePreviewError(c, http.StatusServiceUnavailable,
+			fmt.Sprintf("failed to capture the camera %s at path %s: %s", name, videoPath, err.Error()))
+	}
+	defer unsubscribe()
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "multipart/x-mixed-replace; boundary="+previewBoundary)
+	response.Header().Set(echo.HeaderCacheControl, "no-cache, no-store, must-revalidate")
+	response.WriteHeader(http.StatusOK)
+
+	interval := time.Second / time.Duration(fps)
+	var lastFrameAt time.Time
+	for {
+		select {
+		case <-request.Context().Done():
+			return nil
+		case frame, ok := <-frames:
+			if !ok {
+				return nil
+			}
+			now := time.Now()
+			if now.Sub(lastFrameAt) < interval {
+				continue
+			}
+			lastFrameAt = now
+			if err := writePreviewFrame(response, frame); err != nil {
+				d.lc.Debugf("stopped the preview of camera %s, error: %s", name, err.Error())
+				return nil
+			}
+			response.Flush()
+		}
+	}
+}
+
+// authenticateViewer checks the HTTP basic authentication credentials of a client viewing the camera with the
+// specified name against the credentials of the RTSP streams, and asks the client for them if they are missing
+func (d *Driver) authenticateViewer(c echo.Context, name string) (int, string) {
+	request := c.Request()
+	user, password, _ := request.BasicAuth()
+	status, message := d.authenticate(RTSPAuthRequest{
+		// the forwarded headers are not trusted, they would let the clients evade the lockout of their IP
+		IP:       echo.ExtractIPDirect()(request),
+		User:     user,
+		Password: password,
+		Path:     path.Join(Stream, name),
+		Protocol: "http",
+		Action:   "read",
+		Query:    request.URL.RawQuery,
+	})
+	if status == http.StatusUnauthorized {
+		c.Response().Header().Set(echo.HeaderWWWAuthenticate, fmt.Sprintf("Basic realm=%q", previewRealm))
+	}
+	return status, message
+}
+
+// writePreviewFrame writes a frame as a part of the multipart response, the frame is shared with the other clients
+// so it must not be modified
+func writePreviewFrame(response *echo.Response, frame []byte) error {
+	if _, err := fmt.Fprintf(response, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", previewBoundary, len(frame)); err != nil {
+		return err
+	}
+	if _, err := response.Write(frame); err != nil {
+		return err
+	}
+	_, err := response.Write([]byte("\r\n"))
+	return err
+}
+
+// parsePreviewLimit parses a limit of the preview requested by the client, which cannot exceed the configured one
+func parsePreviewLimit(value string, limit uint32) (uint32, error) {
+	if value == "" {
+		return limit, nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || parsed == 0 {
+		return 0, fmt.Errorf("%s is not a positive integer", value)
+	}
+	return min(uint32(parsed), limit), nil
+}
+
+func (d *Driver) writePreviewError(c echo.Context, status int, message string) error {
+	d.writeJSONResponse(c.Response(), c.Request(), status, commonDTO.NewBaseResponse("", message, status))
+	return nil
+}
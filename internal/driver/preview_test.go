@@ -0,0 +1,303 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+// fakeFrameCapture produces copies of a JPEG frame until it is closed
+type fakeFrameCapture struct {
+	frames    chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *fakeFrameCapture) Frames() <-chan []byte {
+	return c.frames
+}
+
+func (c *fakeFrameCapture) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	return nil
+}
+
+// setFakeFrameCapture replaces the camera capture with fake captures, and returns the function returning
+// the number of captures which are currently open
+func setFakeFrameCapture(t *testing.T, frame []byte) func() int {
+	var mutex sync.Mutex
+	open := 0
+	original := openFrameCapture
+	openFrameCapture = func(string, frameCaptureConfig) (frameCapture, error) {
+		capture := &fakeFrameCapture{frames: make(chan []byte, 1), done: make(chan struct{})}
+		mutex.Lock()
+		open++
+		mutex.Unlock()
+		go func() {
+			defer close(capture.frames)
+			defer func() {
+				mutex.Lock()
+				open--
+				mutex.Unlock()
+			}()
+			ticker := time.NewTicker(10 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-capture.done:
+					return
+				case <-ticker.C:
+					select {
+					case capture.frames <- frame:
+					default:
+					}
+				}
+			}
+		}()
+		return capture, nil
+	}
+	t.Cleanup(func() { openFrameCapture = original })
+	return func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return open
+	}
+}
+
+func newTestJPEG(t *testing.T) []byte {
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+func TestParsePreviewConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		expected  previewConfig
+		expectErr bool
+	}{
+		{"defaults", map[string]string{},
+			previewConfig{DefaultPreviewMaxFps, DefaultPreviewMaxWidth, DefaultPreviewMaxHeight, DefaultPreviewJpegQuality}, false},
+		{"configured", map[string]string{PreviewMaxFps: "2", PreviewMaxWidth: "640", PreviewMaxHeight: "480", PreviewJpegQuality: "50"},
+			previewConfig{2, 640, 480, 50}, false},
+		{"zero fps", map[string]string{PreviewMaxFps: "0"}, previewConfig{}, true},
+		{"invalid width", map[string]string{PreviewMaxWidth: "wide"}, previewConfig{}, true},
+		{"invalid quality", map[string]string{PreviewJpegQuality: "101"}, previewConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parsePreviewConfig(tt.configs)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
+
+func TestSelectPreviewFormat(t *testing.T) {
+	discrete := func(width, height uint32) InputFrameSize {
+		return InputFrameSize{Type: v4l2.FrameSizeTypeDiscrete, Size: v4l2.FrameSize{MinWidth: width, MaxWidth: width, MinHeight: height, MaxHeight: height}}
+	}
+	yuyv := InputFormat{PixelFormat: v4l2.PixelFmtYUYV, FrameSizes: []InputFrameSize{discrete(640, 480), discrete(1280, 720)}}
+	mjpeg := InputFormat{PixelFormat: v4l2.PixelFmtMJPEG, FrameSizes: []InputFrameSize{discrete(640, 480), discrete(1920, 1080)}}
+	mjpeg1080 := InputFormat{PixelFormat: v4l2.PixelFmtMJPEG, FrameSizes: []InputFrameSize{discrete(1920, 1080)}}
+	stepwise := InputFormat{PixelFormat: v4l2.PixelFmtYUYV, FrameSizes: []InputFrameSize{{
+		Type: v4l2.FrameSizeTypeStepwise,
+		Size: v4l2.FrameSize{MinWidth: 160, MaxWidth: 1920, StepWidth: 16, MinHeight: 120, MaxHeight: 1080, StepHeight: 16},
+	}}}
+	depth := InputFormat{PixelFormat: PixFmtDepthZ16, FrameSizes: []InputFrameSize{discrete(640, 480)}}
+
+	tests := []struct {
+		name           string
+		formats        []InputFormat
+		maxWidth       uint32
+		maxHeight      uint32
+		expectedFormat uint32
+		expectedWidth  uint32
+		expectedHeight uint32
+		expectErr      bool
+	}{
+		{"prefer MJPG", []InputFormat{yuyv, mjpeg}, 1280, 720, v4l2.PixelFmtMJPEG, 640, 480, false},
+		{"largest fitting size", []InputFormat{yuyv}, 1280, 720, v4l2.PixelFmtYUYV, 1280, 720, false},
+		{"fall back to YUYV when no MJPG size fits", []InputFormat{mjpeg1080, yuyv}, 1280, 720, v4l2.PixelFmtYUYV, 1280, 720, false},
+		{"no size fits", []InputFormat{yuyv, mjpeg}, 320, 240, 0, 0, 0, true},
+		{"stepwise size", []InputFormat{stepwise}, 1000, 700, v4l2.PixelFmtYUYV, 992, 696, false},
+		{"unsupported format", []InputFormat{depth}, 1280, 720, 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pixelFormat, width, height, err := selectPreviewFormat(tt.formats, tt.maxWidth, tt.maxHeight)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedFormat, pixelFormat)
+			assert.Equal(t, tt.expectedWidth, width)
+			assert.Equal(t, tt.expectedHeight, height)
+		})
+	}
+}
+
+func TestEncodeJPEGFrame(t *testing.T) {
+	// a 4x2 YUYV frame of mid gray
+	yuyv := bytes.Repeat([]byte{128, 128, 128, 128}, 4)
+	data, err := encodeJPEGFrame(yuyv, v4l2.PixelFmtYUYV, 4, 2, 90)
+	require.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 4, 2), img.Bounds())
+	gray := color.GrayModel.Convert(img.At(1, 1)).(color.Gray)
+	assert.InDelta(t, 128, gray.Y, 4)
+
+	frame := newTestJPEG(t)
+	data, err = encodeJPEGFrame(frame, v4l2.PixelFmtMJPEG, 4, 4, 90)
+	require.NoError(t, err)
+	assert.Equal(t, frame, data)
+
+	_, err = encodeJPEGFrame(yuyv[:8], v4l2.PixelFmtYUYV, 4, 2, 90)
+	assert.Error(t, err, "the frame is too short")
+	_, err = encodeJPEGFrame(yuyv, PixFmtDepthZ16, 4, 2, 90)
+	assert.Error(t, err)
+}
+
+func TestSubscribePreview(t *testing.T) {
+	openCaptures := setFakeFrameCapture(t, newTestJPEG(t))
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	installFakeFFmpeg(t)
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	frames1, unsubscribe1, err := dev.subscribePreview("/dev/video0", frameCaptureConfig{}, false)
+	require.NoError(t, err)
+	frames2, unsubscribe2, err := dev.subscribePreview("/dev/video0", frameCaptureConfig{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, openCaptures(), "the subscribers share the capture")
+	_, _, err = dev.subscribePreview("/dev/video0", frameCaptureConfig{MaxWidth: 320, MaxHeight: 240}, false)
+	assert.ErrorIs(t, err, errPreviewMismatch, "the capture is only shared with the same config")
+	_, _, err = dev.subscribePreview("/dev/video1", frameCaptureConfig{}, true)
+	assert.ErrorIs(t, err, errPreviewMismatch, "the capture is only shared on the same path")
+	_, unsubscribe3, err := dev.subscribePreview("/dev/video0", frameCaptureConfig{MaxWidth: 320, MaxHeight: 240}, true)
+	require.NoError(t, err, "the capture is shared with the subscribers accepting any config")
+	unsubscribe3()
+	assert.Equal(t, 1, openCaptures())
+	for _, frames := range []<-chan []byte{frames1, frames2} {
+		select {
+		case <-frames:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for a preview frame")
+		}
+	}
+
+	unsubscribe1()
+	assert.Equal(t, 1, openCaptures(), "the capture is kept for the remaining subscriber")
+	unsubscribe2()
+	assert.Eventually(t, func() bool { return openCaptures() == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestStartStreamingDuringPreview(t *testing.T) {
+	setFakeFrameCapture(t, newTestJPEG(t))
+	installFakeFFmpeg(t, "progress", "wait")
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	_, unsubscribe, err := dev.subscribePreview("/dev/video0", frameCaptureConfig{}, false)
+	require.NoError(t, err)
+	edgexErr := d.startStreaming(dev)
+	require.Error(t, edgexErr)
+	assert.Equal(t, errors.KindStatusConflict, errors.Kind(edgexErr))
+	assert.Contains(t, edgexErr.Error(), "preview")
+	assert.False(t, dev.isStreaming())
+	unsubscribe()
+}
+
+func TestPreviewRoute(t *testing.T) {
+	frame := newTestJPEG(t)
+	setFakeFrameCapture(t, frame)
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspServerMode = RTSPServerModeNone
+	d.previewConfig = previewConfig{MaxFps: 100, MaxWidth: 640, MaxHeight: 480, JpegQuality: 80}
+	installFakeFFmpeg(t)
+	addFakeStreamingDevice(t, d, "camera")
+	streaming := addFakeStreamingDevice(t, d, "streaming")
+	streaming.streamingStatus.IsStreaming = true
+
+	e := echo.New()
+	e.GET(common.ApiBase+ApiPreview, d.PreviewRoute)
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		deviceName     string
+		query          string
+		user           string
+		password       string
+		expectedStatus int
+	}{
+		{"preview", "camera", "", testRtspUser, testRtspPassword, http.StatusOK},
+		{"missing credentials", "camera", "", "", "", http.StatusUnauthorized},
+		{"wrong password", "camera", "", testRtspUser, "wrong", http.StatusUnauthorized},
+		{"unknown camera", "unknown", "", testRtspUser, testRtspPassword, http.StatusNotFound},
+		{"streaming camera", "streaming", "", testRtspUser, testRtspPassword, http.StatusConflict},
+		{"invalid fps", "camera", "?fps=0", testRtspUser, testRtspPassword, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodGet, server.URL+common.ApiBase+"/preview/"+tt.deviceName+tt.query, nil)
+			require.NoError(t, err)
+			if tt.user != "" {
+				request.SetBasicAuth(tt.user, tt.password)
+			}
+			response, err := http.DefaultClient.Do(request)
+			require.NoError(t, err)
+			defer response.Body.Close()
+			require.Equal(t, tt.expectedStatus, response.StatusCode)
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.Contains(t, response.Header.Get(echo.HeaderWWWAuthenticate), "Basic")
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			mediaType, params, err := mime.ParseMediaType(response.Header.Get(echo.HeaderContentType))
+			require.NoError(t, err)
+			assert.Equal(t, "multipart/x-mixed-replace", mediaType)
+			reader := multipart.NewReader(response.Body, params["boundary"])
+			for i := 0; i < 2; i++ {
+				part, err := reader.NextPart()
+				require.NoError(t, err)
+				assert.Equal(t, "image/jpeg", part.Header.Get(echo.HeaderContentType))
+				data, err := io.ReadAll(part)
+				require.NoError(t, err)
+				assert.Equal(t, frame, data)
+			}
+		})
+	}
+}
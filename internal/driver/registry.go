@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"sort"
+	"sync"
+)
+
+// deviceRegistry holds the active devices. It owns the locking of the devices map, so that the devices can be
+// looked up from any goroutine, and serializes the lifecycle operations of each device (add, remove, update,
+// start and stop streaming) so that the operations on the same device never interleave while the operations on
+// different devices run concurrently.
+type deviceRegistry struct {
+	mutex   sync.RWMutex
+	devices map[string]*Device
+	// lifecycles are the locks of the devices with lifecycle operations in progress or waiting
+	lifecycles map[string]*lifecycleLock
+}
+
+// lifecycleLock serializes the lifecycle operations of a device, refs counts the operations holding or
+// waiting for it so that it is released once there is none left
+type lifecycleLock struct {
+	mutex sync.Mutex
+	refs  int
+}
+
+func newDeviceRegistry() *deviceRegistry {
+	return &deviceRegistry{
+		devices:    make(map[string]*Device),
+		lifecycles: make(map[string]*lifecycleLock),
+	}
+}
+
+// get returns the active device with the specified name
+func (r *deviceRegistry) get(name string) (*Device, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	dev, ok := r.devices[name]
+	return dev, ok
+}
+
+// list returns the active devices sorted by name
+func (r *deviceRegistry) list() []*Device {
+	r.mutex.RLock()
+	devices := make([]*Device, 0, len(r.devices))
+	for _, dev := range r.devices {
+		devices = append(devices, dev)
+	}
+	r.mutex.RUnlock()
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].name < devices[j].name
+	})
+	return devices
+}
+
+// add adds the device unless conflict returns an error for one of the active devices, the check and the
+// addition are atomic so that two devices added concurrently cannot both claim the same camera
+func (r *deviceRegistry) add(dev *Device, conflict func(existing *Device) error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if conflict != nil {
+		for _, existing := range r.devices {
+			if err := conflict(existing); err != nil {
+				return err
+			}
+		}
+	}
+	r.devices[dev.name] = dev
+	return nil
+}
+
+// remove removes the device with the specified name, and returns it if it was active
+func (r *deviceRegistry) remove(name string) (*Device, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	dev, ok := r.devices[name]
+	delete(r.devices, name)
+	return dev, ok
+}
+
+// lock waits until no other lifecycle operation is in progress for the device with the specified name, and
+// returns the function ending the operation. The device does not have to be active, so that its addition
+// can be serialized as well.
+func (r *deviceRegistry) lock(name string) (unlock func()) {
+	r.mutex.Lock()
+	l, ok := r.lifecycles[name]
+	if !ok {
+		l = &lifecycleLock{}
+		r.lifecycles[name] = l
+	}
+	l.refs++
+	r.mutex.Unlock()
+
+	l.mutex.Lock()
+	return func() {
+		l.mutex.Unlock()
+		r.mutex.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(r.lifecycles, name)
+		}
+		r.mutex.Unlock()
+	}
+}
@@ -0,0 +1,192 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceRegistryConcurrentAccess(t *testing.T) {
+	r := newDeviceRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("camera%d-%d", i, j%10)
+				assert.NoError(t, r.add(&Device{name: name}, nil))
+				_, ok := r.get(name)
+				assert.True(t, ok)
+				r.list()
+				if j%2 == 0 {
+					r.remove(name)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	devices := r.list()
+	assert.Len(t, devices, 8*5)
+	for i := 1; i < len(devices); i++ {
+		assert.Less(t, devices[i-1].name, devices[i].name, "the devices are sorted by name")
+	}
+}
+
+func TestDeviceRegistryAddConflict(t *testing.T) {
+	r := newDeviceRegistry()
+	conflict := func(serialNumber string) func(*Device) error {
+		return func(existing *Device) error {
+			if existing.serialNumber == serialNumber {
+				return fmt.Errorf("the serial number %s conflicts with existing device %s", serialNumber, existing.name)
+			}
+			return nil
+		}
+	}
+
+	// the devices claiming the same camera are added concurrently, only one of them is added
+	var added atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if r.add(&Device{name: fmt.Sprintf("camera%d", i), serialNumber: "SN1"}, conflict("SN1")) == nil {
+				added.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), added.Load())
+	assert.Len(t, r.list(), 1)
+
+	assert.NoError(t, r.add(&Device{name: "other", serialNumber: "SN2"}, conflict("SN2")))
+	err := r.add(&Device{name: "other", serialNumber: "SN2"}, conflict("SN2"))
+	assert.Error(t, err, "a device added again conflicts with itself until it is removed")
+	_, ok := r.remove("other")
+	assert.True(t, ok)
+	_, ok = r.remove("other")
+	assert.False(t, ok)
+}
+
+func TestDeviceRegistryLock(t *testing.T) {
+	r := newDeviceRegistry()
+
+	unlock := r.lock("camera")
+	locked := make(chan struct{})
+	go func() {
+		defer close(locked)
+		r.lock("camera")()
+	}()
+	select {
+	case <-locked:
+		require.FailNow(t, "the operations on the same device must not interleave")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the operations on the other devices are not blocked
+	otherLocked := make(chan struct{})
+	go func() {
+		defer close(otherLocked)
+		r.lock("other")()
+	}()
+	select {
+	case <-otherLocked:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the operations on different devices must run concurrently")
+	}
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for the lock to be released")
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	assert.Empty(t, r.lifecycles, "the locks are released once no operation holds them")
+}
+
+func TestDeviceRegistryLockSerializes(t *testing.T) {
+	r := newDeviceRegistry()
+	var inProgress, overlaps atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := r.lock("camera")
+			defer unlock()
+			if inProgress.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			time.Sleep(time.Millisecond)
+			inProgress.Add(-1)
+		}()
+	}
+	wg.Wait()
+	assert.Zero(t, overlaps.Load())
+}
+
+func TestStreamingStatusDuringLifecycleOperations(t *testing.T) {
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	startFakeRTSPAuthServer(t, d)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	go func() {
+		for range asyncCh {
+		}
+	}()
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if dev, ok := d.devices.get("camera"); ok {
+					d.streamingStatus(dev)
+				}
+				for _, dev := range d.devices.list() {
+					_ = dev.status().IsStreaming
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		unlock := d.devices.lock(dev.name)
+		err := d.startStreaming(dev)
+		unlock()
+		require.NoError(t, err)
+		require.Eventually(t, dev.isStreaming, 5*time.Second, 10*time.Millisecond)
+
+		unlock = d.devices.lock(dev.name)
+		dev.StopStreaming()
+		unlock()
+		require.Eventually(t, func() bool { return !dev.isStreaming() }, 5*time.Second, 10*time.Millisecond)
+	}
+	close(done)
+	readers.Wait()
+
+	require.NoError(t, d.Stop(false))
+}
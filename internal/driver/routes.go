@@ -7,11 +7,19 @@
 package driver
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	commonDTO "github.com/edgexfoundry/go-mod-core-contracts/v4/dtos/common"
 )
 
+// DiscoveryReportResponse is the response of the discovery report API
+type DiscoveryReportResponse struct {
+	commonDTO.BaseResponse `json:",inline"`
+	Entries                []DiscoveryReportEntry `json:"entries"`
+}
+
 func (d *Driver) RefreshExistingDevicePathsRoute(writer http.ResponseWriter, request *http.Request) {
 	go d.RefreshAllDevicePaths()
 	correlationID := request.Header.Get(common.CorrelationHeader)
@@ -19,3 +27,27 @@ func (d *Driver) RefreshExistingDevicePathsRoute(writer http.ResponseWriter, req
 	writer.Header().Set(common.ContentType, common.ContentTypeJSON)
 	writer.WriteHeader(http.StatusAccepted)
 }
+
+// DiscoveryReportRoute scans the video devices the same way as the discovery does, and reports what the discovery
+// would do with each of them without adding or updating any device
+func (d *Driver) DiscoveryReportRoute(writer http.ResponseWriter, request *http.Request) {
+	scan := d.scanVideoDevices()
+	response := DiscoveryReportResponse{
+		BaseResponse: commonDTO.NewBaseResponse("", "", http.StatusOK),
+		Entries:      scan.report,
+	}
+	if response.Entries == nil {
+		response.Entries = []DiscoveryReportEntry{}
+	}
+	d.writeJSONResponse(writer, request, http.StatusOK, response)
+}
+
+func (d *Driver) writeJSONResponse(writer http.ResponseWriter, request *http.Request, statusCode int, response any) {
+	correlationID := request.Header.Get(common.CorrelationHeader)
+	writer.Header().Set(common.CorrelationHeader, correlationID)
+	writer.Header().Set(common.ContentType, common.ContentTypeJSON)
+	writer.WriteHeader(statusCode)
+	if err := json.NewEncoder(writer).Encode(response); err != nil {
+		d.lc.Errorf("failed to write the response of %s, error: %s", request.URL.Path, err.Error())
+	}
+}
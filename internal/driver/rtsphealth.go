@@ -0,0 +1,317 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+)
+
+// The methods probing the external RTSP server
+const (
+	// RtspHealthCheckMethodOptions sends an RTSP OPTIONS request, any RTSP response means the server is alive
+	RtspHealthCheckMethodOptions = "options"
+	// RtspHealthCheckMethodTcp only connects to the RTSP port
+	RtspHealthCheckMethodTcp = "tcp"
+)
+
+// rtspHealthConfig configures the health checks of the external RTSP server
+type rtspHealthConfig struct {
+	// Interval is the interval of the periodic checks, which are disabled if it is zero
+	Interval time.Duration
+	Timeout  time.Duration
+	Method   string
+	// ApiUrl is the optional url of an HTTP API of the server, which must answer with a 2xx status
+	ApiUrl string
+	// Tls probes the RTSPS listener of the server in the RTSPS mode
+	Tls bool
+}
+
+func parseRTSPHealthConfig(configs map[string]string) (rtspHealthConfig, error) {
+	config := rtspHealthConfig{
+		Interval: DefaultRtspHealthCheckInterval,
+		Timeout:  DefaultRtspHealthCheckTimeout,
+		Method:   RtspHealthCheckMethodOptions,
+		ApiUrl:   strings.TrimSpace(configs[RtspHealthCheckApiUrl]),
+	}
+	if value := configs[RtspHealthCheckInterval]; value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < 0 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be a duration such as 10s, or 0 to disable the periodic checks",
+				RtspHealthCheckInterval, value)
+		}
+		config.Interval = interval
+	}
+	if value := configs[RtspHealthCheckTimeout]; value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be a positive duration such as 2s", RtspHealthCheckTimeout, value)
+		}
+		config.Timeout = timeout
+	}
+	if value := strings.ToLower(configs[RtspHealthCheckMethod]); value != "" {
+		if value != RtspHealthCheckMethodOptions && value != RtspHealthCheckMethodTcp {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, valid options are \"%s\" and \"%s\"",
+				RtspHealthCheckMethod, configs[RtspHealthCheckMethod], RtspHealthCheckMethodOptions, RtspHealthCheckMethodTcp)
+		}
+		config.Method = value
+	}
+	return config, nil
+}
+
+// RTSPServerHealth is the result of the latest health check of the external RTSP server
+type RTSPServerHealth struct {
+	Address   string     `json:"address"`
+	Available bool       `json:"available"`
+	CheckedAt *time.Time `json:"checkedAt,omitempty"`
+	// Since is when the server has become available or unavailable
+	Since *time.Time `json:"since,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// rtspHealthChecker checks whether the external RTSP server is available
+type rtspHealthChecker struct {
+	lc      logger.LoggingClient
+	address string
+	config  rtspHealthConfig
+	client  *http.Client
+
+	mutex  sync.Mutex
+	health RTSPServerHealth
+	// queued are the devices whose automatic streaming waits for the server to become available
+	queued   []string
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newRTSPHealthChecker(lc logger.LoggingClient, address string, config rtspHealthConfig) *rtspHealthChecker {
+	return &rtspHealthChecker{
+		lc:      lc,
+		address: address,
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		health:  RTSPServerHealth{Address: address},
+		done:    make(chan struct{}),
+	}
+}
+
+// probe checks the server once, and returns why it is unavailable
+func (c *rtspHealthChecker) probe() error {
+	var conn net.Conn
+	var err error
+	if c.config.Tls {
+		// the probe does not send any credentials, so the certificate of the server does not need to be verified
+		dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: c.config.Timeout}, Config: &tls.Config{InsecureSkipVerify: true}} // #nosec G402
+		conn, err = dialer.Dial("tcp", c.address)
+	} else {
+		conn, err = net.DialTimeout("tcp", c.address, c.config.Timeout)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if c.config.Method == RtspHealthCheckMethodOptions {
+		if err := conn.SetDeadline(time.Now().Add(c.config.Timeout)); err != nil {
+			return err
+		}
+		scheme := RtspUriScheme
+		if c.config.Tls {
+			scheme = RtspsUriScheme
+		}
+		request := fmt.Sprintf("OPTIONS %s://%s/ RTSP/1.0\r\nCSeq: 1\r\nUser-Agent: device-usb-camera\r\n\r\n", scheme, c.address)
+		if _, err := conn.Write([]byte(request)); err != nil {
+			return fmt.Errorf("failed to send the OPTIONS request: %w", err)
+		}
+		line, err := textproto.NewReader(bufio.NewReader(conn)).ReadLine()
+		if err != nil {
+			return fmt.Errorf("no response to the OPTIONS request: %w", err)
+		}
+		// any status means that the server is alive, it may require credentials for OPTIONS requests
+		if !strings.HasPrefix(line, "RTSP/1.0 ") {
+			return fmt.Errorf("invalid response to the OPTIONS request: %q", line)
+		}
+	}
+
+	if c.config.ApiUrl != "" {
+		resp, err := c.client.Get(c.config.ApiUrl)
+		if err != nil {
+			return fmt.Errorf("api check failed: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("api check failed: %s returned status %d", c.config.ApiUrl, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// check probes the server and records the result, it returns whether the server has become available
+func (c *rtspHealthChecker) check() (RTSPServerHealth, bool) {
+	err := c.probe()
+	now := time.Now()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	changed := c.health.CheckedAt == nil || c.health.Available != (err == nil)
+	c.health.Available = err == nil
+	c.health.CheckedAt = &now
+	c.health.Error = ""
+	if err != nil {
+		c.health.Error = err.Error()
+	}
+	if changed {
+		c.health.Since = &now
+		if err != nil {
+			c.lc.Warnf("The external rtsp server at %s is unavailable: %s", c.address, err.Error())
+		} else {
+			c.lc.Infof("The external rtsp server at %s is available", c.address)
+		}
+	}
+	return c.health, changed && err == nil
+}
+
+// status returns the result of the latest check
+func (c *rtspHealthChecker) status() RTSPServerHealth {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.health
+}
+
+// ensureAvailable returns the error of the server unless it is available. The server is probed again
+// if the latest check has failed, so that the streaming can start as soon as the server is back.
+func (c *rtspHealthChecker) ensureAvailable() error {
+	health := c.status()
+	if !health.Available {
+		health, _ = c.check()
+	}
+	if !health.Available {
+		return fmt.Errorf("the external rtsp server at %s is unavailable: %s", c.address, health.Error)
+	}
+	return nil
+}
+
+// queue queues the automatic streaming of the device until the server becomes available
+func (c *rtspHealthChecker) queue(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !slices.Contains(c.queued, name) {
+		c.queued = append(c.queued, name)
+	}
+}
+
+// takeQueued returns and clears the devices queued for automatic streaming
+func (c *rtspHealthChecker) takeQueued() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	queued := c.queued
+	c.queued = nil
+	return queued
+}
+
+// run checks the server periodically until stopped, and calls onAvailable whenever the server becomes available
+func (c *rtspHealthChecker) run(onAvailable func()) {
+	if c.config.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if _, becameAvailable := c.check(); becameAvailable {
+				onAvailable()
+			}
+		}
+	}
+}
+
+func (c *rtspHealthChecker) stop() {
+	c.stopOnce.Do(func() { close(c.done) })
+}
+
+func (c *rtspHealthChecker) stopped() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// startRTSPHealthChecks checks the external RTSP server at startup, and then periodically in the background
+func (d *Driver) startRTSPHealthChecks() {
+	d.rtspHealth.check()
+	// the checks are not tracked by the wait group, since Stop waits for it while holding the driver mutex
+	go d.rtspHealth.run(d.startQueuedStreaming)
+}
+
+// rtspServerHealth returns the result of the latest health check of the external RTSP server,
+// or nil if the RTSP server is not external
+func (d *Driver) rtspServerHealth() *RTSPServerHealth {
+	if d.rtspHealth == nil {
+		return nil
+	}
+	health := d.rtspHealth.status()
+	return &health
+}
+
+// checkRTSPServerAvailable refuses to start the streaming of the device while the external RTSP server
+// is unavailable, since ffmpeg would fail to publish the stream
+func (d *Driver) checkRTSPServerAvailable(device *Device) errors.EdgeX {
+	if d.rtspHealth == nil {
+		return nil
+	}
+	err := d.rtspHealth.ensureAvailable()
+	if err == nil {
+		return nil
+	}
+	device.mutex.Lock()
+	device.streamingStatus.Error = err.Error()
+	device.mutex.Unlock()
+	go d.publishStreamingStatus(device)
+	return errors.NewCommonEdgeX(errors.KindServiceUnavailable,
+		fmt.Sprintf("cannot start streaming for device %s", device.name), err)
+}
+
+// startQueuedStreaming starts the automatic streaming of the devices queued while the external RTSP server
+// was unavailable
+func (d *Driver) startQueuedStreaming() {
+	for _, name := range d.rtspHealth.takeQueued() {
+		device, ok := d.devices.get(name)
+		if !ok || d.rtspHealth.stopped() {
+			continue
+		}
+		unlock := d.devices.lock(name)
+		if device.isStreaming() {
+			unlock()
+			continue
+		}
+		d.lc.Infof("Starting the queued video streaming of device %s", name)
+		edgexErr := d.startStreaming(device)
+		unlock()
+		if edgexErr != nil {
+			d.lc.Errorf("failed to start video streaming for device %s, error: %s", name, edgexErr)
+			if errors.Kind(edgexErr) == errors.KindServiceUnavailable {
+				d.rtspHealth.queue(name)
+			}
+		}
+	}
+}
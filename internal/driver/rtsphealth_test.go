@@ -0,0 +1,162 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startFakeRTSPServer answers every connection with the response line, and returns the address of the server
+func startFakeRTSPServer(t *testing.T, address, responseLine string) string {
+	listener, err := net.Listen("tcp", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == "\r\n" {
+						break
+					}
+				}
+				_, _ = conn.Write([]byte(responseLine + "\r\nCSeq: 1\r\n\r\n"))
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// unusedAddress returns a local address nothing listens on
+func unusedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return address
+}
+
+func TestParseRTSPHealthConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		expected  rtspHealthConfig
+		expectErr bool
+	}{
+		{"defaults", map[string]string{},
+			rtspHealthConfig{Interval: DefaultRtspHealthCheckInterval, Timeout: DefaultRtspHealthCheckTimeout, Method: RtspHealthCheckMethodOptions}, false},
+		{"configured", map[string]string{RtspHealthCheckInterval: "0", RtspHealthCheckTimeout: "500ms", RtspHealthCheckMethod: "TCP",
+			RtspHealthCheckApiUrl: "http://localhost:9997/v3/paths/list"},
+			rtspHealthConfig{Timeout: 500 * time.Millisecond, Method: RtspHealthCheckMethodTcp, ApiUrl: "http://localhost:9997/v3/paths/list"}, false},
+		{"invalid interval", map[string]string{RtspHealthCheckInterval: "often"}, rtspHealthConfig{}, true},
+		{"zero timeout", map[string]string{RtspHealthCheckTimeout: "0s"}, rtspHealthConfig{}, true},
+		{"invalid method", map[string]string{RtspHealthCheckMethod: "ping"}, rtspHealthConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseRTSPHealthConfig(tt.configs)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
+
+func TestRTSPHealthCheckerProbe(t *testing.T) {
+	rtspServer := startFakeRTSPServer(t, "127.0.0.1:0", "RTSP/1.0 401 Unauthorized")
+	httpServer := startFakeRTSPServer(t, "127.0.0.1:0", "HTTP/1.1 400 Bad Request")
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		name      string
+		address   string
+		method    string
+		apiUrl    string
+		expectErr bool
+	}{
+		{"options", rtspServer, RtspHealthCheckMethodOptions, "", false},
+		{"not rtsp", httpServer, RtspHealthCheckMethodOptions, "", true},
+		{"tcp", httpServer, RtspHealthCheckMethodTcp, "", false},
+		{"down", unusedAddress(t), RtspHealthCheckMethodTcp, "", true},
+		{"api", rtspServer, RtspHealthCheckMethodOptions, api.URL + "/ok", false},
+		{"api failure", rtspServer, RtspHealthCheckMethodOptions, api.URL + "/failure", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := newRTSPHealthChecker(logger.MockLogger{}, tt.address,
+				rtspHealthConfig{Timeout: time.Second, Method: tt.method, ApiUrl: tt.apiUrl})
+			health, becameAvailable := checker.check()
+			assert.Equal(t, tt.address, health.Address)
+			assert.Equal(t, !tt.expectErr, health.Available)
+			assert.Equal(t, !tt.expectErr, becameAvailable)
+			assert.NotNil(t, health.CheckedAt)
+			if tt.expectErr {
+				assert.NotEmpty(t, health.Error)
+			} else {
+				assert.Empty(t, health.Error)
+			}
+		})
+	}
+}
+
+func TestStartStreamingWithUnavailableRTSPServer(t *testing.T) {
+	installFakeFFmpeg(t, "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspServerMode = RTSPServerModeExternal
+	address := unusedAddress(t)
+	d.rtspHealth = newRTSPHealthChecker(d.lc, address,
+		rtspHealthConfig{Interval: 10 * time.Millisecond, Timeout: time.Second, Method: RtspHealthCheckMethodOptions})
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	edgexErr := d.startStreaming(dev)
+	require.Error(t, edgexErr)
+	assert.Equal(t, errors.KindServiceUnavailable, errors.Kind(edgexErr))
+	assert.Contains(t, edgexErr.Error(), "the external rtsp server at "+address+" is unavailable")
+	status := nextStreamingStatus(t, asyncCh)
+	assert.False(t, status.IsStreaming)
+	assert.Contains(t, status.Error, "is unavailable")
+	require.NotNil(t, status.RtspServer)
+	assert.False(t, status.RtspServer.Available)
+
+	// the queued streaming starts once the server is back
+	d.rtspHealth.queue(dev.name)
+	startFakeRTSPServer(t, address, "RTSP/1.0 200 OK")
+	go d.rtspHealth.run(d.startQueuedStreaming)
+	defer d.rtspHealth.stop()
+	status = nextStreamingStatus(t, asyncCh)
+	assert.True(t, status.IsStreaming)
+	require.NotNil(t, status.RtspServer)
+	assert.True(t, status.RtspServer.Available)
+	assert.Empty(t, d.rtspHealth.takeQueued())
+
+	dev.StopStreaming()
+	nextStreamingStatus(t, asyncCh)
+}
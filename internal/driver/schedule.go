@@ -0,0 +1,504 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/spf13/cast"
+)
+
+const (
+	// StreamingSchedule is the protocol property of the weekday/time windows in which the device streams,
+	// e.g. "Mon-Fri 09:00-18:00; Sat,Sun 10:00-16:00". A window ending before it starts ends the next day.
+	StreamingSchedule = "StreamingSchedule"
+	// StreamingScheduleStart and StreamingScheduleStop are the protocol properties of the cron expressions
+	// starting and stopping the streaming of the device, e.g. "0 9 * * 1-5" and "0 18 * * 1-5"
+	StreamingScheduleStart = "StreamingScheduleStart"
+	StreamingScheduleStop  = "StreamingScheduleStop"
+	// StreamingScheduleTimezone is the protocol property of the IANA time zone of the schedule, such as
+	// "Europe/London", the schedule uses the local time of the service otherwise
+	StreamingScheduleTimezone = "StreamingScheduleTimezone"
+
+	// the actions of the schedules, reported as NextAction in the StreamingStatus
+	scheduleActionStart = "start"
+	scheduleActionStop  = "stop"
+
+	// streamingScheduleInterval is how often the schedules of the devices are applied
+	streamingScheduleInterval = 15 * time.Second
+	// cronSearchLimit is how far the cron expressions are searched for their next or previous occurrence
+	cronSearchLimit = 5
+)
+
+// streamingSchedule tells when the streaming of a device runs
+type streamingSchedule interface {
+	// at returns whether the streaming runs at the time, and when it is next started or stopped. The next
+	// transition is zero if the schedule never changes.
+	at(now time.Time) (inWindow bool, next time.Time)
+}
+
+// parseStreamingSchedule parses the schedule set by the protocol properties, it returns nil if there is none
+func parseStreamingSchedule(protocols map[string]models.ProtocolProperties) (streamingSchedule, error) {
+	property := func(key string) string {
+		return strings.TrimSpace(cast.ToString(protocols[UsbProtocol][key]))
+	}
+	windows, start, stop := property(StreamingSchedule), property(StreamingScheduleStart), property(StreamingScheduleStop)
+	if windows == "" && start == "" && stop == "" {
+		return nil, nil
+	}
+
+	location := time.Local
+	if timezone := property(StreamingScheduleTimezone); timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", StreamingScheduleTimezone, timezone, err)
+		}
+	}
+
+	switch {
+	case windows != "" && (start != "" || stop != ""):
+		return nil, fmt.Errorf("%s cannot be used together with %s and %s", StreamingSchedule, StreamingScheduleStart, StreamingScheduleStop)
+	case windows != "":
+		return parseWindowSchedule(windows, location)
+	case start == "" || stop == "":
+		return nil, fmt.Errorf("%s and %s must be set together", StreamingScheduleStart, StreamingScheduleStop)
+	}
+	startExpr, err := parseCronExpression(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", StreamingScheduleStart, err)
+	}
+	stopExpr, err := parseCronExpression(stop)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", StreamingScheduleStop, err)
+	}
+	return cronSchedule{start: startExpr, stop: stopExpr, location: location}, nil
+}
+
+var weekdayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+
+var monthNames = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+
+// scheduleWindow is a time window repeated on some weekdays, start and end are minutes of the day
+type scheduleWindow struct {
+	days       [7]bool
+	start, end int
+}
+
+// windowSchedule streams during any of its windows
+type windowSchedule struct {
+	windows  []scheduleWindow
+	location *time.Location
+}
+
+// parseWindowSchedule parses windows separated by semicolons, each made of the weekdays, such as "Mon-Fri",
+// "Sat,Sun" or "*", followed by the time range such as "09:00-18:00"
+func parseWindowSchedule(value string, location *time.Location) (windowSchedule, error) {
+	schedule := windowSchedule{location: location}
+	for _, entry := range strings.Split(value, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return schedule, fmt.Errorf("invalid window %q, it must be the weekdays followed by the time range, e.g. \"Mon-Fri 09:00-18:00\"",
+				strings.TrimSpace(entry))
+		}
+		var window scheduleWindow
+		days, err := parseCronField(fields[0], 0, 6, weekdayNames)
+		if err != nil {
+			return schedule, fmt.Errorf("invalid weekdays in window %q: %w", strings.TrimSpace(entry), err)
+		}
+		for day := range window.days {
+			window.days[day] = days.has(day)
+		}
+		startValue, endValue, ok := strings.Cut(fields[1], "-")
+		if !ok {
+			return schedule, fmt.Errorf("invalid time range %q, it must be such as 09:00-18:00", fields[1])
+		}
+		if window.start, err = parseTimeOfDay(startValue); err != nil || window.start == 24*60 {
+			return schedule, fmt.Errorf("invalid start time %q, it must be such as 09:00", startValue)
+		}
+		if window.end, err = parseTimeOfDay(endValue); err != nil {
+			return schedule, fmt.Errorf("invalid end time %q, it must be such as 18:00", endValue)
+		}
+		schedule.windows = append(schedule.windows, window)
+	}
+	if len(schedule.windows) == 0 {
+		return schedule, fmt.Errorf("%s has no window", StreamingSchedule)
+	}
+	return schedule, nil
+}
+
+// parseTimeOfDay parses a time such as 09:30 into minutes of the day, 24:00 is the end of the day
+func parseTimeOfDay(value string) (int, error) {
+	hours, minutes, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, fmt.Errorf("missing minutes")
+	}
+	h, err := strconv.Atoi(hours)
+	if err != nil {
+		return 0, err
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil {
+		return 0, err
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("out of range")
+	}
+	return h*60 + m, nil
+}
+
+// bounds returns when the window starting on the day of the date starts and ends, the windows whose end is not
+// after their start end the next day
+func (w scheduleWindow) bounds(date time.Time) (time.Time, time.Time) {
+	y, m, d := date.Date()
+	start := time.Date(y, m, d, 0, w.start, 0, 0, date.Location())
+	if w.end > w.start {
+		return start, time.Date(y, m, d, 0, w.end, 0, 0, date.Location())
+	}
+	return start, time.Date(y, m, d+1, 0, w.end, 0, 0, date.Location())
+}
+
+// contains returns whether the time is within the window, which may have started the previous day
+func (w scheduleWindow) contains(t time.Time) bool {
+	for _, date := range []time.Time{t, t.AddDate(0, 0, -1)} {
+		if !w.days[date.Weekday()] {
+			continue
+		}
+		start, end := w.bounds(date)
+		if !t.Before(start) && t.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s windowSchedule) contains(t time.Time) bool {
+	for _, w := range s.windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s windowSchedule) at(now time.Time) (bool, time.Time) {
+	now = now.In(s.location)
+	inWindow := s.contains(now)
+
+	// the state only changes at the bounds of the windows, which repeat every week
+	var bounds []time.Time
+	for offset := -1; offset <= 8; offset++ {
+		date := now.AddDate(0, 0, offset)
+		for _, w := range s.windows {
+			if !w.days[date.Weekday()] {
+				continue
+			}
+			start, end := w.bounds(date)
+			bounds = append(bounds, start, end)
+		}
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i].Before(bounds[j]) })
+	for _, bound := range bounds {
+		if bound.After(now) && s.contains(bound) != inWindow {
+			return inWindow, bound
+		}
+	}
+	return inWindow, time.Time{}
+}
+
+// cronSchedule starts the streaming whenever the start expression fires, and stops it whenever the stop
+// expression fires. The stop expression wins if both fire at the same minute.
+type cronSchedule struct {
+	start, stop cronExpression
+	location    *time.Location
+}
+
+func (s cronSchedule) at(now time.Time) (bool, time.Time) {
+	now = now.In(s.location)
+	lastStart, lastStop := s.start.prev(now), s.stop.prev(now)
+	inWindow := !lastStart.IsZero() && lastStart.After(lastStop)
+	if inWindow {
+		return true, s.stop.next(now)
+	}
+	return false, s.start.next(now)
+}
+
+// cronField is the set of values matched by a field of a cron expression
+type cronField uint64
+
+func (f cronField) has(value int) bool {
+	return f&(1<<uint(value)) != 0
+}
+
+// cronExpression is a standard cron expression made of the minute, hour, day of month, month and day of week
+// fields. The day of week and the month accept names such as Mon and Jan.
+type cronExpression struct {
+	minute, hour, dom, month, dow cronField
+	// the day matches either the day of month or the day of week if both are restricted
+	domAny, dowAny bool
+}
+
+func parseCronExpression(value string) (cronExpression, error) {
+	var expr cronExpression
+	fields := strings.Fields(value)
+	if len(fields) != 5 {
+		return expr, fmt.Errorf("%q must have the 5 fields minute, hour, day of month, month and day of week", value)
+	}
+	var err error
+	if expr.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return expr, fmt.Errorf("invalid minute: %w", err)
+	}
+	if expr.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return expr, fmt.Errorf("invalid hour: %w", err)
+	}
+	if expr.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return expr, fmt.Errorf("invalid day of month: %w", err)
+	}
+	if expr.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return expr, fmt.Errorf("invalid month: %w", err)
+	}
+	// 7 is Sunday as well
+	if expr.dow, err = parseCronField(fields[4], 0, 7, weekdayNames); err != nil {
+		return expr, fmt.Errorf("invalid day of week: %w", err)
+	}
+	if expr.dow.has(7) {
+		expr.dow |= 1
+	}
+	expr.domAny, expr.dowAny = fields[2] == "*", fields[4] == "*"
+	return expr, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges such as 1-5, and steps such as */15 or 8-18/2
+func parseCronField(value string, min, max int, names map[string]int) (cronField, error) {
+	parse := func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < min || n > max {
+			return 0, fmt.Errorf("%q is not a value between %d and %d", s, min, max)
+		}
+		return n, nil
+	}
+
+	var field cronField
+	for _, part := range strings.Split(value, ",") {
+		rangeValue, stepValue, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepValue); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepValue)
+			}
+		}
+		first, last := min, max
+		if rangeValue != "*" {
+			firstValue, lastValue, isRange := strings.Cut(rangeValue, "-")
+			var err error
+			if first, err = parse(firstValue); err != nil {
+				return 0, err
+			}
+			last = first
+			if isRange {
+				if last, err = parse(lastValue); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				last = max
+			}
+			if last < first {
+				return 0, fmt.Errorf("invalid range %q", rangeValue)
+			}
+		}
+		for n := first; n <= last; n += step {
+			field |= 1 << uint(n)
+		}
+	}
+	return field, nil
+}
+
+func (e cronExpression) dayMatches(t time.Time) bool {
+	if !e.month.has(int(t.Month())) {
+		return false
+	}
+	dom, dow := e.dom.has(t.Day()), e.dow.has(int(t.Weekday()))
+	switch {
+	case e.domAny:
+		return dow
+	case e.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// next returns the first minute after the time matching the expression, or zero if there is none
+func (e cronExpression) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(cronSearchLimit, 0, 0); t.Before(limit); {
+		y, m, d := t.Date()
+		switch {
+		case !e.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+		case !e.hour.has(t.Hour()):
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, t.Location())
+		case !e.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// prev returns the last minute until the time matching the expression, or zero if there is none
+func (e cronExpression) prev(until time.Time) time.Time {
+	t := until.Truncate(time.Minute)
+	for limit := t.AddDate(-cronSearchLimit, 0, 0); t.After(limit); {
+		y, m, d := t.Date()
+		switch {
+		case !e.dayMatches(t):
+			t = time.Date(y, m, d, 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case !e.hour.has(t.Hour()):
+			t = time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location()).Add(-time.Minute)
+		case !e.minute.has(t.Minute()):
+			t = t.Add(-time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// deviceStreamingSchedule returns the streaming schedule of the device, or nil if it has none
+func deviceStreamingSchedule(name string, protocols map[string]models.ProtocolProperties) (streamingSchedule, errors.EdgeX) {
+	schedule, err := parseStreamingSchedule(protocols)
+	if err != nil {
+		return nil, errors.NewCommonEdgeX(errors.KindContractInvalid,
+			fmt.Sprintf("invalid streaming schedule of device %s", name), err)
+	}
+	return schedule, nil
+}
+
+// scheduleStatus returns the state of the streaming schedule of the device, or nil if it has none
+func scheduleStatus(schedule streamingSchedule, now time.Time) *StreamingScheduleStatus {
+	if schedule == nil {
+		return nil
+	}
+	inWindow, next := schedule.at(now)
+	status := &StreamingScheduleStatus{InWindow: inWindow}
+	if !next.IsZero() {
+		status.NextTransition = &next
+		status.NextAction = scheduleActionStart
+		if inWindow {
+			status.NextAction = scheduleActionStop
+		}
+	}
+	return status
+}
+
+// startStreamingSchedules applies the streaming schedules of the devices now, and then periodically in the
+// background until Stop
+func (d *Driver) startStreamingSchedules() {
+	done := make(chan struct{})
+	d.scheduleDone = done
+	go func() {
+		ticker := time.NewTicker(streamingScheduleInterval)
+		defer ticker.Stop()
+		for {
+			d.applyStreamingSchedules(done, time.Now())
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// applyStreamingSchedules starts or stops the streaming of the devices whose schedule has changed
+func (d *Driver) applyStreamingSchedules(done <-chan struct{}, now time.Time) {
+	for _, device := range d.devices.list() {
+		if device.schedule == nil {
+			continue
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+		unlock := d.devices.lock(device.name)
+		d.applyStreamingSchedule(device, now)
+		unlock()
+	}
+}
+
+// applyStreamingSchedule starts or stops the streaming of the device when its schedule enters or leaves a
+// window, so that the streaming started or stopped by a command stays so until the next transition. A start
+// which fails is retried the next time the schedule is applied. The caller must hold the lifecycle lock of
+// the device.
+func (d *Driver) applyStreamingSchedule(device *Device, now time.Time) {
+	inWindow, _ := device.schedule.at(now)
+	device.mutex.Lock()
+	unchanged := device.scheduleApplied && device.scheduleInWindow == inWindow
+	isStreaming := device.streamingStatus.IsStreaming
+	device.mutex.Unlock()
+	if unchanged {
+		return
+	}
+
+	switch {
+	case inWindow && !isStreaming:
+		d.lc.Infof("Starting the scheduled video streaming of device %s", device.name)
+		if edgexErr := d.startDefaultStreaming(device); edgexErr != nil {
+			d.lc.Errorf("failed to start the scheduled video streaming for device %s, error: %s", device.name, edgexErr)
+			return
+		}
+	case !inWindow && isStreaming:
+		d.lc.Infof("Stopping the scheduled video streaming of device %s", device.name)
+		device.StopStreaming()
+	}
+
+	device.mutex.Lock()
+	device.scheduleApplied = true
+	device.scheduleInWindow = inWindow
+	device.mutex.Unlock()
+}
+
+// startDefaultStreaming starts the streaming with the options last used for the device, or with the default
+// options of its profile if it has never been started with options. The input path is the first path of the
+// device unless a start command has set another one.
+func (d *Driver) startDefaultStreaming(device *Device) errors.EdgeX {
+	device.mutex.Lock()
+	configured := device.optionsConfigured
+	path := device.streamingStatus.TranscoderInputPath
+	device.mutex.Unlock()
+	if path == "" {
+		path = device.paths[0]
+		device.setTranscoderInputPath(path)
+	}
+	if !configured && len(device.streamingDefaults) > 0 {
+		caps, err := getPathInputCapabilities(path)
+		if err != nil {
+			// as for the start command, the streaming is not blocked and ffmpeg reports any problems instead
+			d.lc.Warnf("Unable to enumerate the input formats of device %s, input options will not be validated: %v", device.name, err)
+		}
+		if edgexErr := setupFFmpegOptions(device, map[string]interface{}{}, device.streamingDefaults, caps); edgexErr != nil {
+			return errors.NewCommonEdgeXWrapper(edgexErr)
+		}
+	}
+	return d.startStreaming(device)
+}
@@ -0,0 +1,250 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
+
+func scheduleProtocols(properties map[string]any) map[string]models.ProtocolProperties {
+	return map[string]models.ProtocolProperties{UsbProtocol: properties}
+}
+
+// utcTime returns the time of the day in October 2026, the 19th is a Monday
+func utcTime(day, hour, minute int) time.Time {
+	return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseStreamingSchedule(t *testing.T) {
+	tests := []struct {
+		name        string
+		properties  map[string]any
+		expectNil   bool
+		errorSubstr string
+	}{
+		{"none", map[string]any{}, true, ""},
+		{"empty", map[string]any{StreamingSchedule: "", StreamingScheduleTimezone: "UTC"}, true, ""},
+		{"windows", map[string]any{StreamingSchedule: "Mon-Fri 09:00-18:00; sat,SUN 10:00-16:00"}, false, ""},
+		{"every day", map[string]any{StreamingSchedule: "* 00:00-24:00"}, false, ""},
+		{"cron", map[string]any{StreamingScheduleStart: "0 9 * * MON-FRI", StreamingScheduleStop: "30 18 * * 1-5"}, false, ""},
+		{"timezone", map[string]any{StreamingSchedule: "Mon 09:00-18:00", StreamingScheduleTimezone: "Europe/London"}, false, ""},
+		{"invalid timezone", map[string]any{StreamingSchedule: "Mon 09:00-18:00", StreamingScheduleTimezone: "Mars/Olympus"}, true, StreamingScheduleTimezone},
+		{"both kinds", map[string]any{StreamingSchedule: "Mon 09:00-18:00", StreamingScheduleStart: "0 9 * * *"}, true, "cannot be used together"},
+		{"start without stop", map[string]any{StreamingScheduleStart: "0 9 * * *"}, true, "must be set together"},
+		{"missing time range", map[string]any{StreamingSchedule: "Mon-Fri"}, true, "invalid window"},
+		{"invalid weekday", map[string]any{StreamingSchedule: "Mon-Funday 09:00-18:00"}, true, "invalid weekdays"},
+		{"invalid start time", map[string]any{StreamingSchedule: "Mon 9-18:00"}, true, "invalid start time"},
+		{"start at the end of the day", map[string]any{StreamingSchedule: "Mon 24:00-06:00"}, true, "invalid start time"},
+		{"invalid end time", map[string]any{StreamingSchedule: "Mon 09:00-18:60"}, true, "invalid end time"},
+		{"no window", map[string]any{StreamingSchedule: " ; "}, true, "has no window"},
+		{"cron with 6 fields", map[string]any{StreamingScheduleStart: "0 0 9 * * *", StreamingScheduleStop: "0 18 * * *"}, true, "5 fields"},
+		{"cron value out of range", map[string]any{StreamingScheduleStart: "0 24 * * *", StreamingScheduleStop: "0 18 * * *"}, true, "invalid hour"},
+		{"cron invalid step", map[string]any{StreamingScheduleStart: "*/0 9 * * *", StreamingScheduleStop: "0 18 * * *"}, true, "invalid step"},
+		{"cron reversed range", map[string]any{StreamingScheduleStart: "0 9 * * *", StreamingScheduleStop: "0 18 * * 5-1"}, true, "invalid range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseStreamingSchedule(scheduleProtocols(tt.properties))
+			if tt.errorSubstr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorSubstr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectNil, schedule == nil)
+		})
+	}
+}
+
+func TestWindowSchedule(t *testing.T) {
+	schedule, err := parseStreamingSchedule(scheduleProtocols(map[string]any{
+		StreamingSchedule:         "Mon-Fri 09:00-18:00; Sat 22:00-02:00",
+		StreamingScheduleTimezone: "UTC",
+	}))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		expectWindow bool
+		expectNext   time.Time
+	}{
+		{"before the window", utcTime(19, 8, 59), false, utcTime(19, 9, 0)},
+		{"start of the window", utcTime(19, 9, 0), true, utcTime(19, 18, 0)},
+		{"end of the window", utcTime(19, 18, 0), false, utcTime(20, 9, 0)},
+		{"friday evening", utcTime(23, 20, 0), false, utcTime(24, 22, 0)},
+		{"window crossing midnight", utcTime(24, 23, 0), true, utcTime(25, 2, 0)},
+		{"after midnight", utcTime(25, 1, 0), true, utcTime(25, 2, 0)},
+		{"sunday", utcTime(25, 12, 0), false, utcTime(26, 9, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inWindow, next := schedule.at(tt.now)
+			assert.Equal(t, tt.expectWindow, inWindow)
+			assert.True(t, tt.expectNext.Equal(next), "expected %s, got %s", tt.expectNext, next)
+		})
+	}
+}
+
+func TestWindowScheduleAdjacentWindows(t *testing.T) {
+	schedule, err := parseWindowSchedule("* 00:00-12:00; * 12:00-24:00", time.UTC)
+	require.NoError(t, err)
+	inWindow, next := schedule.at(utcTime(19, 11, 0))
+	assert.True(t, inWindow)
+	assert.True(t, next.IsZero(), "a schedule always in a window has no transition")
+}
+
+func TestWindowScheduleTimezone(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	schedule, err := parseWindowSchedule("Mon-Fri 09:00-18:00", location)
+	require.NoError(t, err)
+
+	// 13:30 UTC is 09:30 in New York during the daylight saving time
+	inWindow, next := schedule.at(utcTime(19, 13, 30))
+	assert.True(t, inWindow)
+	assert.True(t, utcTime(19, 22, 0).Equal(next), "got %s", next)
+}
+
+func TestCronExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		now        time.Time
+		expectNext time.Time
+		expectPrev time.Time
+	}{
+		{"daily", "0 9 * * *", utcTime(19, 9, 0), utcTime(20, 9, 0), utcTime(19, 9, 0)},
+		{"steps", "*/15 8-18/2 * * *", utcTime(19, 9, 20), utcTime(19, 10, 0), utcTime(19, 8, 45)},
+		{"weekdays", "30 18 * * mon-fri", utcTime(23, 19, 0), utcTime(26, 18, 30), utcTime(23, 18, 30)},
+		{"sunday as 7", "0 0 * * 7", utcTime(19, 0, 0), utcTime(25, 0, 0), utcTime(18, 0, 0)},
+		{"day of month or day of week", "0 12 1 * sat", utcTime(19, 0, 0), utcTime(24, 12, 0), utcTime(17, 12, 0)},
+		{"month names", "0 0 1 jan,jul *", utcTime(19, 0, 0), time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 31 2 *", utcTime(19, 0, 0), time.Time{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseCronExpression(tt.expression)
+			require.NoError(t, err)
+			assert.True(t, tt.expectNext.Equal(expr.next(tt.now)), "next: expected %s, got %s", tt.expectNext, expr.next(tt.now))
+			assert.True(t, tt.expectPrev.Equal(expr.prev(tt.now)), "prev: expected %s, got %s", tt.expectPrev, expr.prev(tt.now))
+		})
+	}
+}
+
+func TestCronSchedule(t *testing.T) {
+	schedule, err := parseStreamingSchedule(scheduleProtocols(map[string]any{
+		StreamingScheduleStart:    "0 9 * * 1-5",
+		StreamingScheduleStop:     "0 18 * * 1-5",
+		StreamingScheduleTimezone: "UTC",
+	}))
+	require.NoError(t, err)
+
+	inWindow, next := schedule.at(utcTime(19, 12, 0))
+	assert.True(t, inWindow)
+	assert.True(t, utcTime(19, 18, 0).Equal(next), "got %s", next)
+
+	inWindow, next = schedule.at(utcTime(24, 12, 0))
+	assert.False(t, inWindow)
+	assert.True(t, utcTime(26, 9, 0).Equal(next), "got %s", next)
+
+	status := scheduleStatus(schedule, utcTime(19, 12, 0))
+	require.NotNil(t, status)
+	assert.True(t, status.InWindow)
+	assert.Equal(t, scheduleActionStop, status.NextAction)
+	assert.Nil(t, scheduleStatus(nil, utcTime(19, 12, 0)))
+}
+
+func TestApplyStreamingSchedule(t *testing.T) {
+	argsFile := installFakeFFmpeg(t, "auth", "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	startFakeRTSPAuthServer(t, d)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.schedule, _ = parseWindowSchedule("Mon-Fri 09:00-18:00", time.UTC)
+	dev.streamingDefaults = map[string]interface{}{SetFunction: VideoStartStreaming, "defaultOutputFps": "10"}
+
+	d.applyStreamingSchedules(nil, utcTime(19, 8, 0))
+	assert.False(t, dev.isStreaming())
+
+	d.applyStreamingSchedules(nil, utcTime(19, 9, 0))
+	assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	assert.Contains(t, readFakeFFmpegArgs(t, argsFile), "10", "the default options of the profile are used")
+	assert.Equal(t, "10", dev.status().OutputFps)
+
+	// the streaming stopped by a command stays stopped until the next transition of the schedule
+	dev.StopStreaming()
+	assert.False(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	d.applyStreamingSchedules(nil, utcTime(19, 10, 0))
+	assert.False(t, dev.isStreaming())
+
+	// the streaming started by a command is stopped when the window ends
+	require.NoError(t, d.startStreaming(dev))
+	assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	d.applyStreamingSchedules(nil, utcTime(19, 18, 0))
+	assert.False(t, dev.isStreaming())
+	assert.False(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+
+	status := d.streamingStatus(dev)
+	require.NotNil(t, status.Schedule)
+	require.NoError(t, d.Stop(false))
+}
+
+func TestApplyStreamingScheduleSetsInputPath(t *testing.T) {
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	var capturePaths []string
+	original := currentCaptureFormat
+	currentCaptureFormat = func(path string) (v4l2.PixFormat, v4l2.Fract, error) {
+		capturePaths = append(capturePaths, path)
+		return v4l2.PixFormat{PixelFormat: v4l2.PixelFmtMJPEG, Width: 640, Height: 480}, v4l2.Fract{Numerator: 30, Denominator: 1}, nil
+	}
+	t.Cleanup(func() { currentCaptureFormat = original })
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	startFakeRTSPAuthServer(t, d)
+	d.admissionConfig = admissionConfig{BandwidthBudgetPercent: DefaultUsbBandwidthBudgetPercent}
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.busPath = "1-1"
+	dev.schedule, _ = parseWindowSchedule("* 00:00-24:00", time.UTC)
+	// newDevice only sets the input path of the devices streaming automatically
+	dev.streamingStatus.TranscoderInputPath = ""
+
+	d.applyStreamingSchedules(nil, utcTime(19, 9, 0))
+	status := nextStreamingStatus(t, asyncCh)
+	assert.True(t, status.IsStreaming)
+	assert.Equal(t, "/dev/video0", status.TranscoderInputPath, "the first path of the device is streamed")
+	assert.Equal(t, []string{"/dev/video0"}, capturePaths, "the bandwidth is estimated from the capture format of the input path")
+	require.NotNil(t, dev.streamBandwidth())
+
+	dev.StopStreaming()
+	require.NoError(t, d.Stop(false))
+}
+
+func TestApplyStreamingScheduleRetriesFailedStart(t *testing.T) {
+	installFakeFFmpeg(t, "error camera unplugged", "exit 1")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	startFakeRTSPAuthServer(t, d)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.schedule, _ = parseWindowSchedule("* 00:00-24:00", time.UTC)
+
+	d.applyStreamingSchedules(nil, utcTime(19, 9, 0))
+	assert.False(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	assert.False(t, dev.scheduleApplied, "the failed start is retried")
+
+	installFakeFFmpeg(t, "auth", "progress", "wait")
+	d.applyStreamingSchedules(nil, utcTime(19, 9, 0))
+	assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	assert.True(t, dev.scheduleApplied)
+
+	dev.StopStreaming()
+	require.NoError(t, d.Stop(false))
+}
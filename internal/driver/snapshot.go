@@ -0,0 +1,433 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	SnapshotFormatJPEG = "jpeg"
+	SnapshotFormatPNG  = "png"
+
+	// snapshotStreamFps is the rate at which the transcoder writes the snapshots of the stream
+	snapshotStreamFps = "1"
+	// snapshotStreamQScale is the quality of the snapshots of the stream, from 2 (best) to 31 (worst)
+	snapshotStreamQScale = "3"
+	// snapshotMaxAge is how long a snapshot captured from an idle camera is reused for the following requests
+	snapshotMaxAge = time.Second
+	// snapshotTimeout is how long to wait for the first frame of a capture or a stream
+	snapshotTimeout = 5 * time.Second
+	// maxSnapshotFrameSize is the maximum size of a JPEG frame written by the transcoder
+	maxSnapshotFrameSize = 16 * 1024 * 1024
+	// maxSnapshotDimension is the maximum width and height of the snapshots
+	maxSnapshotDimension = 7680
+)
+
+// snapshotFrame is the latest JPEG frame captured from a path of a camera
+type snapshotFrame struct {
+	data       []byte
+	path       string
+	capturedAt time.Time
+}
+
+// snapshotOptions are the options of the snapshot requested by the client
+type snapshotOptions struct {
+	width   int
+	height  int
+	format  string
+	quality int
+	// reencode is set when the frame cannot be returned as it is
+	reencode bool
+}
+
+// insertSnapshotOutput adds an output to the ffmpeg command which writes JPEG frames of the input to the file
+// descriptor 3, i.e. the first extra file of the process. The output is inserted right after the input so that
+// the options of the rtsp output keep applying to the rtsp output only, the overlay filter of the rtsp output if
+// any is applied to the snapshots as well.
+func insertSnapshotOutput(command []string, overlayFilter string) []string {
+	filter := "fps=" + snapshotStreamFps
+	if overlayFilter != "" {
+		filter = overlayFilter + "," + filter
+	}
+	for i := 0; i+1 < len(command); i++ {
+		if command[i] == "-i" {
+			output := []string{"-map", "0:v", "-vf", filter, "-c:v", "mjpeg",
+				"-q:v", snapshotStreamQScale, "-f", "image2pipe", "pipe:3"}
+			return slices.Concat(command[:i+2], output, command[i+2:])
+		}
+	}
+	return command
+}
+
+// readStreamSnapshots keeps the latest frame written by the transcoder to the snapshot pipe. The pipe is drained
+// until the transcoder exits, as ffmpeg would stall or fail if it could not write to it.
+func (dev *Device) readStreamSnapshots(reader io.ReadCloser, path string) {
+	defer reader.Close()
+	scanner := bufio.NewScanner(reader)
+	scanner.Split(scanJPEGFrames)
+	scanner.Buffer(make([]byte, 64*1024), maxSnapshotFrameSize)
+	for scanner.Scan() {
+		frame := bytes.Clone(scanner.Bytes())
+		dev.mutex.Lock()
+		dev.snapshot = snapshotFrame{data: frame, path: path, capturedAt: time.Now()}
+		dev.mutex.Unlock()
+	}
+	if err := scanner.Err(); err != nil {
+		dev.lc.Warnf("stopped reading the snapshots of the stream of device %s, error: %s", dev.name, err.Error())
+		_, _ = io.Copy(io.Discard, reader)
+	}
+}
+
+// scanJPEGFrames is a bufio.SplitFunc which splits concatenated JPEG images, such as the ones written by the ffmpeg
+// image2pipe muxer. The entropy coded data of JPEG images escapes 0xFF bytes, so the end of image marker only
+// appears at the end of an image.
+func scanJPEGFrames(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := bytes.Index(data, []byte{0xFF, 0xD8})
+	if start < 0 {
+		if atEOF {
+			return len(data), nil, nil
+		}
+		// keep the last byte, it may be the first byte of a start of image marker
+		return max(len(data)-1, 0), nil, nil
+	}
+	if end := bytes.Index(data[start+2:], []byte{0xFF, 0xD9}); end >= 0 {
+		end += start + 4
+		return end, data[start:end], nil
+	}
+	if atEOF {
+		// drop the truncated image
+		return len(data), nil, nil
+	}
+	// skip the data before the image, and request more data
+	return start, nil, nil
+}
+
+// streamSnapshot returns the latest frame written by the transcoder of the device if the device is streaming from the
+// specified path
+func (dev *Device) streamSnapshot(path string) (snapshotFrame, bool) {
+	deadline := time.Now().Add(snapshotTimeout)
+	for {
+		dev.mutex.Lock()
+		streaming := dev.streamingStatus.IsStreaming && dev.streamingStatus.TranscoderInputPath == path
+		snapshot := dev.snapshot
+		startedAt := dev.streamStartedAt
+		dev.mutex.Unlock()
+		if !streaming {
+			return snapshotFrame{}, false
+		}
+		if snapshot.data != nil && snapshot.path == path && !snapshot.capturedAt.Before(startedAt) {
+			return snapshot, true
+		}
+		// the stream has just started, wait for its first frame
+		if time.Now().After(deadline) {
+			return snapshotFrame{}, true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// captureSnapshot returns a recent frame from the specified path of the camera. If the camera is streaming from that
+// path, the frame is taken from the stream so that the camera does not need to be opened again: from the snapshot
+// output of the transcoder if StreamSnapshots is enabled, or else read from the published stream. Otherwise, a frame
+// is captured from the camera, sharing the capture of the running previews if any.
+func (d *Driver) captureSnapshot(dev *Device, path string) (snapshotFrame, error) {
+	dev.mutex.Lock()
+	readStream := dev.streamingStatus.IsStreaming && dev.streamingStatus.TranscoderInputPath == path && !dev.snapshotOutput
+	dev.mutex.Unlock()
+	if readStream {
+		return d.readStreamSnapshot(dev, path)
+	}
+	if snapshot, streaming := dev.streamSnapshot(path); streaming {
+		if snapshot.data == nil {
+			return snapshotFrame{}, fmt.Errorf("the stream of the camera has not produced any frame within %s", snapshotTimeout)
+		}
+		return snapshot, nil
+	}
+
+	dev.mutex.Lock()
+	snapshot := dev.snapshot
+	dev.mutex.Unlock()
+	if snapshot.data != nil && snapshot.path == path && time.Since(snapshot.capturedAt) < snapshotMaxAge {
+		return snapshot, nil
+	}
+
+	frames, unsubscribe, err := dev.subscribePreview(path, frameCaptureConfig{
+		MaxWidth:    d.previewConfig.MaxWidth,
+		MaxHeight:   d.previewConfig.MaxHeight,
+		JpegQuality: d.previewConfig.JpegQuality,
+	}, true)
+	if err != nil {
+		return snapshotFrame{}, err
+	}
+	defer unsubscribe()
+
+	select {
+	case frame, ok := <-frames:
+		if !ok {
+			return snapshotFrame{}, fmt.Errorf("the capture has stopped before producing any frame")
+		}
+		snapshot = snapshotFrame{data: frame, path: path, capturedAt: time.Now()}
+	case <-time.After(snapshotTimeout):
+		return snapshotFrame{}, fmt.Errorf("the capture has not produced any frame within %s", snapshotTimeout)
+	}
+	dev.mutex.Lock()
+	dev.snapshot = snapshot
+	dev.mutex.Unlock()
+	return snapshot, nil
+}
+
+// readStreamSnapshot reads a frame of the stream of the device from the RTSP server, the frame is reused by the
+// requests of the following snapshotMaxAge
+func (d *Driver) readStreamSnapshot(dev *Device, path string) (snapshotFrame, error) {
+	dev.snapshotMutex.Lock()
+	defer dev.snapshotMutex.Unlock()
+	dev.mutex.Lock()
+	snapshot := dev.snapshot
+	startedAt := dev.streamStartedAt
+	dev.mutex.Unlock()
+	if snapshot.data != nil && snapshot.path == path && !snapshot.capturedAt.Before(startedAt) &&
+		time.Since(snapshot.capturedAt) < snapshotMaxAge {
+		return snapshot, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+	command := []string{"-loglevel", "error", "-rtsp_transport", "tcp", "-i", d.getAuthenticatedRTSPUri(dev.name),
+		"-frames:v", "1", "-c:v", "mjpeg", "-q:v", snapshotStreamQScale, "-f", "image2pipe", "pipe:1"}
+	proc := exec.CommandContext(ctx, dev.transcoder.FFmpegExec(), command...)
+	var stderr bytes.Buffer
+	proc.Stderr = &stderr
+	frame, err := proc.Output()
+	if ctx.Err() != nil {
+		return snapshotFrame{}, fmt.Errorf("the stream of the camera has not produced any frame within %s", snapshotTimeout)
+	}
+	if err != nil {
+		return snapshotFrame{}, fmt.Errorf("failed to read a frame of the stream: %s %s", err, redact(strings.TrimSpace(stderr.String())))
+	}
+	if !bytes.HasPrefix(frame, []byte{0xFF, 0xD8}) {
+		return snapshotFrame{}, fmt.Errorf("the frame read from the stream is not a JPEG image")
+	}
+
+	snapshot = snapshotFrame{data: frame, path: path, capturedAt: time.Now()}
+	dev.mutex.Lock()
+	dev.snapshot = snapshot
+	dev.mutex.Unlock()
+	return snapshot, nil
+}
+
+// parseSnapshotOptions parses the width, height, format and quality query parameters of a snapshot request
+func parseSnapshotOptions(queryParams url.Values, defaultQuality int) (snapshotOptions, error) {
+	options := snapshotOptions{format: SnapshotFormatJPEG, quality: defaultQuality}
+	for name, value := range map[string]*int{"width": &options.width, "height": &options.height} {
+		param := queryParams.Get(name)
+		if param == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 1 || parsed > maxSnapshotDimension {
+			return options, fmt.Errorf("%s value of \"%s\" is invalid, it must be between 1 and %d", name, param, maxSnapshotDimension)
+		}
+		*value = parsed
+	}
+	if format := strings.ToLower(queryParams.Get("format")); format != "" {
+		if format == "jpg" {
+			format = SnapshotFormatJPEG
+		}
+		if format != SnapshotFormatJPEG && format != SnapshotFormatPNG {
+			return options, fmt.Errorf("format value of \"%s\" is invalid, valid options are \"jpeg\" and \"png\"", format)
+		}
+		options.format = format
+	}
+	if param := queryParams.Get("quality"); param != "" {
+		quality, err := strconv.Atoi(param)
+		if err != nil || quality < 1 || quality > 100 {
+			return options, fmt.Errorf("quality value of \"%s\" is invalid, it must be between 1 and 100", param)
+		}
+		options.quality = quality
+		options.reencode = true
+	}
+	options.reencode = options.reencode || options.width > 0 || options.height > 0 || options.format != SnapshotFormatJPEG
+	return options, nil
+}
+
+// etag returns the entity tag of the snapshot of the frame encoded with the options
+func (options snapshotOptions) etag(frame []byte) string {
+	hash := sha256.Sum256(frame)
+	tag := hex.EncodeToString(hash[:8])
+	if options.reencode {
+		tag += fmt.Sprintf("-%dx%d-%s-%d", options.width, options.height, options.format, options.quality)
+	}
+	return strconv.Quote(tag)
+}
+
+// encode returns the frame resized and encoded as specified by the options
+func (options snapshotOptions) encode(frame []byte) ([]byte, error) {
+	if !options.reencode {
+		return frame, nil
+	}
+	img, err := jpeg.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the frame: %w", err)
+	}
+	bounds := img.Bounds()
+	width, height := snapshotSize(bounds.Dx(), bounds.Dy(), options.width, options.height)
+	if width != bounds.Dx() || height != bounds.Dy() {
+		img = resizeImage(img, width, height)
+	}
+
+	var buf bytes.Buffer
+	if options.format == SnapshotFormatPNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: options.quality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// snapshotSize returns the size of the snapshot, keeping the aspect ratio of the frame if only one of the requested
+// width and height is specified
+func snapshotSize(frameWidth, frameHeight, width, height int) (int, int) {
+	switch {
+	case width > 0 && height > 0:
+		return width, height
+	case width > 0:
+		return width, max(1, (frameHeight*width+frameWidth/2)/frameWidth)
+	case height > 0:
+		return max(1, (frameWidth*height+frameHeight/2)/frameHeight), height
+	default:
+		return frameWidth, frameHeight
+	}
+}
+
+// resizeImage resizes the image by averaging the source pixels covered by each pixel of the resized image,
+// which degrades to the nearest neighbour when enlarging
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	source := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(source, source.Bounds(), src, bounds.Min, draw.Src)
+	srcWidth, srcHeight := source.Bounds().Dx(), source.Bounds().Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max(y0+1, (y+1)*srcHeight/height)
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max(x0+1, (x+1)*srcWidth/width)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := source.Pix[sy*source.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
+
+// notModified checks the conditional headers of the request against the entity tag and the modification time
+// of the snapshot
+func notModified(request *http.Request, etag string, modified time.Time) bool {
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince := request.Header.Get("If-Modified-Since"); ifModifiedSince != "" {
+		since, err := http.ParseTime(ifModifiedSince)
+		return err == nil && !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// SnapshotRoute returns a single image of the camera specified by the name path parameter. The width, height,
+// format and quality query parameters resize and encode the image, and the pathIndex and streamFormat query
+// parameters select the path of the camera. The clients authenticate the same way as for the MJPEG previews.
+func (d *Driver) SnapshotRoute(c echo.Context) error {
+	request := c.Request()
+	name := c.Param(common.Name)
+
+	if status, message := d.authenticateViewer(c, name); status != http.StatusOK {
+		return d.writePreviewError(c, status, message)
+	}
+
+	dev, ok := d.devices.get(name)
+	if !ok {
+		return d.writePreviewError(c, http.StatusNotFound, fmt.Sprintf("camera %s is not active", name))
+	}
+
+	queryParams := request.URL.Query()
+	options, err := parseSnapshotOptions(queryParams, d.previewConfig.JpegQuality)
+	if err != nil {
+		return d.writePreviewError(c, http.StatusBadRequest, err.Error())
+	}
+	videoPath, err := d.getPathName(dev, url.Values{
+		PathIndex:    queryParams["pathIndex"],
+		StreamFormat: queryParams["streamFormat"],
+	})
+	if err != nil {
+		return d.writePreviewError(c, http.StatusBadRequest, err.Error())
+	}
+
+	snapshot, err := d.captureSnapshot(dev, videoPath)
+	if err != nil {
+		return d.writePreviewError(c, http.StatusServiceUnavailable,
+			fmt.Sprintf("failed to capture a snapshot of the camera %s at path %s: %s", name, videoPath, err.Error()))
+	}
+
+	header := c.Response().Header()
+	etag := options.etag(snapshot.data)
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	header.Set("ETag", etag)
+	header.Set(echo.HeaderLastModified, snapshot.capturedAt.UTC().Format(http.TimeFormat))
+	if notModified(request, etag, snapshot.capturedAt) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	body, err := options.encode(snapshot.data)
+	if err != nil {
+		return d.writePreviewError(c, http.StatusInternalServerError, err.Error())
+	}
+	return c.Blob(http.StatusOK, "image/"+options.format, body)
+}
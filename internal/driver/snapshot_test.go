@@ -0,0 +1,291 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/common"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertSnapshotOutput(t *testing.T) {
+	command := []string{"-y", "-f", "v4l2", "-i", "/dev/video0", "-vcodec", "libx264", "-f", "rtsp", "rtsp://localhost:8554/stream/camera"}
+	result := insertSnapshotOutput(command, "")
+	assert.Equal(t, []string{"-y", "-f", "v4l2", "-i", "/dev/video0"}, result[:5])
+	assert.Equal(t, "pipe:3", result[len(result)-len(command)+4], "the snapshot output must precede the rtsp output options")
+	assert.Equal(t, command[5:], result[len(result)-len(command)+5:])
+	assert.Subset(t, result, []string{"-vf", "fps=" + snapshotStreamFps})
+	assert.Equal(t, []string{"-y"}, insertSnapshotOutput([]string{"-y"}, ""), "no input")
+
+	result = insertSnapshotOutput(command, "drawtext=text=camera")
+	assert.Subset(t, result, []string{"-vf", "drawtext=text=camera,fps=" + snapshotStreamFps}, "the overlay is drawn on the snapshots too")
+}
+
+func TestScanJPEGFrames(t *testing.T) {
+	frame1 := []byte{0xFF, 0xD8, 0x01, 0xFF, 0x00, 0x02, 0xFF, 0xD9}
+	frame2 := []byte{0xFF, 0xD8, 0x03, 0xFF, 0xD9}
+	truncated := []byte{0xFF, 0xD8, 0x04}
+	data := bytes.Join([][]byte{{0x00, 0xFF}, frame1, {0x05}, frame2, truncated}, nil)
+
+	for name, reader := range map[string]io.Reader{
+		"whole":       bytes.NewReader(data),
+		"byte a time": iotest.OneByteReader(bytes.NewReader(data)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			scanner := bufio.NewScanner(reader)
+			scanner.Split(scanJPEGFrames)
+			var frames [][]byte
+			for scanner.Scan() {
+				frames = append(frames, bytes.Clone(scanner.Bytes()))
+			}
+			require.NoError(t, scanner.Err())
+			assert.Equal(t, [][]byte{frame1, frame2}, frames)
+		})
+	}
+}
+
+func TestParseSnapshotOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		expected  snapshotOptions
+		expectErr bool
+	}{
+		{"defaults", "", snapshotOptions{format: SnapshotFormatJPEG, quality: 80}, false},
+		{"resize", "width=320", snapshotOptions{width: 320, format: SnapshotFormatJPEG, quality: 80, reencode: true}, false},
+		{"png", "format=PNG", snapshotOptions{format: SnapshotFormatPNG, quality: 80, reencode: true}, false},
+		{"jpg", "format=jpg&quality=50", snapshotOptions{format: SnapshotFormatJPEG, quality: 50, reencode: true}, false},
+		{"invalid format", "format=gif", snapshotOptions{}, true},
+		{"invalid width", "width=0", snapshotOptions{}, true},
+		{"too large height", "height=100000", snapshotOptions{}, true},
+		{"invalid quality", "quality=0", snapshotOptions{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			options, err := parseSnapshotOptions(query, 80)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, options)
+		})
+	}
+}
+
+func TestSnapshotSize(t *testing.T) {
+	tests := []struct {
+		name           string
+		width, height  int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{"original size", 0, 0, 640, 480},
+		{"width", 320, 0, 320, 240},
+		{"height", 0, 120, 160, 120},
+		{"both", 100, 100, 100, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height := snapshotSize(640, 480, tt.width, tt.height)
+			assert.Equal(t, tt.expectedWidth, width)
+			assert.Equal(t, tt.expectedHeight, height)
+		})
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	// the left half is black and the right half is white
+	src := image.NewGray(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		src.SetGray(2, y, color.Gray{Y: 255})
+		src.SetGray(3, y, color.Gray{Y: 255})
+	}
+
+	resized := resizeImage(src, 2, 1)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), resized.Bounds())
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, resized.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, resized.RGBAAt(1, 0))
+
+	resized = resizeImage(src, 1, 1)
+	assert.Equal(t, color.RGBA{127, 127, 127, 255}, resized.RGBAAt(0, 0))
+
+	resized = resizeImage(src, 8, 4)
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, resized.RGBAAt(3, 3))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, resized.RGBAAt(4, 0))
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC)
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		expected bool
+	}{
+		{"no condition", "", "", false},
+		{"matching etag", "If-None-Match", `"other", "abc"`, true},
+		{"weak etag", "If-None-Match", `W/"abc"`, true},
+		{"any etag", "If-None-Match", "*", true},
+		{"other etag", "If-None-Match", `"other"`, false},
+		{"not modified since", "If-Modified-Since", modified.Format(http.TimeFormat), true},
+		{"modified since", "If-Modified-Since", modified.Add(-time.Second).Format(http.TimeFormat), false},
+		{"invalid date", "If-Modified-Since", "yesterday", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				request.Header.Set(tt.header, tt.value)
+			}
+			assert.Equal(t, tt.expected, notModified(request, `"abc"`, modified))
+		})
+	}
+}
+
+// getSnapshot requests a snapshot of the camera with the given query and headers using the test rtsp credentials
+func getSnapshot(t *testing.T, d *Driver, name, query string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, common.ApiBase+"/snapshot/"+name+query, nil)
+	request.SetBasicAuth(testRtspUser, testRtspPassword)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	c := echo.New().NewContext(request, recorder)
+	c.SetParamNames(common.Name)
+	c.SetParamValues(name)
+	require.NoError(t, d.SnapshotRoute(c))
+	return recorder
+}
+
+func TestSnapshotRoute(t *testing.T) {
+	frame := fakeSnapshotFrame()
+	openCaptures := setFakeFrameCapture(t, frame)
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.previewConfig = previewConfig{MaxFps: 10, MaxWidth: 640, MaxHeight: 480, JpegQuality: 80}
+	installFakeFFmpeg(t)
+	addFakeStreamingDevice(t, d, "camera")
+
+	recorder := getSnapshot(t, d, "camera", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "image/jpeg", recorder.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, frame, recorder.Body.Bytes(), "the frame is returned as it is without options")
+	etag := recorder.Header().Get("ETag")
+	lastModified := recorder.Header().Get(echo.HeaderLastModified)
+	assert.NotEmpty(t, etag)
+	assert.NotEmpty(t, lastModified)
+	assert.Eventually(t, func() bool { return openCaptures() == 0 }, 5*time.Second, 10*time.Millisecond,
+		"the capture must be stopped after the snapshot")
+
+	recorder = getSnapshot(t, d, "camera", "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.Bytes())
+	recorder = getSnapshot(t, d, "camera", "", map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	recorder = getSnapshot(t, d, "camera", "?format=png&width=4", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "image/png", recorder.Header().Get(echo.HeaderContentType))
+	assert.NotEqual(t, etag, recorder.Header().Get("ETag"), "the options are part of the entity tag")
+	img, err := png.Decode(recorder.Body)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 4, 3), img.Bounds())
+
+	recorder = getSnapshot(t, d, "camera", "?height=12&quality=90", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	img, err = jpeg.Decode(recorder.Body)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 16, 12), img.Bounds())
+
+	assert.Equal(t, http.StatusBadRequest, getSnapshot(t, d, "camera", "?format=gif", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, getSnapshot(t, d, "camera", "?pathIndex=1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, getSnapshot(t, d, "unknown", "", nil).Code)
+
+	recorder = httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, common.ApiBase+"/snapshot/camera", nil), recorder)
+	c.SetParamNames(common.Name)
+	c.SetParamValues("camera")
+	require.NoError(t, d.SnapshotRoute(c))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestSnapshotRouteWhileStreaming(t *testing.T) {
+	original := openFrameCapture
+	openFrameCapture = func(string, frameCaptureConfig) (frameCapture, error) {
+		return nil, errors.New("device or resource busy")
+	}
+	t.Cleanup(func() { openFrameCapture = original })
+	installFakeFFmpeg(t, "snapshot", "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.streamSnapshots = true
+	require.NoError(t, d.startStreaming(dev))
+	require.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	defer func() {
+		dev.StopStreaming()
+		nextStreamingStatus(t, asyncCh)
+	}()
+
+	recorder := getSnapshot(t, d, "camera", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, fakeSnapshotFrame(), recorder.Body.Bytes(), "the snapshot is the frame of the stream")
+}
+
+func TestSnapshotRouteReadsStream(t *testing.T) {
+	original := openFrameCapture
+	openFrameCapture = func(string, frameCaptureConfig) (frameCapture, error) {
+		return nil, errors.New("device or resource busy")
+	}
+	t.Cleanup(func() { openFrameCapture = original })
+	argsFile := installFakeFFmpeg(t, "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	require.NoError(t, d.startStreaming(dev))
+	require.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	defer func() {
+		dev.StopStreaming()
+		nextStreamingStatus(t, asyncCh)
+	}()
+	assert.NotContains(t, readFakeFFmpegArgs(t, argsFile), "pipe:3", "the snapshot output is disabled by default")
+
+	recorder := getSnapshot(t, d, "camera", "", nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, fakeSnapshotFrame(), recorder.Body.Bytes(), "the snapshot is read from the published stream")
+}
+
+func TestPassthroughHasNoSnapshotOutput(t *testing.T) {
+	argsFile := installFakeFFmpeg(t, "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	dev.streamSnapshots = true
+	dev.streamingStatus.OutputVideoCodec = FFmpegCodecCopy
+	require.NoError(t, d.startStreaming(dev))
+	require.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	defer func() {
+		dev.StopStreaming()
+		nextStreamingStatus(t, asyncCh)
+	}()
+	assert.NotContains(t, readFakeFFmpegArgs(t, argsFile), "pipe:3")
+}
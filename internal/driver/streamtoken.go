@@ -0,0 +1,185 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+)
+
+const (
+	// StreamTokenSecretName is the secret holding the key signing the stream tokens under StreamTokenSecretKey.
+	// A random key is used if the secret is not stored, so the tokens do not survive a restart of the service.
+	StreamTokenSecretName = "streamtoken"
+	StreamTokenSecretKey  = "key"
+	// StreamTokenQueryKey is the query parameter carrying the token in the stream uris
+	StreamTokenQueryKey = "token"
+	// StreamTokenTtlQuery and StreamTokenIpQuery are the query parameters of the VIDEO_STREAM_TOKEN command,
+	// which set the lifetime of the token and the only client IP allowed to use it
+	StreamTokenTtlQuery = "ttl"
+	StreamTokenIpQuery  = "ip"
+)
+
+// streamTokenConfig configures the lifetime of the stream tokens
+type streamTokenConfig struct {
+	DefaultTtl time.Duration
+	MaxTtl     time.Duration
+}
+
+func parseStreamTokenConfig(configs map[string]string) (streamTokenConfig, error) {
+	config := streamTokenConfig{DefaultTtl: DefaultStreamTokenTtl, MaxTtl: DefaultStreamTokenMaxTtl}
+	for key, duration := range map[string]*time.Duration{
+		StreamTokenTtl:    &config.DefaultTtl,
+		StreamTokenMaxTtl: &config.MaxTtl,
+	} {
+		if configs[key] == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(configs[key])
+		if err != nil || parsed <= 0 {
+			return config, fmt.Errorf("%s value of \"%s\" is invalid, it must be a positive duration such as 5m", key, configs[key])
+		}
+		*duration = parsed
+	}
+	if config.DefaultTtl > config.MaxTtl {
+		return config, fmt.Errorf("%s of %s exceeds %s of %s", StreamTokenTtl, config.DefaultTtl, StreamTokenMaxTtl, config.MaxTtl)
+	}
+	return config, nil
+}
+
+// streamTokenClaims are the signed content of a stream token
+type streamTokenClaims struct {
+	// Path is the path of the stream the token gives read access to
+	Path string `json:"path"`
+	// IP is the only client IP allowed to use the token if set
+	IP      string `json:"ip,omitempty"`
+	Expires int64  `json:"exp"`
+}
+
+// streamTokenKey returns the key signing the stream tokens
+func (d *Driver) streamTokenKey() []byte {
+	secretData, err := d.ds.SecretProvider().GetSecret(StreamTokenSecretName, StreamTokenSecretKey)
+	if err == nil && secretData[StreamTokenSecretKey] != "" {
+		return []byte(secretData[StreamTokenSecretKey])
+	}
+	d.streamTokenKeyOnce.Do(func() {
+		d.lc.Infof("No stream token key stored for secretName %s, the stream tokens are signed with a random key until the service restarts",
+			StreamTokenSecretName)
+		d.streamTokenRandomKey = make([]byte, 32)
+		_, _ = rand.Read(d.streamTokenRandomKey)
+	})
+	return d.streamTokenRandomKey
+}
+
+func signStreamToken(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issueStreamToken returns a token giving read access to the stream of the device until it expires
+func (d *Driver) issueStreamToken(claims streamTokenClaims) (string, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + signStreamToken(d.streamTokenKey(), payload), nil
+}
+
+// verifyStreamToken checks that the token gives read access to the stream path to the client IP
+func (d *Driver) verifyStreamToken(token, streamPath, ip string, now time.Time) error {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return fmt.Errorf("malformed token")
+	}
+	if !hmac.Equal([]byte(signature), []byte(signStreamToken(d.streamTokenKey(), payload))) {
+		return fmt.Errorf("invalid token signature")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return fmt.Errorf("malformed token")
+	}
+	var claims streamTokenClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return fmt.Errorf("malformed token")
+	}
+	if now.Unix() >= claims.Expires {
+		return fmt.Errorf("the token has expired at %s", time.Unix(claims.Expires, 0).UTC().Format(time.RFC3339))
+	}
+	if claims.Path != streamPath {
+		return fmt.Errorf("the token is not valid for the path %s", streamPath)
+	}
+	if claims.IP != "" && !net.ParseIP(claims.IP).Equal(net.ParseIP(ip)) {
+		return fmt.Errorf("the token is not valid for the IP %s", ip)
+	}
+	return nil
+}
+
+// authenticateStreamToken authenticates a request to read a stream with the token in its query, it returns false
+// if the request does not carry any token
+func (d *Driver) authenticateStreamToken(authRequest RTSPAuthRequest, now time.Time) (bool, error) {
+	query, err := url.ParseQuery(authRequest.Query)
+	if err != nil || !query.Has(StreamTokenQueryKey) {
+		return false, nil
+	}
+	if authRequest.Action != "read" {
+		return true, fmt.Errorf("the tokens only give read access")
+	}
+	return true, d.verifyStreamToken(query.Get(StreamTokenQueryKey), authRequest.Path, authRequest.IP, now)
+}
+
+// streamTokenUri returns the stream uri of the device with a new token in its query, the lifetime of the token
+// and the client IP allowed to use it are set by the query parameters of the command
+func (d *Driver) streamTokenUri(device *Device, queryParams url.Values, now time.Time) (string, errors.EdgeX) {
+	ttl := d.streamTokenConfig.DefaultTtl
+	if value := queryParams.Get(StreamTokenTtlQuery); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return "", errors.NewCommonEdgeX(errors.KindContractInvalid,
+				fmt.Sprintf("invalid %s query parameter %s, it must be a positive duration such as 5m", StreamTokenTtlQuery, value), nil)
+		}
+		if parsed > d.streamTokenConfig.MaxTtl {
+			return "", errors.NewCommonEdgeX(errors.KindContractInvalid,
+				fmt.Sprintf("the %s query parameter %s exceeds the maximum of %s", StreamTokenTtlQuery, value, d.streamTokenConfig.MaxTtl), nil)
+		}
+		ttl = parsed
+	}
+	claims := streamTokenClaims{Path: path.Join(Stream, device.name), Expires: now.Add(ttl).Unix()}
+	if value := queryParams.Get(StreamTokenIpQuery); value != "" {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", errors.NewCommonEdgeX(errors.KindContractInvalid,
+				fmt.Sprintf("invalid %s query parameter %s, it must be an IP address", StreamTokenIpQuery, value), nil)
+		}
+		claims.IP = ip.String()
+	}
+
+	token, err := d.issueStreamToken(claims)
+	if err != nil {
+		return "", errors.NewCommonEdgeX(errors.KindServerError, "failed to issue the stream token", err)
+	}
+	uri, err := url.Parse(device.rtspUri)
+	if err != nil {
+		return "", errors.NewCommonEdgeX(errors.KindServerError, fmt.Sprintf("invalid stream uri of device %s", device.name), err)
+	}
+	uri.RawQuery = url.Values{StreamTokenQueryKey: {token}}.Encode()
+	d.lc.Infof("Issued a stream token for device %s expiring at %s, restricted to the IP %q",
+		device.name, time.Unix(claims.Expires, 0).UTC().Format(time.RFC3339), claims.IP)
+	return uri.String(), nil
+}
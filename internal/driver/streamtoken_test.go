@@ -0,0 +1,160 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	bootstrapMocks "github.com/edgexfoundry/go-mod-bootstrap/v4/bootstrap/interfaces/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeTokenDriver returns a fake streaming driver signing the stream tokens with the key, or with a random
+// key if it is empty
+func newFakeTokenDriver(t *testing.T, key string) *Driver {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.streamTokenConfig = streamTokenConfig{DefaultTtl: DefaultStreamTokenTtl, MaxTtl: time.Hour}
+	secretProvider, ok := d.ds.SecretProvider().(*bootstrapMocks.SecretProviderExt)
+	require.True(t, ok)
+	if key == "" {
+		secretProvider.On("GetSecret", StreamTokenSecretName, StreamTokenSecretKey).
+			Return(nil, fmt.Errorf("no secret stored for %s", StreamTokenSecretName))
+	} else {
+		secretProvider.On("GetSecret", StreamTokenSecretName, StreamTokenSecretKey).
+			Return(map[string]string{StreamTokenSecretKey: key}, nil)
+	}
+	return d
+}
+
+// tokenQuery returns the query of the stream uri with a token
+func tokenQuery(t *testing.T, uri string) string {
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "rtsp://localhost:8554/stream/camera", (&url.URL{Scheme: parsed.Scheme, Host: parsed.Host, Path: parsed.Path}).String())
+	require.NotEmpty(t, parsed.Query().Get(StreamTokenQueryKey))
+	return parsed.RawQuery
+}
+
+func TestParseStreamTokenConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		expected  streamTokenConfig
+		expectErr bool
+	}{
+		{"defaults", map[string]string{}, streamTokenConfig{DefaultStreamTokenTtl, DefaultStreamTokenMaxTtl}, false},
+		{"configured", map[string]string{StreamTokenTtl: "1m", StreamTokenMaxTtl: "1h"}, streamTokenConfig{time.Minute, time.Hour}, false},
+		{"invalid ttl", map[string]string{StreamTokenTtl: "soon"}, streamTokenConfig{}, true},
+		{"zero max ttl", map[string]string{StreamTokenMaxTtl: "0s"}, streamTokenConfig{}, true},
+		{"ttl above max", map[string]string{StreamTokenTtl: "2h", StreamTokenMaxTtl: "1h"}, streamTokenConfig{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseStreamTokenConfig(tt.configs)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
+
+func TestStreamTokenUri(t *testing.T) {
+	d := newFakeTokenDriver(t, "")
+	dev := &Device{name: "camera", rtspUri: "rtsp://localhost:8554/stream/camera"}
+
+	tests := []struct {
+		name      string
+		query     url.Values
+		expectErr bool
+	}{
+		{"default", url.Values{}, false},
+		{"ttl and ip", url.Values{StreamTokenTtlQuery: {"10m"}, StreamTokenIpQuery: {"10.0.0.1"}}, false},
+		{"invalid ttl", url.Values{StreamTokenTtlQuery: {"later"}}, true},
+		{"ttl above max", url.Values{StreamTokenTtlQuery: {"2h"}}, true},
+		{"invalid ip", url.Values{StreamTokenIpQuery: {"10.0.0"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uri, err := d.streamTokenUri(dev, tt.query, time.Now())
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tokenQuery(t, uri)
+		})
+	}
+}
+
+func TestVerifyStreamToken(t *testing.T) {
+	d := newFakeTokenDriver(t, "secret-key")
+	now := time.Now()
+	token, err := d.issueStreamToken(streamTokenClaims{Path: "stream/camera", IP: "10.0.0.1", Expires: now.Add(time.Minute).Unix()})
+	require.NoError(t, err)
+	unbound, err := d.issueStreamToken(streamTokenClaims{Path: "stream/camera", Expires: now.Add(time.Minute).Unix()})
+	require.NoError(t, err)
+	forged, err := newFakeTokenDriver(t, "other-key").issueStreamToken(streamTokenClaims{Path: "stream/camera", Expires: now.Add(time.Minute).Unix()})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		token     string
+		path      string
+		ip        string
+		now       time.Time
+		expectErr bool
+	}{
+		{"valid", token, "stream/camera", "10.0.0.1", now, false},
+		{"any ip", unbound, "stream/camera", "10.0.0.2", now, false},
+		{"other ip", token, "stream/camera", "10.0.0.2", now, true},
+		{"other path", token, "stream/other", "10.0.0.1", now, true},
+		{"expired", token, "stream/camera", "10.0.0.1", now.Add(time.Minute), true},
+		{"other key", forged, "stream/camera", "10.0.0.1", now, true},
+		{"tampered", "e30" + token[3:], "stream/camera", "10.0.0.1", now, true},
+		{"malformed", "token", "stream/camera", "10.0.0.1", now, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := d.verifyStreamToken(tt.token, tt.path, tt.ip, tt.now)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateStreamToken(t *testing.T) {
+	d := newFakeTokenDriver(t, "")
+	d.authGuard = newAuthGuard(authGuardConfig{MaxFailures: 2, FailureWindow: time.Minute, LockoutDuration: time.Minute})
+	dev := &Device{name: "camera", rtspUri: "rtsp://localhost:8554/stream/camera"}
+	uri, edgexErr := d.streamTokenUri(dev, url.Values{StreamTokenIpQuery: {"10.0.0.1"}}, time.Now())
+	require.NoError(t, edgexErr)
+	query := tokenQuery(t, uri)
+
+	request := func(ip, path, action, query string) int {
+		status, _ := d.authenticate(RTSPAuthRequest{IP: ip, Path: path, Protocol: "rtsp", Action: action, Query: query})
+		return status
+	}
+	assert.Equal(t, http.StatusOK, request("10.0.0.1", "stream/camera", "read", query))
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.1", "stream/camera", "publish", query), "the tokens only give read access")
+	assert.Equal(t, http.StatusUnauthorized, request("10.0.0.1", "stream/other", "read", query))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1", "stream/camera", "read", query), "the IP is locked out")
+
+	status, _ := d.authenticate(RTSPAuthRequest{IP: "10.0.0.2", User: testRtspUser, Password: testRtspPassword,
+		Path: "stream/camera", Protocol: "rtsp", Action: "read"})
+	assert.Equal(t, http.StatusOK, status, "the token failures do not lock out the users")
+}
@@ -0,0 +1,138 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/errors"
+)
+
+const (
+	// maxUSBDeviceDepth limits how many directories are walked up from the video4linux device
+	// to find the USB device it belongs to
+	maxUSBDeviceDepth = 3
+)
+
+// USBDeviceIdentity identifies the USB device behind a video4linux device node
+type USBDeviceIdentity struct {
+	// CardName is the name of the video4linux device, i.e. the udev ID_V4L_PRODUCT property
+	CardName string `json:"cardName,omitempty"`
+	// SerialNumber is the USB serial number, or the udev style ID_SERIAL if the device has no serial number
+	SerialNumber    string `json:"serialNumber,omitempty"`
+	VendorID        string `json:"vendorId,omitempty"`
+	ProductID       string `json:"productId,omitempty"`
+	Manufacturer    string `json:"manufacturer,omitempty"`
+	Product         string `json:"product,omitempty"`
+	BusPath         string `json:"busPath,omitempty"`
+	InterfaceNumber string `json:"interfaceNumber,omitempty"`
+	Driver          string `json:"driver,omitempty"`
+	// Removable is the removable attribute of the USB device, which is "fixed" for the devices built into the host
+	Removable string `json:"removable,omitempty"`
+	// ConnectType is the connect type of the USB port, which is "hardwired" for the devices built into the host
+	ConnectType string `json:"connectType,omitempty"`
+}
+
+// getUSBDeviceIdentity returns the identity of the USB device on the specified path using the sysfs tree at d.sysfsRoot
+func (d *Driver) getUSBDeviceIdentity(path string) (USBDeviceIdentity, errors.EdgeX) {
+	sysfsRoot := d.sysfsRoot
+	if sysfsRoot == "" {
+		sysfsRoot = DefaultSysfsRoot
+	}
+	return getUSBDeviceIdentity(sysfsRoot, path)
+}
+
+// getUSBDeviceIdentity reads the identity of the USB device on the specified path from the sysfs tree at sysfsRoot.
+// The video4linux device links to the USB interface it was created for, and the USB device is a parent of the interface.
+func getUSBDeviceIdentity(sysfsRoot, path string) (USBDeviceIdentity, errors.EdgeX) {
+	var identity USBDeviceIdentity
+
+	// resolve symlinks such as /dev/v4l/by-id/* to get the name of the device node
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	v4lDir := filepath.Join(sysfsRoot, "class", "video4linux", filepath.Base(path))
+
+	identity.CardName = readSysfsAttribute(v4lDir, "name")
+	if len(identity.CardName) == 0 {
+		return identity, errors.NewCommonEdgeX(errors.KindServerError,
+			fmt.Sprintf("could not find the card name of the device on the specified path %s", path), nil)
+	}
+
+	interfaceDir, err := filepath.EvalSymlinks(filepath.Join(v4lDir, "device"))
+	if err != nil {
+		return identity, errors.NewCommonEdgeX(errors.KindServerError,
+			fmt.Sprintf("could not find the sysfs device of the device on the specified path %s", path), err)
+	}
+	identity.InterfaceNumber = readSysfsAttribute(interfaceDir, "bInterfaceNumber")
+	if driver, err := filepath.EvalSymlinks(filepath.Join(interfaceDir, "driver")); err == nil {
+		identity.Driver = filepath.Base(driver)
+	}
+
+	usbDir := interfaceDir
+	for depth := 0; readSysfsAttribute(usbDir, "idVendor") == ""; depth++ {
+		if depth == maxUSBDeviceDepth {
+			return identity, errors.NewCommonEdgeX(errors.KindServerError,
+				fmt.Sprintf("the device on the specified path %s is not a USB device", path), nil)
+		}
+		usbDir = filepath.Dir(usbDir)
+	}
+	identity.VendorID = readSysfsAttribute(usbDir, "idVendor")
+	identity.ProductID = readSysfsAttribute(usbDir, "idProduct")
+	identity.Manufacturer = readSysfsAttribute(usbDir, "manufacturer")
+	identity.Product = readSysfsAttribute(usbDir, "product")
+	identity.Removable = readSysfsAttribute(usbDir, "removable")
+	identity.ConnectType = readSysfsAttribute(filepath.Join(usbDir, "port"), "connect_type")
+	// the name of a USB device in sysfs is <bus>-<port>[.<port>...], which identifies the physical port it is plugged into
+	identity.BusPath = filepath.Base(usbDir)
+
+	identity.SerialNumber = udevReplaceChars(readSysfsAttribute(usbDir, "serial"))
+	if len(identity.SerialNumber) == 0 {
+		// keep the same serial number as the udev ID_SERIAL property, which is what has been used for
+		// devices without a serial number so far
+		identity.SerialNumber = udevSerial(identity)
+	}
+	return identity, nil
+}
+
+// readSysfsAttribute returns the trimmed content of the sysfs attribute, or an empty string if it cannot be read
+func readSysfsAttribute(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// udevSerial builds the serial the same way the udev usb_id builtin builds the ID_SERIAL property of a device
+// without serial number, which is <vendor>_<model>. The vendor and model fall back to the vendor and product ids.
+func udevSerial(identity USBDeviceIdentity) string {
+	vendor := udevReplaceChars(identity.Manufacturer)
+	if len(vendor) == 0 {
+		vendor = identity.VendorID
+	}
+	model := udevReplaceChars(identity.Product)
+	if len(model) == 0 {
+		model = identity.ProductID
+	}
+	return vendor + "_" + model
+}
+
+// udevReplaceChars replaces whitespace and the characters udev does not allow in property values with underscores
+func udevReplaceChars(value string) string {
+	value = strings.Join(strings.Fields(value), "_")
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("#+-.:=@_", r) {
+			return r
+		}
+		return '_'
+	}, value)
+}
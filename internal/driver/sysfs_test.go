@@ -0,0 +1,178 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sysfsFixtureDevice describes a video4linux device node of a USB camera in a sysfs fixture tree
+type sysfsFixtureDevice struct {
+	node            string
+	cardName        string
+	busPath         string
+	interfaceNumber string
+	driver          string
+	// attributes of the USB device, such as idVendor, idProduct, serial, manufacturer and product
+	usbAttributes map[string]string
+}
+
+// createSysfsFixture creates a sysfs tree under root which mimics the layout of the kernel:
+// /sys/class/video4linux/<node> links to the device node under the USB interface, the USB interface
+// links to its driver, and the USB device is the parent directory of the interface.
+func createSysfsFixture(t *testing.T, root string, devices ...sysfsFixtureDevice) {
+	writeFile := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte(content+"\n"), 0600))
+	}
+	symlink := func(target, link string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(link), 0750))
+		if _, err := os.Lstat(link); err == nil {
+			return
+		}
+		require.NoError(t, os.Symlink(target, link))
+	}
+
+	for _, dev := range devices {
+		usbDir := filepath.Join(root, "devices", "pci0000:00", "0000:00:14.0", "usb1", dev.busPath)
+		for name, value := range dev.usbAttributes {
+			writeFile(filepath.Join(usbDir, name), value)
+		}
+		interfaceDir := filepath.Join(usbDir, dev.busPath+":1."+dev.interfaceNumber)
+		writeFile(filepath.Join(interfaceDir, "bInterfaceNumber"), dev.interfaceNumber)
+		if dev.driver != "" {
+			driverDir := filepath.Join(root, "bus", "usb", "drivers", dev.driver)
+			require.NoError(t, os.MkdirAll(driverDir, 0750))
+			symlink(driverDir, filepath.Join(interfaceDir, "driver"))
+		}
+		nodeDir := filepath.Join(interfaceDir, "video4linux", dev.node)
+		writeFile(filepath.Join(nodeDir, "name"), dev.cardName)
+		symlink(interfaceDir, filepath.Join(nodeDir, "device"))
+		symlink(nodeDir, filepath.Join(root, "class", "video4linux", dev.node))
+	}
+}
+
+func TestGetUSBDeviceIdentity(t *testing.T) {
+	root := t.TempDir()
+	createSysfsFixture(t, root,
+		sysfsFixtureDevice{
+			node:            "video0",
+			cardName:        "HP Webcam: HP Webcam",
+			busPath:         "1-2.3",
+			interfaceNumber: "00",
+			driver:          "uvcvideo",
+			usbAttributes: map[string]string{
+				"idVendor":          "03f0",
+				"idProduct":         "0a4e",
+				"serial":            "SN 0001",
+				"manufacturer":      "HP",
+				"product":           "HP Webcam",
+				"removable":         "fixed",
+				"port/connect_type": "hardwired",
+			},
+		},
+		sysfsFixtureDevice{
+			node:            "video2",
+			cardName:        "USB 2.0 Camera",
+			busPath:         "1-4",
+			interfaceNumber: "00",
+			driver:          "uvcvideo",
+			usbAttributes: map[string]string{
+				"idVendor":     "1bcf",
+				"idProduct":    "2c99",
+				"manufacturer": "Sonix Technology Co., Ltd.",
+				"product":      "USB 2.0 Camera",
+			},
+		},
+		sysfsFixtureDevice{
+			node:            "video4",
+			cardName:        "Generic Camera",
+			busPath:         "1-5",
+			interfaceNumber: "01",
+			usbAttributes: map[string]string{
+				"idVendor":  "abcd",
+				"idProduct": "1234",
+			},
+		},
+	)
+	// a video4linux device which is not backed by USB
+	platformDir := filepath.Join(root, "devices", "platform", "vivid.0")
+	require.NoError(t, os.MkdirAll(filepath.Join(platformDir, "video4linux", "video6"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(platformDir, "video4linux", "video6", "name"), []byte("vivid-000-vid-cap\n"), 0600))
+	require.NoError(t, os.Symlink(platformDir, filepath.Join(platformDir, "video4linux", "video6", "device")))
+	require.NoError(t, os.Symlink(filepath.Join(platformDir, "video4linux", "video6"), filepath.Join(root, "class", "video4linux", "video6")))
+
+	tests := []struct {
+		name      string
+		path      string
+		expected  USBDeviceIdentity
+		expectErr bool
+	}{
+		{
+			name: "serial number",
+			path: "/dev/video0",
+			expected: USBDeviceIdentity{
+				CardName:        "HP Webcam: HP Webcam",
+				SerialNumber:    "SN_0001",
+				VendorID:        "03f0",
+				ProductID:       "0a4e",
+				Manufacturer:    "HP",
+				Product:         "HP Webcam",
+				BusPath:         "1-2.3",
+				InterfaceNumber: "00",
+				Driver:          "uvcvideo",
+				Removable:       "fixed",
+				ConnectType:     "hardwired",
+			},
+		},
+		{
+			name: "no serial number",
+			path: "/dev/video2",
+			expected: USBDeviceIdentity{
+				CardName:        "USB 2.0 Camera",
+				SerialNumber:    "Sonix_Technology_Co.__Ltd._USB_2.0_Camera",
+				VendorID:        "1bcf",
+				ProductID:       "2c99",
+				Manufacturer:    "Sonix Technology Co., Ltd.",
+				Product:         "USB 2.0 Camera",
+				BusPath:         "1-4",
+				InterfaceNumber: "00",
+				Driver:          "uvcvideo",
+			},
+		},
+		{
+			name: "no serial number nor strings",
+			path: "/dev/video4",
+			expected: USBDeviceIdentity{
+				CardName:        "Generic Camera",
+				SerialNumber:    "abcd_1234",
+				VendorID:        "abcd",
+				ProductID:       "1234",
+				BusPath:         "1-5",
+				InterfaceNumber: "01",
+			},
+		},
+		{name: "not a USB device", path: "/dev/video6", expectErr: true},
+		{name: "unknown device", path: "/dev/video8", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := getUSBDeviceIdentity(root, tt.path)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, identity)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tlsCertificateSource is where a TLS certificate is loaded from, either a secret of the secret store
+// or a pair of PEM files
+type tlsCertificateSource struct {
+	SecretName string
+	CertFile   string
+	KeyFile    string
+}
+
+// parseTLSCertificateSource parses the configs of a TLS certificate, the secret name takes precedence over the files
+func parseTLSCertificateSource(configs map[string]string, secretNameKey, certFileKey, keyFileKey string) (tlsCertificateSource, error) {
+	source := tlsCertificateSource{
+		SecretName: strings.TrimSpace(configs[secretNameKey]),
+		CertFile:   strings.TrimSpace(configs[certFileKey]),
+		KeyFile:    strings.TrimSpace(configs[keyFileKey]),
+	}
+	if source.SecretName != "" {
+		return tlsCertificateSource{SecretName: source.SecretName}, nil
+	}
+	if source.CertFile == "" || source.KeyFile == "" {
+		return source, fmt.Errorf("a TLS certificate must be configured with either %s, or both %s and %s",
+			secretNameKey, certFileKey, keyFileKey)
+	}
+	return source, nil
+}
+
+func (s tlsCertificateSource) String() string {
+	if s.SecretName != "" {
+		return "secret " + s.SecretName
+	}
+	return fmt.Sprintf("files %s and %s", s.CertFile, s.KeyFile)
+}
+
+// tlsCertificate is a PEM encoded certificate along with its private key
+type tlsCertificate struct {
+	Cert []byte
+	Key  []byte
+	// CertFile and KeyFile are set if the certificate has been loaded from files or written to files
+	CertFile string
+	KeyFile  string
+}
+
+// loadTLSCertificate loads the certificate from the secret store or from the files, and checks that the
+// certificate matches the private key
+func (d *Driver) loadTLSCertificate(source tlsCertificateSource) (*tlsCertificate, error) {
+	certificate := &tlsCertificate{}
+	if source.SecretName != "" {
+		secretData, err := d.ds.SecretProvider().GetSecret(source.SecretName, TlsCertSecretKey, TlsKeySecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the TLS certificate from the secret %s: %s", source.SecretName, err.Error())
+		}
+		certificate.Cert = []byte(secretData[TlsCertSecretKey])
+		certificate.Key = []byte(secretData[TlsKeySecretKey])
+	} else {
+		var err error
+		if certificate.Cert, err = os.ReadFile(source.CertFile); err != nil {
+			return nil, fmt.Errorf("failed to read the TLS certificate: %s", err.Error())
+		}
+		if certificate.Key, err = os.ReadFile(source.KeyFile); err != nil {
+			return nil, fmt.Errorf("failed to read the TLS private key: %s", err.Error())
+		}
+		certificate.CertFile = source.CertFile
+		certificate.KeyFile = source.KeyFile
+	}
+	if _, err := tls.X509KeyPair(certificate.Cert, certificate.Key); err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate in %s: %s", source, err.Error())
+	}
+	return certificate, nil
+}
+
+// loadTLSOption loads the certificate of a TLS option, or returns nil if the option is disabled
+func (d *Driver) loadTLSOption(configs map[string]string, enableKey, secretNameKey, certFileKey, keyFileKey string) (*tlsCertificate, error) {
+	enabled, err := parseBoolConfig(configs, enableKey, false)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	source, err := parseTLSCertificateSource(configs, secretNameKey, certFileKey, keyFileKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s is enabled: %s", enableKey, err.Error())
+	}
+	certificate, err := d.loadTLSCertificate(source)
+	if err != nil {
+		return nil, err
+	}
+	d.lc.Infof("%s is enabled with the certificate from %s", enableKey, source)
+	return certificate, nil
+}
+
+// files returns the files of the certificate, the certificates loaded from the secret store are written
+// to the directory first, since the RTSP server only loads certificates from files
+func (c *tlsCertificate) files(dir, name string) (string, string, error) {
+	if c.CertFile != "" && c.KeyFile != "" {
+		return c.CertFile, c.KeyFile, nil
+	}
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, c.Cert, 0600); err != nil {
+		return "", "", fmt.Errorf("failed to write the TLS certificate: %s", err.Error())
+	}
+	if err := os.WriteFile(keyFile, c.Key, 0600); err != nil {
+		return "", "", fmt.Errorf("failed to write the TLS private key: %s", err.Error())
+	}
+	c.CertFile = certFile
+	c.KeyFile = keyFile
+	return certFile, keyFile, nil
+}
+
+// rtspUriScheme returns the scheme of the stream uris, which is rtsps in the RTSPS mode
+func (d *Driver) rtspUriScheme() string {
+	if d.rtspTls {
+		return RtspsUriScheme
+	}
+	return RtspUriScheme
+}
@@ -0,0 +1,197 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bootstrapMocks "github.com/edgexfoundry/go-mod-bootstrap/v4/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTlsSecretName = "rtsptls"
+
+// newTestCertificate returns a self-signed PEM encoded certificate for localhost along with its private key
+func newTestCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+// writeTestCertificate writes a new test certificate to files, and returns the paths of the files
+func writeTestCertificate(t *testing.T) (string, string) {
+	cert, key := newTestCertificate(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, cert, 0600))
+	require.NoError(t, os.WriteFile(keyFile, key, 0600))
+	return certFile, keyFile
+}
+
+// storeTestCertificate stores a new test certificate in the secret store of the fake driver
+func storeTestCertificate(t *testing.T, d *Driver) ([]byte, []byte) {
+	cert, key := newTestCertificate(t)
+	secretProvider, ok := d.ds.SecretProvider().(*bootstrapMocks.SecretProviderExt)
+	require.True(t, ok)
+	secretProvider.On("GetSecret", testTlsSecretName, TlsCertSecretKey, TlsKeySecretKey).
+		Return(map[string]string{TlsCertSecretKey: string(cert), TlsKeySecretKey: string(key)}, nil)
+	return cert, key
+}
+
+func TestParseTLSCertificateSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		expected  tlsCertificateSource
+		expectErr bool
+	}{
+		{"secret", map[string]string{RtspTlsSecretName: "rtsptls", RtspTlsCertFile: "server.crt"},
+			tlsCertificateSource{SecretName: "rtsptls"}, false},
+		{"files", map[string]string{RtspTlsCertFile: "server.crt", RtspTlsKeyFile: "server.key"},
+			tlsCertificateSource{CertFile: "server.crt", KeyFile: "server.key"}, false},
+		{"missing key file", map[string]string{RtspTlsCertFile: "server.crt"}, tlsCertificateSource{}, true},
+		{"nothing", map[string]string{}, tlsCertificateSource{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := parseTLSCertificateSource(tt.configs, RtspTlsSecretName, RtspTlsCertFile, RtspTlsKeyFile)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, source)
+		})
+	}
+}
+
+func TestLoadTLSOption(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	storedCert, storedKey := storeTestCertificate(t, d)
+	certFile, keyFile := writeTestCertificate(t)
+	otherCertFile, _ := writeTestCertificate(t)
+	d.ds.SecretProvider().(*bootstrapMocks.SecretProviderExt).On("GetSecret", "missing", TlsCertSecretKey, TlsKeySecretKey).
+		Return(nil, fmt.Errorf("no secret stored for missing"))
+
+	certificate, err := d.loadTLSOption(map[string]string{}, RtspTls, RtspTlsSecretName, RtspTlsCertFile, RtspTlsKeyFile)
+	require.NoError(t, err)
+	assert.Nil(t, certificate, "TLS is disabled by default")
+
+	certificate, err = d.loadTLSOption(map[string]string{RtspTls: "true", RtspTlsSecretName: testTlsSecretName},
+		RtspTls, RtspTlsSecretName, RtspTlsCertFile, RtspTlsKeyFile)
+	require.NoError(t, err)
+	assert.Equal(t, &tlsCertificate{Cert: storedCert, Key: storedKey}, certificate)
+
+	certificate, err = d.loadTLSOption(map[string]string{RtspTls: "true", RtspTlsCertFile: certFile, RtspTlsKeyFile: keyFile},
+		RtspTls, RtspTlsSecretName, RtspTlsCertFile, RtspTlsKeyFile)
+	require.NoError(t, err)
+	assert.Equal(t, certFile, certificate.CertFile)
+	assert.Equal(t, keyFile, certificate.KeyFile)
+
+	for name, configs := range map[string]map[string]string{
+		"no certificate": {RtspTls: "true"},
+		"missing file":   {RtspTls: "true", RtspTlsCertFile: certFile + ".missing", RtspTlsKeyFile: keyFile},
+		"mismatched key": {RtspTls: "true", RtspTlsCertFile: otherCertFile, RtspTlsKeyFile: keyFile},
+		"invalid toggle": {RtspTls: "sometimes"},
+		"missing secret": {RtspTls: "true", RtspTlsSecretName: "missing"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := d.loadTLSOption(configs, RtspTls, RtspTlsSecretName, RtspTlsCertFile, RtspTlsKeyFile)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRTSPSUris(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	assert.Equal(t, fmt.Sprintf("rtsp://%s:%s@localhost:8554/stream/camera", testRtspUser, testRtspPassword),
+		d.getAuthenticatedRTSPUri("camera"))
+	d.rtspTls = true
+	assert.Equal(t, fmt.Sprintf("rtsps://%s:%s@localhost:8554/stream/camera", testRtspUser, testRtspPassword),
+		d.getAuthenticatedRTSPUri("camera"))
+}
+
+func TestMediamtxConfigWithTLS(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspAuthenticationServerUri = DefaultRtspAuthenticationServer
+	storedCert, _ := storeTestCertificate(t, d)
+	var err error
+	d.rtspCertificate, err = d.loadTLSCertificate(tlsCertificateSource{SecretName: testTlsSecretName})
+	require.NoError(t, err)
+	certFile, keyFile := writeTestCertificate(t)
+	d.authServerCertificate, err = d.loadTLSCertificate(tlsCertificateSource{CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	config, err := d.newMediamtxConfig(map[string]string{RtspServerConfigFile: filepath.Join(dir, "mediamtx.yml")},
+		[]models.Device{{Name: "camera"}})
+	require.NoError(t, err)
+	assert.Equal(t, "https://localhost:8000/rtspauth", config.AuthHTTPAddress)
+	assert.Equal(t, certFile, d.authServerCertificate.CertFile, "the files of the certificate are used as they are")
+
+	assert.Equal(t, "strict", config.RTSPEncryption)
+	assert.Equal(t, ":8554", config.RTSPSAddress)
+	assert.Equal(t, filepath.Join(dir, rtspCertificateName+".crt"), config.RTSPServerCert)
+	assert.Equal(t, filepath.Join(dir, rtspCertificateName+".key"), config.RTSPServerKey)
+	written, err := os.ReadFile(config.RTSPServerCert)
+	require.NoError(t, err)
+	assert.Equal(t, storedCert, written, "the certificate of the secret store is written next to the configuration")
+	assert.Equal(t, "strict", config.RTMPEncryption)
+	assert.True(t, config.HLSEncryption)
+	assert.Equal(t, config.RTSPServerCert, config.HLSServerCert)
+	assert.True(t, config.WebRTCEncryption)
+}
+
+func TestEmbeddedRTSPServerWithTLS(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspServerMode = RTSPServerModeEmbedded
+	d.rtspTcpPort = "0"
+	certFile, keyFile := writeTestCertificate(t)
+	var err error
+	d.rtspCertificate, err = d.loadTLSCertificate(tlsCertificateSource{CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	require.NoError(t, d.startEmbeddedRTSPServer())
+	defer func() { _ = d.rtspServer.Close() }()
+	address := d.rtspServer.Addr().String()
+
+	checker := newRTSPHealthChecker(d.lc, address, rtspHealthConfig{Timeout: time.Second, Method: RtspHealthCheckMethodOptions, Tls: true})
+	health, _ := checker.check()
+	assert.True(t, health.Available, health.Error)
+
+	checker = newRTSPHealthChecker(d.lc, address, rtspHealthConfig{Timeout: time.Second, Method: RtspHealthCheckMethodOptions})
+	health, _ = checker.check()
+	assert.False(t, health.Available, "the embedded server only accepts RTSPS connections")
+}
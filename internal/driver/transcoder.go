@@ -10,8 +10,10 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 const (
@@ -41,8 +43,25 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 	// these args must be put in the output section and not the first args, so just inject them right before the last
 	// arg which is the rtsp url.
 	command = append(command[0:len(command)-1], "-rtsp_transport", "tcp", command[len(command)-1])
+	// if enabled, the transcoder also writes JPEG frames to a pipe, so that snapshots can be taken while the camera is
+	// streaming. The passthrough does not decode the video, so it never has that output.
+	var snapshotReader, snapshotWriter *os.File
+	if dev.streamSnapshots && dev.streamingStatus.OutputVideoCodec != FFmpegCodecCopy {
+		var err error
+		if snapshotReader, snapshotWriter, err = os.Pipe(); err != nil {
+			dev.lc.Warnf("Snapshots of the stream not available for device %s: %s", dev.name, err.Error())
+		} else {
+			command = insertSnapshotOutput(command, t.MediaFile().VideoFilter())
+		}
+	}
+	dev.snapshotOutput = snapshotWriter != nil
 	ffmpegBin := t.FFmpegExec()
 	proc := exec.Command(ffmpegBin, command...)
+	proc.SysProcAttr = transcoderSysProcAttr()
+	proc.Env = dev.transcoderEnv()
+	if snapshotWriter != nil {
+		proc.ExtraFiles = []*os.File{snapshotWriter}
+	}
 
 	// Set the stdinPipe in case we need to stop the transcoding
 	stdinPipe, err := proc.StdinPipe()
@@ -52,9 +71,13 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 
 	var progress chan string
 	var stdErrLines []string
+	// outputDone is closed once all the output of the process has been processed. The process must not be
+	// waited on before that, as Wait closes the pipe and stdErrLines would be read while it is still written.
+	outputDone := make(chan struct{})
 	stdErrPipe, err := proc.StderrPipe()
 	if err != nil {
 		dev.lc.Errorf("Ffmpeg StderrPipe not available: %s. Unable to track output from process.", err.Error())
+		close(outputDone)
 	} else {
 		output := make(chan string, 10)
 		progress = make(chan string, 10)
@@ -71,7 +94,14 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 				line := redact(scanner.Text())
 				output <- line
 				if strings.HasPrefix(line, "[info] frame=") {
-					progress <- strings.Replace(line, "[info] ", "", 1)
+					dev.mutex.Lock()
+					dev.lastProgressAt = time.Now()
+					dev.mutex.Unlock()
+					// nobody reads the progress once the streaming has started, so do not block the output on it
+					select {
+					case progress <- strings.Replace(line, "[info] ", "", 1):
+					default:
+					}
 				}
 			}
 			dev.lc.Debugf("Output scanner complete for transcoder for device %s", dev.name)
@@ -79,6 +109,7 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 
 		// keep track of stdErr text, so it can be returned to the caller via done channel
 		go func() {
+			defer close(outputDone)
 			for line := range output {
 				// cap the size so that way the memory usage does not grow on commands with lots of output
 				if len(stdErrLines) >= maxStderrLines {
@@ -107,7 +138,15 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 	}
 
 	// attempt to start the process
-	if err = proc.Start(); err != nil {
+	err = startTranscoderProcess(proc)
+	if snapshotWriter != nil {
+		// the process has its own copy of the write end
+		_ = snapshotWriter.Close()
+	}
+	if err != nil {
+		if snapshotReader != nil {
+			_ = snapshotReader.Close()
+		}
 		return nil, nil, fmt.Errorf("failed to start FFMPEG transcoding for device %s (%s) with %s, message %s",
 			dev.name, redact(strings.Join(command, " ")), err, strings.Join(stdErrLines, "\n"))
 	}
@@ -117,6 +156,15 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 	dev.lc.Debugf("Set IsStreaming=true for device %s", dev.name)
 	dev.streamingStatus.IsStreaming = true
 	dev.streamingStatus.Error = ""
+	dev.streamingStatus.StoppedBy = ""
+	dev.stopStage = ""
+	dev.streamStartedAt = time.Now()
+	dev.lastProgressAt = time.Time{}
+	stopped := make(chan struct{})
+	dev.stopped = stopped
+	if snapshotReader != nil {
+		go dev.readStreamSnapshots(snapshotReader, dev.streamingStatus.TranscoderInputPath)
+	}
 
 	dev.lc.Debugf("FFmpeg transcoder process for device %s has started with pid %d", dev.name, proc.Process.Pid)
 
@@ -126,6 +174,7 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 		defer close(done)
 
 		// wait until the process has exited
+		<-outputDone
 		err = proc.Wait()
 		dev.lc.Debugf("FFmpeg process with pid %d for device %s exited with code %d. User time: %v, System time: %v",
 			proc.Process.Pid, dev.name, proc.ProcessState.ExitCode(), proc.ProcessState.UserTime(), proc.ProcessState.SystemTime())
@@ -133,6 +182,8 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 		dev.mutex.Lock()
 		dev.lc.Debugf("Set IsStreaming=false for device %s", dev.name)
 		dev.streamingStatus.IsStreaming = false
+		dev.streamStartedAt = time.Time{}
+		dev.streamingStatus.StoppedBy = dev.stopStage
 
 		// if ffmpeg returned an error, add more details surrounding it
 		if err != nil {
@@ -145,6 +196,7 @@ func (dev *Device) runTranscoderWithOutput() (<-chan string, <-chan error, error
 		t.SetProcess(nil)
 		t.SetProcessStdinPipe(nil)
 		dev.mutex.Unlock()
+		close(stopped)
 		done <- err
 	}()
 
@@ -183,3 +235,13 @@ func scanFFmpegLines(data []byte, atEOF bool) (advance int, token []byte, err er
 	// Request more data.
 	return 0, nil, nil
 }
+
+// transcoderEnv returns the environment of the transcoder process, the caller must hold dev.mutex. The timestamp
+// overlay is drawn in the local time of the process, so its time zone is set by the TZ variable, which applies to
+// all the times ffmpeg outputs in local time, such as the creation_time of the recordings.
+func (dev *Device) transcoderEnv() []string {
+	if dev.overlayTimezone == "" {
+		return nil
+	}
+	return append(os.Environ(), "TZ="+dev.overlayTimezone)
+}
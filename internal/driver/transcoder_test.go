@@ -0,0 +1,247 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"net/http"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTranscoderWithOutput(t *testing.T) {
+	argsFile := installFakeFFmpeg(t, "info starting", "progress", "progress", "wait")
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	progressChan, errChan, err := dev.runTranscoderWithOutput()
+	require.NoError(t, err)
+
+	select {
+	case progress := <-progressChan:
+		assert.Regexp(t, `^frame=\s+1 `, progress)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for progress")
+	}
+	dev.mutex.Lock()
+	assert.True(t, dev.streamingStatus.IsStreaming)
+	dev.mutex.Unlock()
+
+	args := readFakeFFmpegArgs(t, argsFile)
+	require.GreaterOrEqual(t, len(args), 3)
+	assert.Equal(t, []string{"-rtsp_transport", "tcp"}, args[len(args)-3:len(args)-1],
+		"rtsp transport must be injected right before the output url")
+	assert.Contains(t, args[len(args)-1], testRtspPassword)
+
+	dev.StopStreaming()
+	select {
+	case err, ok := <-errChan:
+		require.True(t, ok)
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for the transcoder to exit")
+	}
+	waitForChannelClose(t, errChan)
+
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	assert.False(t, dev.streamingStatus.IsStreaming)
+	assert.Empty(t, dev.streamingStatus.Error)
+	assert.Nil(t, dev.transcoder.Process())
+}
+
+func TestRunTranscoderWithOutputError(t *testing.T) {
+	installFakeFFmpeg(t, "warning deprecated pixel format used", "info not an issue", "error /dev/video0: Device or resource busy", "exit 1")
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	progressChan, errChan, err := dev.runTranscoderWithOutput()
+	require.NoError(t, err)
+
+	var exitErr error
+	select {
+	case exitErr = <-errChan:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for the transcoder to exit")
+	}
+	require.Error(t, exitErr)
+	assert.Contains(t, exitErr.Error(), "exit status 1")
+	assert.Contains(t, exitErr.Error(), "[warning] deprecated pixel format used")
+	assert.Contains(t, exitErr.Error(), "[error] /dev/video0: Device or resource busy")
+	assert.NotContains(t, exitErr.Error(), "not an issue", "info messages should not be reported to the caller")
+	assert.NotContains(t, exitErr.Error(), testRtspPassword, "credentials must be redacted")
+	assert.Contains(t, exitErr.Error(), redactedStr)
+	waitForChannelClose(t, errChan)
+
+	_, ok := <-progressChan
+	assert.False(t, ok, "progress channel should be closed once the process exits")
+
+	dev.mutex.Lock()
+	defer dev.mutex.Unlock()
+	assert.False(t, dev.streamingStatus.IsStreaming)
+	assert.Equal(t, exitErr.Error(), dev.streamingStatus.Error)
+}
+
+func TestStartStreaming(t *testing.T) {
+	installFakeFFmpeg(t, "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	require.NoError(t, d.startStreaming(dev))
+	status := nextStreamingStatus(t, asyncCh)
+	assert.True(t, status.IsStreaming)
+	assert.Equal(t, "/dev/video0", status.TranscoderInputPath)
+
+	edgexErr := d.startStreaming(dev)
+	require.Error(t, edgexErr, "streaming should not start twice")
+	assert.Contains(t, edgexErr.Error(), "already in progress")
+
+	dev.StopStreaming()
+	status = nextStreamingStatus(t, asyncCh)
+	assert.False(t, status.IsStreaming)
+	assert.Empty(t, status.Error)
+}
+
+func TestStartStreamingFailure(t *testing.T) {
+	installFakeFFmpeg(t, "error Could not find video device with pixel format rgb24", "exit 1")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	edgexErr := d.startStreaming(dev)
+	require.Error(t, edgexErr)
+	assert.Contains(t, edgexErr.Error(), "Could not find video device with pixel format rgb24")
+
+	status := nextStreamingStatus(t, asyncCh)
+	assert.False(t, status.IsStreaming)
+	assert.Contains(t, status.Error, "Could not find video device with pixel format rgb24")
+}
+
+func TestStartStreamingWithoutProgress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the startup timeout of startStreaming")
+	}
+	installFakeFFmpeg(t, "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	require.NoError(t, d.startStreaming(dev))
+	assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+
+	dev.StopStreaming()
+	assert.False(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+}
+
+func TestStartStreamingRtspServerDisabled(t *testing.T) {
+	installFakeFFmpeg(t, "progress", "wait")
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.rtspServerMode = RTSPServerModeNone
+	dev := addFakeStreamingDevice(t, d, "camera")
+
+	require.Error(t, d.startStreaming(dev))
+	assert.False(t, dev.streamingStatus.IsStreaming)
+}
+
+func TestStartStreamingAuthentication(t *testing.T) {
+	tests := []struct {
+		name          string
+		publisherUser string
+		publisherPass string
+		expectErr     bool
+	}{
+		{"valid credentials", testRtspUser, testRtspPassword, false},
+		{"wrong password", testRtspUser, "wrong", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeFFmpeg(t, "auth", "progress", "wait")
+			// the publisher uses the credentials of the transcoder, the auth server uses the ones of the secret store
+			publisher, _ := newFakeStreamingDriver(t, tt.publisherUser, tt.publisherPass)
+			d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+			startFakeRTSPAuthServer(t, d)
+			dev := addFakeStreamingDevice(t, publisher, "camera")
+
+			edgexErr := d.startStreaming(dev)
+			status := nextStreamingStatus(t, asyncCh)
+			if tt.expectErr {
+				require.Error(t, edgexErr)
+				assert.Contains(t, edgexErr.Error(), "401 Unauthorized")
+				assert.False(t, status.IsStreaming)
+				return
+			}
+			require.NoError(t, edgexErr)
+			assert.True(t, status.IsStreaming)
+			dev.StopStreaming()
+			assert.False(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+		})
+	}
+}
+
+func TestRTSPCredentialsHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		storedUser     string
+		request        RTSPAuthRequest
+		expectedStatus int
+	}{
+		{"valid credentials", testRtspUser, RTSPAuthRequest{User: testRtspUser, Password: testRtspPassword}, http.StatusOK},
+		{"wrong user", testRtspUser, RTSPAuthRequest{User: "other", Password: testRtspPassword}, http.StatusUnauthorized},
+		{"wrong password", testRtspUser, RTSPAuthRequest{User: testRtspUser, Password: "wrong"}, http.StatusUnauthorized},
+		{"no credentials", testRtspUser, RTSPAuthRequest{}, http.StatusUnauthorized},
+		{"credentials not stored", "", RTSPAuthRequest{User: testRtspUser, Password: testRtspPassword}, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, _ := newFakeStreamingDriver(t, tt.storedUser, testRtspPassword)
+			client := startFakeRTSPAuthServer(t, d)
+			tt.request.Path = path.Join(Stream, "camera")
+			tt.request.Action = "read"
+			status, err := client.Authenticate(tt.request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, status)
+		})
+	}
+}
+
+func TestDriverStop(t *testing.T) {
+	installFakeFFmpeg(t, "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	names := []string{"camera1", "camera2", "camera3"}
+	for _, name := range names {
+		require.NoError(t, d.startStreaming(addFakeStreamingDevice(t, d, name)))
+		assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+	}
+	// a device which is not streaming must not block the shutdown
+	addFakeStreamingDevice(t, d, "idle")
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- d.Stop(false)
+	}()
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timed out waiting for the driver to stop")
+	}
+
+	for _, dev := range d.devices.list() {
+		assert.False(t, dev.isStreaming(), "device %s is still streaming", dev.name)
+	}
+
+	// every streaming device has published its final status by the time Stop returns
+	for range names {
+		select {
+		case asyncValues := <-asyncCh:
+			assert.False(t, asyncValues.CommandValues[0].Value.(StreamingStatus).IsStreaming)
+		default:
+			assert.Fail(t, "final streaming status was not published before Stop returned")
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// FFmpegStopQuitGracePeriod, FFmpegStopInterruptGracePeriod and FFmpegStopTerminateGracePeriod are the driver
+	// configs of the time a transcoder is given to exit after 'q', SIGINT and SIGTERM respectively
+	FFmpegStopQuitGracePeriod             = "FFmpegStopQuitGracePeriod"
+	DefaultFFmpegStopQuitGracePeriod      = 5 * time.Second
+	FFmpegStopInterruptGracePeriod        = "FFmpegStopInterruptGracePeriod"
+	DefaultFFmpegStopInterruptGracePeriod = 3 * time.Second
+	FFmpegStopTerminateGracePeriod        = "FFmpegStopTerminateGracePeriod"
+	DefaultFFmpegStopTerminateGracePeriod = 3 * time.Second
+
+	// the ways a transcoder is stopped, reported as StoppedBy in the StreamingStatus
+	stopStageQuit    = "quit"
+	stopStageSigint  = "SIGINT"
+	stopStageSigterm = "SIGTERM"
+	stopStageSigkill = "SIGKILL"
+
+	// ffmpegKillWait is how long a transcoder is given to exit after SIGKILL, it may take a while if the process
+	// is blocked in the kernel on a dead USB device
+	ffmpegKillWait = 5 * time.Second
+	// streamingStatusPublishTimeout is how long the driver waits for the final status of the transcoders to be
+	// published once they have exited
+	streamingStatusPublishTimeout = 5 * time.Second
+)
+
+// ffmpegStopPolicy configures how long a transcoder is given to exit after each step of the stop sequence,
+// which sends 'q' on its stdin, then SIGINT, then SIGTERM, and finally SIGKILL
+type ffmpegStopPolicy struct {
+	QuitGracePeriod      time.Duration
+	InterruptGracePeriod time.Duration
+	TerminateGracePeriod time.Duration
+}
+
+var defaultFFmpegStopPolicy = ffmpegStopPolicy{
+	QuitGracePeriod:      DefaultFFmpegStopQuitGracePeriod,
+	InterruptGracePeriod: DefaultFFmpegStopInterruptGracePeriod,
+	TerminateGracePeriod: DefaultFFmpegStopTerminateGracePeriod,
+}
+
+func parseFFmpegStopPolicy(configs map[string]string) (ffmpegStopPolicy, error) {
+	policy := defaultFFmpegStopPolicy
+	for key, duration := range map[string]*time.Duration{
+		FFmpegStopQuitGracePeriod:      &policy.QuitGracePeriod,
+		FFmpegStopInterruptGracePeriod: &policy.InterruptGracePeriod,
+		FFmpegStopTerminateGracePeriod: &policy.TerminateGracePeriod,
+	} {
+		if configs[key] == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(configs[key])
+		if err != nil || parsed < 0 {
+			return policy, fmt.Errorf("%s value of \"%s\" is invalid, it must be a duration such as 5s, or 0s to skip the step",
+				key, configs[key])
+		}
+		*duration = parsed
+	}
+	return policy, nil
+}
+
+// timeout returns the longest time the stop sequence can take
+func (p ffmpegStopPolicy) timeout() time.Duration {
+	return p.QuitGracePeriod + p.InterruptGracePeriod + p.TerminateGracePeriod + ffmpegKillWait
+}
+
+// transcoderSysProcAttr runs the transcoder in its own process group, so that the signals of the stop sequence
+// reach every process it has started while the signals sent to the group of the service do not reach it, and
+// makes the kernel kill it if the service dies so that no transcoder is left behind. The transcoders must be
+// started with startTranscoderProcess for the latter to work.
+func transcoderSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+}
+
+// transcoderStarter is the goroutine starting the transcoders. The kernel sends the parent death signal when the
+// thread which has started the process exits rather than the whole service, and the Go runtime ends the thread of
+// a goroutine which exits while locked to it, so the transcoders are all started from a goroutine which is locked
+// to its thread and never exits.
+var transcoderStarter struct {
+	once     sync.Once
+	requests chan transcoderStartRequest
+}
+
+type transcoderStartRequest struct {
+	proc   *exec.Cmd
+	result chan<- error
+}
+
+// startTranscoderProcess starts the process of a transcoder from the thread of transcoderStarter
+func startTranscoderProcess(proc *exec.Cmd) error {
+	transcoderStarter.once.Do(func() {
+		transcoderStarter.requests = make(chan transcoderStartRequest)
+		go func() {
+			// the thread is never unlocked, so no other goroutine runs on it and it lives as long as the service
+			runtime.LockOSThread()
+			for request := range transcoderStarter.requests {
+				request.result <- request.proc.Start()
+			}
+		}()
+	})
+	result := make(chan error, 1)
+	transcoderStarter.requests <- transcoderStartRequest{proc: proc, result: result}
+	return <-result
+}
+
+// stopTranscoder runs the stop sequence of the transcoder until its process has exited, and returns an error
+// if it has not exited even after SIGKILL
+func (dev *Device) stopTranscoder() error {
+	dev.mutex.Lock()
+	process, stopped, policy := dev.transcoder.Process(), dev.stopped, dev.stopPolicy
+	dev.mutex.Unlock()
+	if process == nil || process.Process == nil {
+		return nil
+	}
+	pgid := process.Process.Pid
+	signal := func(sig syscall.Signal) func() error {
+		return func() error { return syscall.Kill(-pgid, sig) }
+	}
+
+	steps := []struct {
+		stage  string
+		send   func() error
+		period time.Duration
+	}{
+		{stopStageQuit, dev.transcoder.Stop, policy.QuitGracePeriod},
+		{stopStageSigint, signal(syscall.SIGINT), policy.InterruptGracePeriod},
+		{stopStageSigterm, signal(syscall.SIGTERM), policy.TerminateGracePeriod},
+		{stopStageSigkill, signal(syscall.SIGKILL), ffmpegKillWait},
+	}
+	for _, step := range steps {
+		select {
+		case <-stopped:
+			return nil
+		default:
+		}
+		dev.lc.Debugf("Stopping transcoder for device %s with %s", dev.name, step.stage)
+		// the stage is recorded before the step, so that it is reported once the process has exited
+		dev.mutex.Lock()
+		dev.stopStage = step.stage
+		err := step.send()
+		dev.mutex.Unlock()
+		if err != nil {
+			dev.lc.Debugf("Failed to stop transcoder for device %s with %s: %v", dev.name, step.stage, err)
+		}
+		if step.period == 0 {
+			continue
+		}
+		select {
+		case <-stopped:
+			if step.stage != stopStageQuit {
+				dev.lc.Warnf("The transcoder for device %s has only stopped after %s", dev.name, step.stage)
+			}
+			return nil
+		case <-time.After(step.period):
+		}
+	}
+	return fmt.Errorf("the transcoder of device %s with pid %d has not exited %s after %s", dev.name, pgid, ffmpegKillWait, stopStageSigkill)
+}
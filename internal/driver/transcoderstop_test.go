@@ -0,0 +1,142 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package driver
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFFmpegStopPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		configs   map[string]string
+		expected  ffmpegStopPolicy
+		expectErr bool
+	}{
+		{"defaults", map[string]string{}, defaultFFmpegStopPolicy, false},
+		{"configured", map[string]string{FFmpegStopQuitGracePeriod: "10s", FFmpegStopInterruptGracePeriod: "0s", FFmpegStopTerminateGracePeriod: "1s"},
+			ffmpegStopPolicy{10 * time.Second, 0, time.Second}, false},
+		{"invalid", map[string]string{FFmpegStopQuitGracePeriod: "soon"}, ffmpegStopPolicy{}, true},
+		{"negative", map[string]string{FFmpegStopTerminateGracePeriod: "-1s"}, ffmpegStopPolicy{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := parseFFmpegStopPolicy(tt.configs)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
+
+func TestStopStreamingEscalation(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     []string
+		stoppedBy string
+	}{
+		{"quit", []string{"progress", "wait"}, stopStageQuit},
+		{"interrupt", []string{"progress", "hang"}, stopStageSigint},
+		{"terminate", []string{"ignore SIGINT", "progress", "hang"}, stopStageSigterm},
+		{"kill", []string{"ignore SIGINT", "ignore SIGTERM", "progress", "hang"}, stopStageSigkill},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installFakeFFmpeg(t, tt.steps...)
+			d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+			d.ffmpegStopPolicy = ffmpegStopPolicy{time.Second, time.Second, time.Second}
+			dev := addFakeStreamingDevice(t, d, "camera")
+			require.NoError(t, d.startStreaming(dev))
+			assert.True(t, nextStreamingStatus(t, asyncCh).IsStreaming)
+
+			start := time.Now()
+			dev.StopStreaming()
+			assert.Less(t, time.Since(start), ffmpegKillWait, "StopStreaming returns once the process has exited")
+			status := d.streamingStatus(dev)
+			assert.False(t, status.IsStreaming)
+			assert.Equal(t, tt.stoppedBy, status.StoppedBy)
+			assert.Equal(t, tt.stoppedBy, nextStreamingStatus(t, asyncCh).StoppedBy, "the published status reports how the process was stopped")
+		})
+	}
+}
+
+func TestTranscoderProcessGroup(t *testing.T) {
+	installFakeFFmpeg(t, "progress", "wait")
+	d, asyncCh := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	dev := addFakeStreamingDevice(t, d, "camera")
+	require.NoError(t, d.startStreaming(dev))
+	nextStreamingStatus(t, asyncCh)
+
+	dev.mutex.Lock()
+	pid := dev.transcoder.Process().Process.Pid
+	dev.mutex.Unlock()
+	pgid, err := syscall.Getpgid(pid)
+	require.NoError(t, err)
+	assert.Equal(t, pid, pgid, "the transcoder leads its own process group")
+	assert.Equal(t, syscall.SIGKILL, transcoderSysProcAttr().Pdeathsig, "the transcoder is killed if the service dies")
+
+	dev.StopStreaming()
+	assert.Equal(t, stopStageQuit, d.streamingStatus(dev).StoppedBy)
+}
+
+func TestTranscoderOutlivesStartingThread(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep is not available")
+	}
+	proc := exec.Command(sleep, "10")
+	proc.SysProcAttr = transcoderSysProcAttr()
+	// the thread of a goroutine which exits while locked to it is ended, which used to kill the process. The main
+	// thread is never ended though, so it is kept busy while the process is started from another thread.
+	started := make(chan error, 1)
+	var start func()
+	start = func() {
+		runtime.LockOSThread()
+		if syscall.Gettid() == syscall.Getpid() {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				start()
+			}()
+			<-done
+			runtime.UnlockOSThread()
+			return
+		}
+		started <- startTranscoderProcess(proc)
+	}
+	go start()
+	require.NoError(t, <-started)
+	defer func() { _ = proc.Process.Kill() }()
+
+	exited := make(chan error, 1)
+	go func() { exited <- proc.Wait() }()
+	select {
+	case err := <-exited:
+		t.Fatalf("the transcoder has been killed when the thread which started it has exited: %v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestWaitForStreamingDeadline(t *testing.T) {
+	d, _ := newFakeStreamingDriver(t, testRtspUser, testRtspPassword)
+	d.wg.Add(1)
+	defer d.wg.Done()
+
+	start := time.Now()
+	d.waitForStreaming(100 * time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second, "a transcoder which never exits does not block the shutdown")
+}
@@ -6,7 +6,11 @@
 
 package driver
 
-import "github.com/vladimirvivien/go4vl/v4l2"
+import (
+	"time"
+
+	"github.com/vladimirvivien/go4vl/v4l2"
+)
 
 type RTSPServerMode string
 
@@ -14,6 +18,18 @@ const (
 	RTSPServerModeInternal RTSPServerMode = "internal"
 	RTSPServerModeExternal RTSPServerMode = "external"
 	RTSPServerModeNone     RTSPServerMode = "none"
+	// RTSPServerModeEmbedded runs the RTSP server in the device service process
+	RTSPServerModeEmbedded RTSPServerMode = "embedded"
+)
+
+// DeviceIdentityMode defines how a device is bound to the physical camera
+type DeviceIdentityMode string
+
+const (
+	// IdentityModeSerial binds a device to the camera with the same card name and serial number
+	IdentityModeSerial DeviceIdentityMode = "serial"
+	// IdentityModePort binds a device to the camera plugged into the same USB port
+	IdentityModePort DeviceIdentityMode = "port"
 )
 
 type RTSPAuthRequest struct {
@@ -68,6 +84,45 @@ type StreamingStatus struct {
 	OutputImageSize     string
 	OutputAspect        string
 	OutputVideoQuality  string
+	// InputPixelFormat is the FFmpeg pixel format the camera is captured in, it is empty if the current format
+	// of the camera is used
+	InputPixelFormat string `json:"InputPixelFormat,omitempty"`
+	// OutputVideoCodec is the FFmpeg codec of the stream, copy publishes the compressed stream of the camera as it is
+	OutputVideoCodec string `json:"OutputVideoCodec,omitempty"`
+	// StoppedBy is how the last transcoder process was stopped: "quit", "SIGINT", "SIGTERM" or "SIGKILL",
+	// it is empty if the process has exited by itself
+	StoppedBy string `json:"StoppedBy,omitempty"`
+	// RtspServer is the health of the external RTSP server, it is only set in the external mode
+	RtspServer *RTSPServerHealth `json:"RtspServer,omitempty"`
+	// Schedule is the state of the streaming schedule, it is only set if the device has one
+	Schedule *StreamingScheduleStatus `json:"Schedule,omitempty"`
+}
+
+// StreamingScheduleStatus is the state of the streaming schedule of a device
+type StreamingScheduleStatus struct {
+	// InWindow is whether the schedule is in a streaming window
+	InWindow bool `json:"inWindow"`
+	// NextTransition is when the schedule next starts or stops the streaming, as told by NextAction
+	NextTransition *time.Time `json:"nextTransition,omitempty"`
+	NextAction     string     `json:"nextAction,omitempty"`
+}
+
+// InputFormat describes a pixel format the camera can capture, along with the frame sizes
+// and frame rates enumerated for it.
+type InputFormat struct {
+	PixelFormat uint32
+	Description string
+	FrameSizes  []InputFrameSize
+}
+
+// InputFrameSize describes a frame size supported by an InputFormat. For discrete frame sizes
+// MinWidth equals MaxWidth and MinHeight equals MaxHeight. FrameRates is only populated when the
+// device reports discrete frame intervals; an empty list means any frame rate within the device
+// limits may be requested.
+type InputFrameSize struct {
+	Type       v4l2.FrameSizeType
+	Size       v4l2.FrameSize
+	FrameRates []v4l2.Fract
 }
 
 var PixelFormatV4l2Mappings = map[string]uint32{
@@ -87,6 +142,16 @@ var PixelFormatV4l2Mappings = map[string]uint32{
 	"Y12I": PixFmtY12I,
 }
 
+// FFmpegPixelFormatV4l2Mappings maps the FFmpeg input pixel formats accepted by parseOptionValue
+// to the v4l2 pixel formats they can be captured from.
+var FFmpegPixelFormatV4l2Mappings = map[string][]uint32{
+	FFmpegPixelFmtRGB24: {v4l2.PixelFmtRGB24},
+	FFmpegPixelFmtGray:  {v4l2.PixelFmtGrey},
+	FFmpegPixelFmtYUYV:  {v4l2.PixelFmtYUYV},
+	FFmpegPixelFmtMJPEG: {v4l2.PixelFmtMJPEG, v4l2.PixelFmtJPEG},
+	FFmpegPixelFmtH264:  {v4l2.PixelFmtH264},
+}
+
 var StreamFormatTypeMap = map[uint32]string{
 	v4l2.PixelFmtRGB24: RGB,
 	v4l2.PixelFmtGrey:  Greyscale,
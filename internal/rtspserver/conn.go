@@ -0,0 +1,398 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rtspserver
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// idleTimeout closes the connections which neither publish nor play a stream, and do not send any request
+	idleTimeout = 60 * time.Second
+	// writeTimeout closes the connections of the readers which do not keep up with the stream
+	writeTimeout = 10 * time.Second
+	// readerQueueSize is the number of packets queued for a reader, the packets are dropped once the queue is full
+	readerQueueSize = 512
+	// sessionTimeout is the timeout of the sessions advertised to the clients, in seconds
+	sessionTimeout = "60"
+	realm          = "device-usb-camera"
+)
+
+// conn is a connection of a publisher or a reader
+type conn struct {
+	server     *Server
+	netConn    net.Conn
+	reader     *bufio.Reader
+	remoteAddr string
+	remoteIP   string
+
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+	done       chan struct{}
+
+	session string
+	// authorized are the actions on the paths the connection has been authorized for
+	authorized map[string]bool
+
+	// publishing is the stream announced by the connection
+	publishing *stream
+	// publisherMedia maps the RTP channels of the publisher to the media of the stream
+	publisherMedia map[int]int
+	recording      bool
+
+	// reading is the stream set up by the connection
+	reading *stream
+	// readerChannels maps the media of the stream to the RTP channels of the reader
+	readerChannels map[int]int
+	playing        bool
+	packets        chan []byte
+	writerDone     chan struct{}
+}
+
+func newConn(server *Server, netConn net.Conn) *conn {
+	remoteAddr := netConn.RemoteAddr().String()
+	remoteIP, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		remoteIP = remoteAddr
+	}
+	return &conn{
+		server:         server,
+		netConn:        netConn,
+		reader:         bufio.NewReader(netConn),
+		remoteAddr:     remoteAddr,
+		remoteIP:       remoteIP,
+		done:           make(chan struct{}),
+		authorized:     make(map[string]bool),
+		publisherMedia: make(map[int]int),
+		readerChannels: make(map[int]int),
+	}
+}
+
+// run serves the requests and the packets of the connection until it is closed
+func (c *conn) run() {
+	defer c.cleanup()
+	lc := c.server.lc
+	for {
+		// the publishers and readers may not send anything for a while once the stream has started
+		var deadline time.Time
+		if !c.recording && !c.playing {
+			deadline = time.Now().Add(idleTimeout)
+		}
+		if err := c.netConn.SetReadDeadline(deadline); err != nil {
+			return
+		}
+
+		magic, err := c.reader.Peek(1)
+		if err != nil {
+			c.logReadError(err)
+			return
+		}
+		if magic[0] == interleavedMagic {
+			channel, payload, err := readInterleavedFrame(c.reader)
+			if err != nil {
+				c.logReadError(err)
+				return
+			}
+			c.handlePacket(channel, payload)
+			continue
+		}
+
+		req, err := readRequest(c.reader)
+		if err != nil {
+			c.logReadError(err)
+			return
+		}
+		lc.Debugf("rtsp server: %s %s from %s", req.method, req.path(), c.remoteAddr)
+		startPlaying := false
+		resp := c.handle(req, &startPlaying)
+		if err := c.write(func(writer io.Writer) error { return resp.write(writer, req.header.Get("CSeq")) }); err != nil {
+			lc.Debugf("rtsp server: failed to write the response to %s, error: %s", c.remoteAddr, err.Error())
+			return
+		}
+		if startPlaying {
+			// the packets must follow the response of the PLAY request
+			c.startPlaying()
+		}
+		if req.method == "TEARDOWN" {
+			return
+		}
+	}
+}
+
+func (c *conn) logReadError(err error) {
+	if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		return
+	}
+	c.server.lc.Debugf("rtsp server: closing the connection of %s, error: %s", c.remoteAddr, err.Error())
+}
+
+// handle handles a request and returns its response
+func (c *conn) handle(req *request, startPlaying *bool) *response {
+	if session := req.header.Get("Session"); session != "" && req.method != "OPTIONS" {
+		id, _, _ := strings.Cut(session, ";")
+		if id != c.session {
+			return newResponse(StatusSessionNotFound)
+		}
+	}
+
+	switch req.method {
+	case "OPTIONS":
+		return newResponse(StatusOK).set("Public", "OPTIONS, DESCRIBE, ANNOUNCE, SETUP, PLAY, RECORD, TEARDOWN, GET_PARAMETER, SET_PARAMETER")
+	case "ANNOUNCE":
+		return c.handleAnnounce(req)
+	case "DESCRIBE":
+		return c.handleDescribe(req)
+	case "SETUP":
+		return c.handleSetup(req)
+	case "PLAY":
+		return c.handlePlay(startPlaying)
+	case "RECORD":
+		return c.handleRecord()
+	case "TEARDOWN", "GET_PARAMETER", "SET_PARAMETER":
+		return newResponse(StatusOK)
+	default:
+		return newResponse(StatusNotImplemented)
+	}
+}
+
+// authorize authenticates the connection for the action on the path, and returns the response asking for
+// the credentials if they are missing or wrong
+func (c *conn) authorize(req *request, path, action string) *response {
+	key := action + " " + path
+	if c.authorized[key] {
+		return nil
+	}
+	user, password, _ := req.basicAuth()
+	if !c.server.authenticate(AuthRequest{
+		IP:       c.remoteIP,
+		User:     user,
+		Password: password,
+		Path:     path,
+		Action:   action,
+		Query:    req.url.RawQuery,
+	}) {
+		return newResponse(StatusUnauthorized).set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", realm))
+	}
+	c.authorized[key] = true
+	return nil
+}
+
+func (c *conn) handleAnnounce(req *request) *response {
+	if c.publishing != nil || c.reading != nil {
+		return newResponse(StatusMethodNotValidInThisState)
+	}
+	path := req.path()
+	if resp := c.authorize(req, path, ActionPublish); resp != nil {
+		return resp
+	}
+	if !strings.HasPrefix(req.header.Get("Content-Type"), "application/sdp") {
+		return newResponse(StatusBadRequest)
+	}
+	st := newStream(path, req.body, c.remoteAddr)
+	if len(st.controls) == 0 {
+		return newResponse(StatusBadRequest)
+	}
+	if !c.server.addStream(st) {
+		c.server.lc.Warnf("rtsp server: %s cannot publish to %s, which already has a publisher", c.remoteAddr, path)
+		return newResponse(StatusBadRequest)
+	}
+	c.publishing = st
+	c.server.lc.Infof("rtsp server: %s is publishing to %s", c.remoteAddr, path)
+	return newResponse(StatusOK)
+}
+
+func (c *conn) handleDescribe(req *request) *response {
+	path := req.path()
+	if resp := c.authorize(req, path, ActionRead); resp != nil {
+		return resp
+	}
+	st, rest := c.server.waitStream(path, c.done)
+	if st == nil || rest != "" {
+		return newResponse(StatusNotFound)
+	}
+	base := *req.url
+	base.User = nil
+	base.RawQuery = ""
+	return &response{
+		status: StatusOK,
+		header: [][2]string{
+			{"Content-Type", "application/sdp"},
+			{"Content-Base", strings.TrimSuffix(base.String(), "/") + "/"},
+		},
+		body: st.sdp,
+	}
+}
+
+func (c *conn) handleSetup(req *request) *response {
+	tr := parseTransport(req.header.Get("Transport"))
+	if !tr.tcp {
+		return newResponse(StatusUnsupportedTransport)
+	}
+	if c.recording || c.playing {
+		return newResponse(StatusMethodNotValidInThisState)
+	}
+
+	path := req.path()
+	var media int
+	if st := c.publishing; st != nil {
+		rest, ok := strings.CutPrefix(path, st.path+"/")
+		if !ok && path != st.path {
+			return newResponse(StatusNotFound)
+		}
+		if media = st.media(rest); media < 0 {
+			return newResponse(StatusNotFound)
+		}
+		if tr.interleaved < 0 {
+			tr.interleaved = 2 * media
+		}
+		c.publisherMedia[tr.interleaved] = media
+	} else {
+		st, rest := c.server.findStream(path)
+		if st == nil {
+			return newResponse(StatusNotFound)
+		}
+		if resp := c.authorize(req, st.path, ActionRead); resp != nil {
+			return resp
+		}
+		if c.reading != nil && c.reading != st {
+			return newResponse(StatusBadRequest)
+		}
+		if media = st.media(rest); media < 0 {
+			return newResponse(StatusNotFound)
+		}
+		if tr.interleaved < 0 {
+			tr.interleaved = 2 * media
+		}
+		c.reading = st
+		c.readerChannels[media] = tr.interleaved
+	}
+
+	if c.session == "" {
+		c.session = newSessionID()
+	}
+	return newResponse(StatusOK).
+		set("Transport", fmt.Sprintf("RTP/AVP/TCP;unicast;interleaved=%d-%d", tr.interleaved, tr.interleaved+1)).
+		set("Session", c.session+";timeout="+sessionTimeout)
+}
+
+func (c *conn) handlePlay(startPlaying *bool) *response {
+	if c.reading == nil || len(c.readerChannels) == 0 {
+		return newResponse(StatusMethodNotValidInThisState)
+	}
+	if st, _ := c.server.findStream(c.reading.path); st != c.reading {
+		// the publisher has gone since the setup
+		return newResponse(StatusNotFound)
+	}
+	if !c.playing {
+		c.playing = true
+		*startPlaying = true
+	}
+	return newResponse(StatusOK).set("Session", c.session).set("Range", "npt=0.000-")
+}
+
+func (c *conn) handleRecord() *response {
+	if c.publishing == nil || len(c.publisherMedia) == 0 {
+		return newResponse(StatusMethodNotValidInThisState)
+	}
+	c.recording = true
+	return newResponse(StatusOK).set("Session", c.session)
+}
+
+// handlePacket relays the RTP and RTCP packets of a publisher, the packets of the readers are ignored
+func (c *conn) handlePacket(channel int, payload []byte) {
+	if !c.recording {
+		return
+	}
+	if media, ok := c.publisherMedia[channel]; ok {
+		c.publishing.forward(media, false, payload)
+	} else if media, ok := c.publisherMedia[channel-1]; ok {
+		c.publishing.forward(media, true, payload)
+	}
+}
+
+// startPlaying starts writing the packets of the stream to the reader
+func (c *conn) startPlaying() {
+	c.packets = make(chan []byte, readerQueueSize)
+	c.writerDone = make(chan struct{})
+	go func() {
+		defer close(c.writerDone)
+		for {
+			select {
+			case <-c.done:
+				return
+			case frame := <-c.packets:
+				if err := c.write(func(writer io.Writer) error {
+					_, err := writer.Write(frame)
+					return err
+				}); err != nil {
+					c.server.lc.Debugf("rtsp server: failed to write to the reader %s, error: %s", c.remoteAddr, err.Error())
+					c.close()
+					return
+				}
+			}
+		}
+	}()
+	c.reading.addReader(c)
+	c.server.lc.Infof("rtsp server: %s is reading %s", c.remoteAddr, c.reading.path)
+}
+
+// send queues a packet for the reader, the packet is dropped if the reader does not keep up
+func (c *conn) send(frame []byte) {
+	select {
+	case c.packets <- frame:
+	default:
+	}
+}
+
+func (c *conn) write(writeFunc func(writer io.Writer) error) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if err := c.netConn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return writeFunc(c.netConn)
+}
+
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.netConn.Close()
+	})
+}
+
+// cleanup releases the stream published or read by the connection once it has been closed
+func (c *conn) cleanup() {
+	c.close()
+	if c.writerDone != nil {
+		<-c.writerDone
+	}
+	if st := c.publishing; st != nil {
+		c.server.removeStream(st)
+		st.closeReaders()
+		c.server.lc.Infof("rtsp server: %s has stopped publishing to %s", c.remoteAddr, st.path)
+	}
+	if st := c.reading; st != nil && c.playing {
+		st.removeReader(c)
+		c.server.lc.Infof("rtsp server: %s has stopped reading %s", c.remoteAddr, st.path)
+	}
+	c.server.removeConn(c)
+}
+
+func newSessionID() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
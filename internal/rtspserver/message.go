@@ -0,0 +1,224 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rtspserver
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	rtspVersion = "RTSP/1.0"
+	// maxBodySize is the maximum size of the body of a request, which is the SDP of an ANNOUNCE request
+	maxBodySize = 64 * 1024
+	// interleavedMagic starts the RTP and RTCP packets interleaved with the RTSP messages on the connection
+	interleavedMagic = '$'
+)
+
+// RTSP status codes, see https://datatracker.ietf.org/doc/html/rfc2326#section-7.1.1
+const (
+	StatusOK                        = 200
+	StatusBadRequest                = 400
+	StatusUnauthorized              = 401
+	StatusNotFound                  = 404
+	StatusSessionNotFound           = 454
+	StatusMethodNotValidInThisState = 455
+	StatusUnsupportedTransport      = 461
+	StatusInternalServerError       = 500
+	StatusNotImplemented            = 501
+)
+
+var statusText = map[int]string{
+	StatusOK:                        "OK",
+	StatusBadRequest:                "Bad Request",
+	StatusUnauthorized:              "Unauthorized",
+	StatusNotFound:                  "Not Found",
+	StatusSessionNotFound:           "Session Not Found",
+	StatusMethodNotValidInThisState: "Method Not Valid in This State",
+	StatusUnsupportedTransport:      "Unsupported Transport",
+	StatusInternalServerError:       "Internal Server Error",
+	StatusNotImplemented:            "Not Implemented",
+}
+
+// request is an RTSP request
+type request struct {
+	method string
+	url    *url.URL
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// path returns the path of the request url without the leading and trailing slashes
+func (req *request) path() string {
+	return strings.Trim(req.url.Path, "/")
+}
+
+// basicAuth returns the credentials of the Basic authorization header of the request
+func (req *request) basicAuth() (string, string, bool) {
+	scheme, encoded, ok := strings.Cut(req.header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	user, password, ok := strings.Cut(string(decoded), ":")
+	return user, password, ok
+}
+
+// readRequest reads an RTSP request from the reader
+func readRequest(reader *bufio.Reader) (*request, error) {
+	tp := textproto.NewReader(reader)
+	line, err := tp.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+	method, rest, ok1 := strings.Cut(line, " ")
+	rawURL, version, ok2 := strings.Cut(rest, " ")
+	if !ok1 || !ok2 || version != rtspVersion {
+		return nil, fmt.Errorf("malformed request line %q", line)
+	}
+	requestURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("malformed request url %q: %w", rawURL, err)
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &request{method: method, url: requestURL, header: header}
+	if value := header.Get("Content-Length"); value != "" {
+		length, err := strconv.Atoi(value)
+		if err != nil || length < 0 || length > maxBodySize {
+			return nil, fmt.Errorf("invalid content length %q", value)
+		}
+		req.body = make([]byte, length)
+		if _, err := io.ReadFull(reader, req.body); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// response is an RTSP response
+type response struct {
+	status int
+	// header are the header fields in the order they are written
+	header [][2]string
+	body   []byte
+}
+
+func newResponse(status int) *response {
+	return &response{status: status}
+}
+
+func (resp *response) set(key, value string) *response {
+	resp.header = append(resp.header, [2]string{key, value})
+	return resp
+}
+
+// write writes the response to the request with the specified sequence number
+func (resp *response) write(writer io.Writer, cseq string) error {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s %d %s\r\n", rtspVersion, resp.status, statusText[resp.status])
+	fmt.Fprintf(&builder, "CSeq: %s\r\n", cseq)
+	builder.WriteString("Server: device-usb-camera\r\n")
+	for _, field := range resp.header {
+		fmt.Fprintf(&builder, "%s: %s\r\n", field[0], field[1])
+	}
+	if len(resp.body) > 0 {
+		fmt.Fprintf(&builder, "Content-Length: %d\r\n", len(resp.body))
+	}
+	builder.WriteString("\r\n")
+	builder.Write(resp.body)
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// interleavedFrame returns an RTP or RTCP packet interleaved on the specified channel, see
+// https://datatracker.ietf.org/doc/html/rfc2326#section-10.12
+func interleavedFrame(channel int, payload []byte) []byte {
+	frame := make([]byte, 4+len(payload))
+	frame[0] = interleavedMagic
+	frame[1] = byte(channel)
+	frame[2] = byte(len(payload) >> 8)
+	frame[3] = byte(len(payload))
+	copy(frame[4:], payload)
+	return frame
+}
+
+// readInterleavedFrame reads an interleaved RTP or RTCP packet, and returns its channel and payload
+func readInterleavedFrame(reader *bufio.Reader) (int, []byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, int(header[2])<<8|int(header[3]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return 0, nil, err
+	}
+	return int(header[1]), payload, nil
+}
+
+// transport is the transport requested by a SETUP request
+type transport struct {
+	tcp bool
+	// interleaved is the RTP channel, the RTCP channel is the following one. It is negative if not specified.
+	interleaved int
+}
+
+// parseTransport parses the Transport header of a SETUP request, and returns the first TCP transport
+// or the first transport if none is TCP
+func parseTransport(value string) transport {
+	var transports []transport
+	for _, spec := range strings.Split(value, ",") {
+		result := transport{interleaved: -1}
+		for i, param := range strings.Split(spec, ";") {
+			param = strings.TrimSpace(param)
+			if i == 0 {
+				result.tcp = strings.EqualFold(param, "RTP/AVP/TCP")
+				continue
+			}
+			key, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(key, "interleaved") {
+				rtp, _, _ := strings.Cut(value, "-")
+				if channel, err := strconv.Atoi(rtp); err == nil && channel >= 0 && channel < 255 {
+					result.interleaved = channel
+				}
+			}
+		}
+		if result.tcp {
+			return result
+		}
+		transports = append(transports, result)
+	}
+	return transports[0]
+}
+
+// parseSDPControls returns the control attribute of each media of the SDP, which identifies the media in
+// the SETUP requests. The control is empty for the media without control attribute.
+func parseSDPControls(sdp []byte) []string {
+	var controls []string
+	for _, line := range strings.Split(string(sdp), "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "m="):
+			controls = append(controls, "")
+		case strings.HasPrefix(line, "a=control:") && len(controls) > 0:
+			controls[len(controls)-1] = strings.TrimPrefix(line, "a=control:")
+		}
+	}
+	return controls
+}
@@ -0,0 +1,388 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rtspserver implements a minimal RTSP server, which relays the streams published by ffmpeg to the readers.
+// Only the TCP interleaved transport is supported, which is the one used by the transcoders of the device service.
+package rtspserver
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+)
+
+// The actions of the authentication requests
+const (
+	ActionPublish = "publish"
+	ActionRead    = "read"
+)
+
+// AuthRequest is a request to publish or read the stream on a path
+type AuthRequest struct {
+	IP       string
+	User     string
+	Password string
+	Path     string
+	Action   string
+	Query    string
+}
+
+// Authenticator returns whether the request is allowed
+type Authenticator func(req AuthRequest) bool
+
+// PublisherWait returns how long a reader of a path without publisher waits for the publisher, e.g. for the streams
+// started on demand by their first reader. The readers do not wait if it returns 0.
+type PublisherWait func(path string) time.Duration
+
+// StreamInfo describes a stream being published
+type StreamInfo struct {
+	Path string
+	// Publisher is the address of the publisher
+	Publisher string
+	// Readers are the addresses of the readers playing the stream
+	Readers []string
+	Since   time.Time
+}
+
+// Server is an RTSP server which relays the streams published on its paths to the readers of the same paths
+type Server struct {
+	lc           logger.LoggingClient
+	authenticate Authenticator
+
+	mutex         sync.Mutex
+	listener      net.Listener
+	streams       map[string]*stream
+	publisherWait PublisherWait
+	// published is closed and replaced whenever a stream is added, which wakes up the readers waiting for it
+	published chan struct{}
+	conns     map[*conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewServer creates an RTSP server which authenticates the publishers and readers with the authenticator
+func NewServer(lc logger.LoggingClient, authenticate Authenticator) *Server {
+	return &Server{
+		lc:           lc,
+		authenticate: authenticate,
+		streams:      make(map[string]*stream),
+		published:    make(chan struct{}),
+		conns:        make(map[*conn]struct{}),
+	}
+}
+
+// SetPublisherWait sets how long the readers wait for the publisher of the paths without publisher
+func (s *Server) SetPublisherWait(wait PublisherWait) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.publisherWait = wait
+}
+
+// Start listens on the TCP address and serves the connections in the background
+func (s *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.serveListener(listener)
+}
+
+// StartTLS listens on the TCP address and serves the RTSPS connections in the background
+func (s *Server) StartTLS(address string, config *tls.Config) error {
+	listener, err := tls.Listen("tcp", address, config)
+	if err != nil {
+		return err
+	}
+	return s.serveListener(listener)
+}
+
+func (s *Server) serveListener(listener net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		_ = listener.Close()
+		return errors.New("the rtsp server is closed")
+	}
+	s.listener = listener
+	s.wg.Add(1)
+	s.mutex.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		s.serve(listener)
+	}()
+	return nil
+}
+
+// Addr returns the address the server listens on, or nil if it has not been started
+func (s *Server) Addr() net.Addr {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+func (s *Server) serve(listener net.Listener) {
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			s.mutex.Lock()
+			closed := s.closed
+			s.mutex.Unlock()
+			if !closed {
+				s.lc.Errorf("rtsp server stopped accepting connections, error: %s", err.Error())
+			}
+			return
+		}
+
+		c := newConn(s, netConn)
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			_ = netConn.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mutex.Unlock()
+		go func() {
+			defer s.wg.Done()
+			c.run()
+		}()
+	}
+}
+
+// Close stops the server and closes all its connections
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for c := range s.conns {
+		c.close()
+	}
+	s.mutex.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// Streams returns the streams being published, sorted by path
+func (s *Server) Streams() []StreamInfo {
+	s.mutex.Lock()
+	streams := make([]*stream, 0, len(s.streams))
+	for _, st := range s.streams {
+		streams = append(streams, st)
+	}
+	s.mutex.Unlock()
+
+	infos := make([]StreamInfo, 0, len(streams))
+	for _, st := range streams {
+		infos = append(infos, st.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Path < infos[j].Path })
+	return infos
+}
+
+// Stream returns the stream being published on the path
+func (s *Server) Stream(path string) (StreamInfo, bool) {
+	s.mutex.Lock()
+	st, ok := s.streams[path]
+	s.mutex.Unlock()
+	if !ok {
+		return StreamInfo{}, false
+	}
+	return st.info(), true
+}
+
+// addStream adds the stream announced by a publisher, unless the path already has a publisher
+func (s *Server) addStream(st *stream) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, exists := s.streams[st.path]; exists {
+		return false
+	}
+	s.streams[st.path] = st
+	close(s.published)
+	s.published = make(chan struct{})
+	return true
+}
+
+// removeStream removes the stream once its publisher has disconnected
+func (s *Server) removeStream(st *stream) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.streams[st.path] == st {
+		delete(s.streams, st.path)
+	}
+}
+
+// findStream returns the stream whose path is the url path or a prefix of it, along with the rest of the url path
+// which identifies a media of the stream
+func (s *Server) findStream(urlPath string) (*stream, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.findStreamLocked(urlPath)
+}
+
+// waitStream returns the stream of the url path as findStream does, waiting for its publisher if the path has
+// none for up to the time set by the PublisherWait of the server, or until done is closed
+func (s *Server) waitStream(urlPath string, done <-chan struct{}) (*stream, string) {
+	var timeout <-chan time.Time
+	for {
+		s.mutex.Lock()
+		st, rest := s.findStreamLocked(urlPath)
+		published := s.published
+		publisherWait := s.publisherWait
+		s.mutex.Unlock()
+		if st != nil {
+			return st, rest
+		}
+		if timeout == nil {
+			if publisherWait == nil {
+				return nil, ""
+			}
+			wait := publisherWait(urlPath)
+			if wait <= 0 {
+				return nil, ""
+			}
+			s.lc.Debugf("rtsp server: waiting up to %s for the publisher of %s", wait, urlPath)
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case <-published:
+		case <-timeout:
+			return nil, ""
+		case <-done:
+			return nil, ""
+		}
+	}
+}
+
+// findStreamLocked is findStream for the callers holding the mutex of the server
+func (s *Server) findStreamLocked(urlPath string) (*stream, string) {
+	if st, ok := s.streams[urlPath]; ok {
+		return st, ""
+	}
+	for path, st := range s.streams {
+		if rest, ok := strings.CutPrefix(urlPath, path+"/"); ok {
+			return st, rest
+		}
+	}
+	return nil, ""
+}
+
+func (s *Server) removeConn(c *conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.conns, c)
+}
+
+// stream is a stream published on a path
+type stream struct {
+	path      string
+	sdp       []byte
+	controls  []string
+	publisher string
+	since     time.Time
+
+	mutex   sync.Mutex
+	readers map[*conn]struct{}
+}
+
+func newStream(path string, sdp []byte, publisher string) *stream {
+	return &stream{
+		path:      path,
+		sdp:       sdp,
+		controls:  parseSDPControls(sdp),
+		publisher: publisher,
+		since:     time.Now(),
+		readers:   make(map[*conn]struct{}),
+	}
+}
+
+// media returns the index of the media identified by the rest of a SETUP url, or -1 if there is no such media
+func (st *stream) media(rest string) int {
+	for i, control := range st.controls {
+		if control == "" {
+			continue
+		}
+		if control == rest || strings.HasSuffix(control, "/"+st.path+"/"+rest) {
+			return i
+		}
+	}
+	if rest == "" && len(st.controls) == 1 {
+		return 0
+	}
+	return -1
+}
+
+func (st *stream) info() StreamInfo {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	info := StreamInfo{Path: st.path, Publisher: st.publisher, Since: st.since, Readers: []string{}}
+	for reader := range st.readers {
+		info.Readers = append(info.Readers, reader.remoteAddr)
+	}
+	sort.Strings(info.Readers)
+	return info
+}
+
+func (st *stream) addReader(c *conn) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	st.readers[c] = struct{}{}
+}
+
+func (st *stream) removeReader(c *conn) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	delete(st.readers, c)
+}
+
+// forward relays a packet of a media of the stream to the readers which have set up the media
+func (st *stream) forward(media int, rtcp bool, payload []byte) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	for reader := range st.readers {
+		channel, ok := reader.readerChannels[media]
+		if !ok {
+			continue
+		}
+		if rtcp {
+			channel++
+		}
+		reader.send(interleavedFrame(channel, payload))
+	}
+}
+
+// closeReaders disconnects the readers once the publisher has disconnected
+func (st *stream) closeReaders() {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	for reader := range st.readers {
+		reader.close()
+	}
+}
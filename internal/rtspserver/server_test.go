@@ -0,0 +1,299 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+//
+// Copyright (C) 2025 IOTech Ltd
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rtspserver
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v4/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUser     = "user"
+	testPassword = "password"
+	testSDP      = "v=0\r\no=- 0 0 IN IP4 127.0.0.1\r\ns=No Name\r\nt=0 0\r\n" +
+		"m=video 0 RTP/AVP 96\r\na=rtpmap:96 H264/90000\r\na=control:streamid=0\r\n"
+)
+
+// testClient is an RTSP client sending the requests the same way as ffmpeg does
+type testClient struct {
+	t       *testing.T
+	conn    net.Conn
+	reader  *bufio.Reader
+	cseq    int
+	user    string
+	session string
+}
+
+type testResponse struct {
+	status int
+	header textproto.MIMEHeader
+	body   string
+}
+
+func newTestClient(t *testing.T, server *Server, user string) *testClient {
+	conn, err := net.Dial("tcp", server.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return &testClient{t: t, conn: conn, reader: bufio.NewReader(conn), user: user}
+}
+
+// request sends a request, and sends it again with the credentials if the server asks for them
+func (c *testClient) request(method, path string, header map[string]string, body string) testResponse {
+	resp := c.send(method, path, header, body, false)
+	if resp.status == StatusUnauthorized && c.user != "" {
+		assert.Contains(c.t, resp.header.Get("WWW-Authenticate"), "Basic")
+		resp = c.send(method, path, header, body, true)
+	}
+	if session := resp.header.Get("Session"); session != "" {
+		c.session, _, _ = strings.Cut(session, ";")
+	}
+	return resp
+}
+
+func (c *testClient) send(method, path string, header map[string]string, body string, withCredentials bool) testResponse {
+	c.cseq++
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s rtsp://%s/%s RTSP/1.0\r\nCSeq: %d\r\n", method, c.conn.RemoteAddr(), path, c.cseq)
+	if withCredentials {
+		credentials := base64.StdEncoding.EncodeToString([]byte(c.user + ":" + testPassword))
+		builder.WriteString("Authorization: Basic " + credentials + "\r\n")
+	}
+	if c.session != "" {
+		fmt.Fprintf(&builder, "Session: %s\r\n", c.session)
+	}
+	for key, value := range header {
+		fmt.Fprintf(&builder, "%s: %s\r\n", key, value)
+	}
+	if body != "" {
+		fmt.Fprintf(&builder, "Content-Length: %d\r\n", len(body))
+	}
+	builder.WriteString("\r\n" + body)
+	_, err := c.conn.Write([]byte(builder.String()))
+	require.NoError(c.t, err)
+
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	tp := textproto.NewReader(c.reader)
+	line, err := tp.ReadLine()
+	require.NoError(c.t, err)
+	fields := strings.SplitN(line, " ", 3)
+	require.Len(c.t, fields, 3)
+	assert.Equal(c.t, rtspVersion, fields[0])
+	status, err := strconv.Atoi(fields[1])
+	require.NoError(c.t, err)
+	respHeader, err := tp.ReadMIMEHeader()
+	require.NoError(c.t, err)
+	assert.Equal(c.t, strconv.Itoa(c.cseq), respHeader.Get("CSeq"))
+	resp := testResponse{status: status, header: respHeader}
+	if length := respHeader.Get("Content-Length"); length != "" {
+		size, err := strconv.Atoi(length)
+		require.NoError(c.t, err)
+		data := make([]byte, size)
+		_, err = io.ReadFull(c.reader, data)
+		require.NoError(c.t, err)
+		resp.body = string(data)
+	}
+	return resp
+}
+
+func (c *testClient) readFrame() (int, []byte) {
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	magic, err := c.reader.Peek(1)
+	require.NoError(c.t, err)
+	require.Equal(c.t, byte(interleavedMagic), magic[0])
+	channel, payload, err := readInterleavedFrame(c.reader)
+	require.NoError(c.t, err)
+	return channel, payload
+}
+
+func (c *testClient) writeFrame(channel int, payload []byte) {
+	_, err := c.conn.Write(interleavedFrame(channel, payload))
+	require.NoError(c.t, err)
+}
+
+// startTestServer starts a server accepting the test credentials, and records the authentication requests
+func startTestServer(t *testing.T) (*Server, func() []AuthRequest) {
+	var mutex sync.Mutex
+	var requests []AuthRequest
+	server := NewServer(logger.MockLogger{}, func(req AuthRequest) bool {
+		mutex.Lock()
+		requests = append(requests, req)
+		mutex.Unlock()
+		return req.User == testUser && req.Password == testPassword
+	})
+	require.NoError(t, server.Start("127.0.0.1:0"))
+	t.Cleanup(func() { _ = server.Close() })
+	return server, func() []AuthRequest {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]AuthRequest(nil), requests...)
+	}
+}
+
+// publish publishes the test stream on the path
+func publish(t *testing.T, server *Server, path string) *testClient {
+	publisher := newTestClient(t, server, testUser)
+	resp := publisher.request("ANNOUNCE", path, map[string]string{"Content-Type": "application/sdp"}, testSDP)
+	require.Equal(t, StatusOK, resp.status)
+	resp = publisher.request("SETUP", path+"/streamid=0", map[string]string{"Transport": "RTP/AVP/TCP;unicast;interleaved=0-1;mode=record"}, "")
+	require.Equal(t, StatusOK, resp.status)
+	assert.Equal(t, "RTP/AVP/TCP;unicast;interleaved=0-1", resp.header.Get("Transport"))
+	require.Equal(t, StatusOK, publisher.request("RECORD", path, nil, "").status)
+	return publisher
+}
+
+func TestServerRelaysStream(t *testing.T) {
+	server, authRequests := startTestServer(t)
+	publisher := publish(t, server, "stream/camera")
+
+	reader := newTestClient(t, server, testUser)
+	resp := reader.request("DESCRIBE", "stream/camera?token=abc", nil, "")
+	require.Equal(t, StatusOK, resp.status)
+	assert.Equal(t, "application/sdp", resp.header.Get("Content-Type"))
+	assert.Equal(t, testSDP, resp.body)
+	resp = reader.request("SETUP", "stream/camera/streamid=0", map[string]string{"Transport": "RTP/AVP/TCP;unicast;interleaved=4-5"}, "")
+	require.Equal(t, StatusOK, resp.status)
+	assert.Equal(t, "RTP/AVP/TCP;unicast;interleaved=4-5", resp.header.Get("Transport"))
+	require.Equal(t, StatusOK, reader.request("PLAY", "stream/camera", nil, "").status)
+
+	require.Eventually(t, func() bool {
+		info, ok := server.Stream("stream/camera")
+		return ok && len(info.Readers) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	info, _ := server.Stream("stream/camera")
+	assert.Equal(t, []string{reader.conn.LocalAddr().String()}, info.Readers)
+	assert.Equal(t, publisher.conn.LocalAddr().String(), info.Publisher)
+
+	publisher.writeFrame(0, []byte("rtp"))
+	publisher.writeFrame(1, []byte("rtcp"))
+	publisher.writeFrame(2, []byte("unknown channel"))
+	channel, payload := reader.readFrame()
+	assert.Equal(t, 4, channel)
+	assert.Equal(t, "rtp", string(payload))
+	channel, payload = reader.readFrame()
+	assert.Equal(t, 5, channel)
+	assert.Equal(t, "rtcp", string(payload))
+
+	var actions []string
+	for _, req := range authRequests() {
+		if req.User != "" {
+			actions = append(actions, req.Action+" "+req.Path)
+		}
+	}
+	assert.Equal(t, []string{"publish stream/camera", "read stream/camera"}, actions,
+		"the connections are authenticated once per path and action")
+	assert.Contains(t, authRequests(), AuthRequest{IP: "127.0.0.1", User: testUser, Password: testPassword,
+		Path: "stream/camera", Action: ActionRead, Query: "token=abc"})
+
+	// the readers are disconnected once the publisher is gone
+	require.NoError(t, publisher.conn.Close())
+	require.NoError(t, reader.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err := reader.reader.ReadByte()
+	assert.Error(t, err)
+	assert.Eventually(t, func() bool { return len(server.Streams()) == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServerRejectsRequests(t *testing.T) {
+	server, _ := startTestServer(t)
+	publish(t, server, "stream/camera")
+
+	anonymous := newTestClient(t, server, "")
+	resp := anonymous.request("DESCRIBE", "stream/camera", nil, "")
+	assert.Equal(t, StatusUnauthorized, resp.status)
+	assert.Equal(t, `Basic realm="device-usb-camera"`, resp.header.Get("WWW-Authenticate"))
+
+	intruder := newTestClient(t, server, "intruder")
+	assert.Equal(t, StatusUnauthorized, intruder.request("ANNOUNCE", "stream/other", map[string]string{"Content-Type": "application/sdp"}, testSDP).status)
+
+	client := newTestClient(t, server, testUser)
+	assert.Equal(t, StatusNotFound, client.request("DESCRIBE", "stream/unknown", nil, "").status)
+	assert.Equal(t, StatusUnsupportedTransport, client.request("SETUP", "stream/camera/streamid=0", map[string]string{"Transport": "RTP/AVP;unicast;client_port=5000-5001"}, "").status)
+	assert.Equal(t, StatusMethodNotValidInThisState, client.request("PLAY", "stream/camera", nil, "").status)
+	assert.Equal(t, StatusBadRequest, client.request("ANNOUNCE", "stream/camera", map[string]string{"Content-Type": "application/sdp"}, testSDP).status,
+		"the path already has a publisher")
+	assert.Equal(t, StatusNotImplemented, client.request("REDIRECT", "stream/camera", nil, "").status)
+	assert.Equal(t, StatusOK, client.request("OPTIONS", "stream/camera", nil, "").status)
+}
+
+func TestServerWaitsForPublisher(t *testing.T) {
+	server, _ := startTestServer(t)
+	server.SetPublisherWait(func(path string) time.Duration {
+		if path == "stream/ondemand" {
+			return 5 * time.Second
+		}
+		return 0
+	})
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		// the reader is waiting for the publisher when the stream is published
+		time.Sleep(200 * time.Millisecond)
+		publish(t, server, "stream/ondemand")
+	}()
+	reader := newTestClient(t, server, testUser)
+	resp := reader.request("DESCRIBE", "stream/ondemand", nil, "")
+	require.Equal(t, StatusOK, resp.status)
+	assert.Equal(t, testSDP, resp.body)
+	<-published
+
+	client := newTestClient(t, server, testUser)
+	assert.Equal(t, StatusNotFound, client.request("DESCRIBE", "stream/other", nil, "").status,
+		"the readers of the other paths do not wait")
+
+	server.SetPublisherWait(func(string) time.Duration { return 100 * time.Millisecond })
+	start := time.Now()
+	assert.Equal(t, StatusNotFound, client.request("DESCRIBE", "stream/other", nil, "").status)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "the reader waits until the timeout")
+}
+
+func TestServerClose(t *testing.T) {
+	server, _ := startTestServer(t)
+	publisher := publish(t, server, "stream/camera")
+	require.NoError(t, server.Close())
+
+	require.NoError(t, publisher.conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err := publisher.reader.ReadByte()
+	assert.Error(t, err, "the connections are closed")
+	_, err = net.Dial("tcp", server.Addr().String())
+	assert.Error(t, err, "the server does not accept connections anymore")
+}
+
+func TestParseTransport(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected transport
+	}{
+		{"RTP/AVP/TCP;unicast;interleaved=2-3", transport{tcp: true, interleaved: 2}},
+		{"RTP/AVP/TCP;unicast", transport{tcp: true, interleaved: -1}},
+		{"RTP/AVP;unicast;client_port=5000-5001", transport{tcp: false, interleaved: -1}},
+		{"RTP/AVP;unicast;client_port=5000-5001,RTP/AVP/TCP;unicast;interleaved=0-1", transport{tcp: true, interleaved: 0}},
+		{"", transport{tcp: false, interleaved: -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseTransport(tt.value))
+		})
+	}
+}
+
+func TestParseSDPControls(t *testing.T) {
+	sdp := "v=0\r\na=control:*\r\nm=video 0 RTP/AVP 96\r\na=control:streamid=0\r\nm=audio 0 RTP/AVP 97\r\n"
+	assert.Equal(t, []string{"streamid=0", ""}, parseSDPControls([]byte(sdp)))
+}